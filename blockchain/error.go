@@ -80,6 +80,10 @@ var (
 	// maximum allowance of the current block.
 	ErrGasLimit = errors.New("exceeds block gas limit")
 
+	// ErrExceedMaxTxGas is returned if a transaction's requested gas limit exceeds
+	// the maximum amount of gas a transaction can use, which is set by governance.
+	ErrExceedMaxTxGas = errors.New("exceeds max tx gas")
+
 	// ErrNegativeValue is a sanity error to ensure noone is able to specify a
 	// transaction with a negative value.
 	ErrNegativeValue = errors.New("negative value")
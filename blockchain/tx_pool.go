@@ -100,6 +100,12 @@ type blockChain interface {
 	GetBalanceCache() common.Cache
 }
 
+// GovernanceReader provides the current values of governance items which are
+// enforced by the tx pool.
+type GovernanceReader interface {
+	GetGovernanceValue(key int) interface{}
+}
+
 // TxPoolConfig are the configuration parameters of the transaction pool.
 type TxPoolConfig struct {
 	NoLocals        bool          // Whether local transaction handling should be disabled
@@ -169,6 +175,7 @@ type TxPool struct {
 	chainconfig  *params.ChainConfig
 	chain        blockChain
 	gasPrice     *big.Int
+	governance   GovernanceReader
 	txFeed       event.Feed
 	scope        event.SubscriptionScope
 	chainHeadCh  chan ChainHeadEvent
@@ -604,6 +611,15 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// SetGovernance sets the governance from which the tx pool reads the maximum
+// amount of gas a transaction can use.
+func (pool *TxPool) SetGovernance(governance GovernanceReader) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.governance = governance
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction) error {
@@ -700,6 +716,14 @@ func (pool *TxPool) validateTx(tx *types.Transaction) error {
 		return ErrIntrinsicGas
 	}
 
+	// Reject transactions using more gas than the governance allows
+	if pool.governance != nil {
+		if maxTxGas, ok := pool.governance.GetGovernanceValue(params.ConstMaxTxGas).(uint64); ok && tx.Gas() > maxTxGas {
+			logger.Trace("[tx_pool] gas exceeds the max tx gas", "gas", tx.Gas(), "maxTxGas", maxTxGas)
+			return ErrExceedMaxTxGas
+		}
+	}
+
 	// "tx.Validate()" conducts additional validation for each new txType.
 	// Validate humanReadable address when this tx has "true" in the humanReadable field.
 	// Validate accountKey when the this create or update an account
@@ -277,6 +277,36 @@ func TestInvalidTransactions(t *testing.T) {
 	}
 }
 
+// testGovernance is a GovernanceReader returning fixed governance values.
+type testGovernance map[int]interface{}
+
+func (g testGovernance) GetGovernanceValue(key int) interface{} {
+	return g[key]
+}
+
+func TestMaxTxGas(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	tx := transaction(0, 100000, key)
+	from, _ := deriveSender(tx)
+	pool.currentState.AddBalance(from, big.NewInt(0xffffffffffffff))
+
+	// A transaction using more gas than the governance allows is rejected
+	pool.SetGovernance(testGovernance{params.ConstMaxTxGas: uint64(99999)})
+	if err := pool.AddRemote(tx); err != ErrExceedMaxTxGas {
+		t.Error("expected", ErrExceedMaxTxGas, "got", err)
+	}
+
+	// A transaction using as much gas as the governance allows is accepted
+	pool.SetGovernance(testGovernance{params.ConstMaxTxGas: uint64(100000)})
+	if err := pool.AddRemote(tx); err != nil {
+		t.Error("expected", nil, "got", err)
+	}
+}
+
 func TestTransactionQueue(t *testing.T) {
 	t.Parallel()
 
@@ -207,11 +207,12 @@ type CacheConfiger interface {
 
 type LRUConfig struct {
 	CacheSize int
+	OnEvicted func(key interface{}, value interface{}) // Called when an item is evicted or purged. It can be nil
 }
 
 func (c LRUConfig) newCache() (Cache, error) {
 	cacheSize := c.CacheSize * calculateScale()
-	lru, err := lru.New(cacheSize)
+	lru, err := lru.NewWithEvict(cacheSize, c.OnEvicted)
 	return &lruCache{lru}, err
 }
 
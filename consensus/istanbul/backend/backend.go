@@ -142,6 +142,11 @@ func (sb *backend) GetSubGroupSize() uint64 {
 	return sb.governance.ChainConfig.Istanbul.SubGroupSize
 }
 
+// IsForkEnabled returns true if the named fork is activated at the given block by the governance fork schedule.
+func (sb *backend) IsForkEnabled(name string, num uint64) bool {
+	return sb.governance.IsForkEnabled(name, num)
+}
+
 func (sb *backend) SetCurrentView(view *istanbul.View) {
 	sb.currentView.Store(view)
 }
@@ -363,6 +368,22 @@ func (sb *backend) ParentValidators(proposal istanbul.Proposal) istanbul.Validat
 	return validator.NewValidatorSet(nil, istanbul.ProposerPolicy(sb.governance.ChainConfig.Istanbul.ProposerPolicy), sb.governance.ChainConfig.Istanbul.SubGroupSize, sb.chain)
 }
 
+// ValidatorSetAt returns a copy of the validator set of the snapshot at the given block of the canonical chain.
+func (sb *backend) ValidatorSetAt(number uint64) (istanbul.ValidatorSet, error) {
+	if sb.chain == nil {
+		return nil, errUnknownBlock
+	}
+	header := sb.chain.GetHeaderByNumber(number)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	snap, err := sb.snapshot(sb.chain, number, header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return snap.ValSet.Copy(), nil
+}
+
 func (sb *backend) getValidators(number uint64, hash common.Hash) istanbul.ValidatorSet {
 	snap, err := sb.snapshot(sb.chain, number, hash, nil)
 	if err != nil {
@@ -188,6 +188,9 @@ func (s *Snapshot) apply(headers []*types.Header, gov *governance.Governance, ad
 			snap.Epoch, snap.Policy, snap.CommitteeSize = getGovernanceValue(gov, number)
 			snap.Votes = make([]governance.GovernanceVote, 0)
 			snap.Tally = make([]governance.GovernanceTallyItem, 0)
+		} else {
+			// A governance change deferred by the confirmation depth is applied once its epoch boundary is confirmed
+			gov.ApplyPendingGovernance()
 		}
 	}
 	snap.Number += uint64(len(headers))
@@ -201,6 +204,7 @@ func (s *Snapshot) apply(headers []*types.Header, gov *governance.Governance, ad
 
 	gov.SetTotalVotingPower(snap.ValSet.TotalVotingPower())
 	gov.SetMyVotingPower(snap.getMyVotingPower(addr))
+	gov.SetValidatorSet(snap.ValSet)
 
 	return snap, nil
 }
@@ -154,6 +154,10 @@ web3._extend({
 			name: 'nodeAddress',
 			getter: 'governance_nodeAddress',
 		}),
+		new web3._extend.Property({
+			name: 'itemsFingerprint',
+			getter: 'governance_itemsFingerprint',
+		}),
 	]
 });
 `
@@ -468,7 +468,7 @@ func updateStakingCache(bc *blockchain.BlockChain, blockNum uint64) (*StakingInf
 }
 
 const (
-	maxStakingLimit        = uint64(100000000000)
+	maxStakingLimit        = params.MaxStakingLimit
 	DefaultGiniCoefficient = -1.0
 )
 
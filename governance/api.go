@@ -25,7 +25,6 @@ import (
 	"math/big"
 	"reflect"
 	"strings"
-	"sync/atomic"
 )
 
 type PublicGovernanceAPI struct {
@@ -122,12 +121,13 @@ func (api *PublicGovernanceAPI) isRemovingSelf(val interface{}) bool {
 
 func (api *PublicGovernanceAPI) ShowTally() []*returnTally {
 	ret := []*returnTally{}
+	snap := api.governance.Snapshot()
 
-	for _, val := range api.governance.GovernanceTallies.Copy() {
+	for _, val := range snap.Tallies {
 		item := &returnTally{
 			Key:                val.Key,
 			Value:              val.Value,
-			ApprovalPercentage: float64(val.Votes) / float64(atomic.LoadUint64(&api.governance.totalVotingPower)) * 100,
+			ApprovalPercentage: float64(val.Votes) / float64(snap.TotalVotingPower) * 100,
 		}
 		ret = append(ret, item)
 	}
@@ -139,7 +139,7 @@ func (api *PublicGovernanceAPI) TotalVotingPower() (float64, error) {
 	if !api.isGovernanceModeBallot() {
 		return 0, errNotAvailableInThisMode
 	}
-	return float64(atomic.LoadUint64(&api.governance.totalVotingPower)) / 1000.0, nil
+	return float64(api.governance.Snapshot().TotalVotingPower) / 1000.0, nil
 }
 
 func (api *PublicGovernanceAPI) ItemsAt(num *rpc.BlockNumber) (map[string]interface{}, error) {
@@ -167,10 +167,8 @@ type VoteList struct {
 func (api *PublicGovernanceAPI) MyVotes() []*VoteList {
 
 	ret := []*VoteList{}
-	api.governance.voteMapLock.RLock()
-	defer api.governance.voteMapLock.RUnlock()
 
-	for k, v := range api.governance.voteMap {
+	for k, v := range api.governance.Snapshot().VoteMap {
 		item := &VoteList{
 			Key:      k,
 			Value:    v.Value,
@@ -187,7 +185,12 @@ func (api *PublicGovernanceAPI) MyVotingPower() (float64, error) {
 	if !api.isGovernanceModeBallot() {
 		return 0, errNotAvailableInThisMode
 	}
-	return float64(atomic.LoadUint64(&api.governance.votingPower)) / 1000.0, nil
+	return float64(api.governance.Snapshot().VotingPower) / 1000.0, nil
+}
+
+// ItemsFingerprint returns currently applied governance items as strings which can be compared with other nodes'.
+func (api *PublicGovernanceAPI) ItemsFingerprint() map[string]string {
+	return api.governance.ItemsFingerprint()
 }
 
 func (api *PublicGovernanceAPI) ChainConfig() *params.ChainConfig {
@@ -195,7 +198,7 @@ func (api *PublicGovernanceAPI) ChainConfig() *params.ChainConfig {
 }
 
 func (api *PublicGovernanceAPI) NodeAddress() common.Address {
-	return api.governance.nodeAddress
+	return api.governance.Snapshot().NodeAddress
 }
 
 func (api *PublicGovernanceAPI) isGovernanceModeBallot() bool {
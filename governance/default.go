@@ -17,31 +17,62 @@
 package governance
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"github.com/klaytn/klaytn/blockchain"
+	"github.com/klaytn/klaytn/blockchain/types"
 	"github.com/klaytn/klaytn/common"
+	"github.com/klaytn/klaytn/consensus/istanbul"
+	"github.com/klaytn/klaytn/crypto"
 	"github.com/klaytn/klaytn/log"
 	"github.com/klaytn/klaytn/params"
 	"github.com/klaytn/klaytn/ser/rlp"
 	"github.com/klaytn/klaytn/storage/database"
 	"github.com/pkg/errors"
+	"io/ioutil"
+	"math"
 	"math/big"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 var (
-	ErrValueTypeMismatch  = errors.New("Value's type mismatch")
-	ErrDecodeGovChange    = errors.New("Failed to decode received governance changes")
-	ErrUnmarshalGovChange = errors.New("Failed to unmarshal received governance changes")
-	ErrVoteValueMismatch  = errors.New("Received change mismatches with the value this node has!!")
-	ErrNotInitialized     = errors.New("Cache not initialized")
-	ErrItemNotFound       = errors.New("Failed to find governance item")
-	ErrItemNil            = errors.New("Governance Item is nil")
+	ErrValueTypeMismatch         = errors.New("Value's type mismatch")
+	ErrDecodeGovChange           = errors.New("Failed to decode received governance changes")
+	ErrUnmarshalGovChange        = errors.New("Failed to unmarshal received governance changes")
+	ErrVoteValueMismatch         = errors.New("Received change mismatches with the value this node has!!")
+	ErrNotInitialized            = errors.New("Cache not initialized")
+	ErrItemNotFound              = errors.New("Failed to find governance item")
+	ErrItemNil                   = errors.New("Governance Item is nil")
+	ErrZeroCommitteeSize         = errors.New("istanbul.committeesize should be greater than 0")
+	ErrUnknownStateVersion       = errors.New("Unknown version of governance state")
+	ErrIncompleteGovernanceState = errors.New("Governance state doesn't have a chain config")
+	ErrConflictingEngines        = errors.New("Both clique and istanbul are configured. Only one consensus engine can be used")
+	ErrReadOnly                  = errors.New("Governance is read-only")
+	ErrNoIstanbulConfig          = errors.New("Istanbul config is required to read governance")
+	ErrInvalidCacheConfig        = errors.New("Governance cache sizes should be positive")
+	ErrUnknownVoteType           = errors.New("Unknown type of vote value")
+	ErrNegativeRadius            = errors.New("Preloading radius should not be negative")
+	ErrUnknownKey                = errors.New("Unknown governance key")
+	ErrGovernanceSetFull         = errors.New("Governance set can't have more items")
+	ErrResetNotAllowed           = errors.New("Resetting governance to genesis is not allowed")
+	ErrResetBehindGovernance     = errors.New("Governance is already written at or after the current epoch")
+	ErrInvalidForkSchedule       = errors.New("Fork schedule should be a list of name:block separated by commas")
+	ErrInvalidBlockRange         = errors.New("The start of a block range should not be greater than the end")
+	ErrDecodeVote                = errors.New("Failed to decode a vote")
+	ErrInvalidVote               = errors.New("Invalid vote")
+	ErrVoteNotFound              = errors.New("No vote for the key")
+	ErrVoteAlreadyCasted         = errors.New("The vote for the key is already casted")
+	ErrMissingHeader             = errors.New("Header is missing in the blockchain")
+	ErrImmutableGovChange        = errors.New("Received changes include an immutable chain config field")
 )
 
 var (
@@ -62,6 +93,15 @@ var (
 		"governance.addvalidator":       params.AddValidator,
 		"governance.removevalidator":    params.RemoveValidator,
 		"param.txgashumanreadable":      params.ConstTxGasHumanReadable,
+		"governance.blockgaslimit":      params.BlockGasLimit,
+		"governance.targetgasperblock":  params.TargetGasPerBlock,
+		"governance.basefeedenominator": params.BaseFeeDenominator,
+		"reward.kiraddress":             params.KIRAddress,
+		"reward.pocaddress":             params.PoCAddress,
+		"param.maxtxgas":                params.ConstMaxTxGas,
+		"governance.forkschedule":       params.ForkSchedule,
+		"param.humanreadableaddress":    params.ConstHumanReadableAddress,
+		"reward.addressbook":            params.AddressBookAddress,
 	}
 
 	GovernanceForbiddenKeyMap = map[string]int{
@@ -70,24 +110,44 @@ var (
 		"reward.proposerupdateinterval": params.ProposerRefreshInterval,
 	}
 
+	// GovernanceImmutableFields are the chain config fields identifying a chain, which can't be changed by governance.
+	// A key whose field name is one of them is rejected regardless of its category (e.g., "governance.chainid").
+	GovernanceImmutableFields = map[string]struct{}{
+		"chainid":     {},
+		"networkid":   {},
+		"engine":      {},
+		"consensus":   {},
+		"useistanbul": {},
+		"useclique":   {},
+	}
+
 	GovernanceKeyMapReverse = map[int]string{
-		params.GovernanceMode:          "governance.governancemode",
-		params.GoverningNode:           "governance.governingnode",
-		params.Epoch:                   "istanbul.epoch",
-		params.CliqueEpoch:             "clique.epoch",
-		params.Policy:                  "istanbul.policy",
-		params.CommitteeSize:           "istanbul.committeesize",
-		params.UnitPrice:               "governance.unitprice",
-		params.MintingAmount:           "reward.mintingamount",
-		params.Ratio:                   "reward.ratio",
-		params.UseGiniCoeff:            "reward.useginicoeff",
-		params.DeferredTxFee:           "reward.deferredtxfee",
-		params.MinimumStake:            "reward.minimumstake",
-		params.StakeUpdateInterval:     "reward.stakingupdateinterval",
-		params.ProposerRefreshInterval: "reward.proposerupdateinterval",
-		params.AddValidator:            "governance.addvalidator",
-		params.RemoveValidator:         "governance.removevalidator",
-		params.ConstTxGasHumanReadable: "param.txgashumanreadable",
+		params.GovernanceMode:            "governance.governancemode",
+		params.GoverningNode:             "governance.governingnode",
+		params.Epoch:                     "istanbul.epoch",
+		params.CliqueEpoch:               "clique.epoch",
+		params.Policy:                    "istanbul.policy",
+		params.CommitteeSize:             "istanbul.committeesize",
+		params.UnitPrice:                 "governance.unitprice",
+		params.MintingAmount:             "reward.mintingamount",
+		params.Ratio:                     "reward.ratio",
+		params.UseGiniCoeff:              "reward.useginicoeff",
+		params.DeferredTxFee:             "reward.deferredtxfee",
+		params.MinimumStake:              "reward.minimumstake",
+		params.StakeUpdateInterval:       "reward.stakingupdateinterval",
+		params.ProposerRefreshInterval:   "reward.proposerupdateinterval",
+		params.AddValidator:              "governance.addvalidator",
+		params.RemoveValidator:           "governance.removevalidator",
+		params.ConstTxGasHumanReadable:   "param.txgashumanreadable",
+		params.BlockGasLimit:             "governance.blockgaslimit",
+		params.TargetGasPerBlock:         "governance.targetgasperblock",
+		params.BaseFeeDenominator:        "governance.basefeedenominator",
+		params.KIRAddress:                "reward.kiraddress",
+		params.PoCAddress:                "reward.pocaddress",
+		params.ConstMaxTxGas:             "param.maxtxgas",
+		params.ForkSchedule:              "governance.forkschedule",
+		params.ConstHumanReadableAddress: "param.humanreadableaddress",
+		params.AddressBookAddress:        "reward.addressbook",
 	}
 
 	ProposerPolicyMap = map[string]int{
@@ -111,10 +171,12 @@ var (
 
 var logger = log.NewModuleLogger(log.Governance)
 
-// Governance item set
+// GovernanceSet is a set of governance items which is copied on write.
+// A published map of items is never modified, so readers load the last published one without a lock
+// and don't contend with writers. Writers are serialized by mu and publish a modified copy.
 type GovernanceSet struct {
-	items map[string]interface{}
-	mu    *sync.RWMutex
+	items *atomic.Value // map[string]interface{}
+	mu    *sync.Mutex
 }
 
 // Governance represents vote information given from istanbul.vote()
@@ -122,6 +184,12 @@ type GovernanceVote struct {
 	Validator common.Address `json:"validator"`
 	Key       string         `json:"key"`
 	Value     interface{}    `json:"value"`
+
+	// BlockNumber is the block where the vote was recorded. It isn't a part of the vote in a header
+	BlockNumber uint64 `json:"blockNumber" rlp:"-"`
+
+	// VotingPower is the voting power tallied for the vote by TallyVotes. It isn't a part of the vote in a header
+	VotingPower uint64 `json:"votingPower,omitempty" rlp:"-"`
 }
 
 // GovernanceTallies represents a tally for each governance item
@@ -141,9 +209,10 @@ type GovernanceVotes struct {
 }
 
 type VoteStatus struct {
-	Value  interface{} `json:"value"`
-	Casted bool        `json:"casted"`
-	Num    uint64      `json:"num"`
+	Value   interface{} `json:"value"`
+	Casted  bool        `json:"casted"`
+	Num     uint64      `json:"num"`
+	Emitted uint64      `json:"emitted"` // The block number where the vote was put into a header last time
 }
 
 type Governance struct {
@@ -157,6 +226,10 @@ type Governance struct {
 	totalVotingPower uint64
 	votingPower      uint64
 
+	// Voting powers of validators used to weight their votes. If empty, the voting powers in the validator set are used
+	votingPowers     map[common.Address]uint64
+	votingPowersLock sync.RWMutex
+
 	GovernanceVotes   GovernanceVotes
 	GovernanceTallies GovernanceTallyList
 
@@ -164,18 +237,144 @@ type Governance struct {
 	itemCache common.Cache
 	idxCache  []uint64
 
+	itemCacheSize int // The number of governance items kept in itemCache
+	idxCacheLimit int // The number of governance block numbers kept in idxCache
+
+	// A contiguous range of governance block numbers whose items are preloaded by PreloadAround.
+	// preloadedUntil is the governance block number right after the range, or MaxUint64 if there is none
+	preloadedIdx   []uint64
+	preloadedUntil uint64
+	preloadedLock  sync.RWMutex
+
 	// The block number when current governance information was changed
 	actualGovernanceBlock uint64
 
 	// The last block number at governance state was stored (used not to replay old votes)
 	lastGovernanceStateBlock uint64
 
+	// The last block number at governance state was stored by RemoveVote
+	lastVoteStateBlock uint64
+
+	// governanceStateLock serializes checking and writing governance state
+	governanceStateLock sync.Mutex
+
+	// readOnly prevents writing anything to the database
+	readOnly bool
+
+	// Governance items of the genesis and whether ResetToGenesis is allowed
+	genesisItems        map[string]interface{}
+	allowResetToGenesis bool
+
+	// allowForbiddenKeys allows votes for the keys in GovernanceForbiddenKeyMap on test chains
+	allowForbiddenKeys bool
+
+	// compressState makes governance state written compressed
+	compressState bool
+
+	// Retries of reading governance items from the database on transient errors
+	dbReadRetries int
+	dbReadBackoff time.Duration
+
 	currentSet GovernanceSet
 	changeSet  GovernanceSet
 
+	// The values of governance items whose changes were triggered lastly
+	triggeredSet  map[string]interface{}
+	triggeredLock sync.Mutex
+
+	// Callbacks invoked once with all the changed items after their changes are triggered
+	batchTriggers     []func(map[string]interface{})
+	batchTriggersLock sync.Mutex
+
+	// Channels of the subscribers to the changes of istanbul.* items
+	istanbulSubs     []chan map[string]interface{}
+	istanbulSubsLock sync.Mutex
+
 	TxPool *blockchain.TxPool
 
 	blockChain *blockchain.BlockChain
+
+	// voteAuthorizer confirms that the validator of a vote was allowed to vote at the given block
+	voteAuthorizer func(common.Address, uint64) bool
+
+	// The number of epochs in which a changed item can't be changed again. 0 means no limitation
+	changeCooldownEpochs uint64
+
+	// The number of blocks which should be built on an epoch boundary before its governance change is applied.
+	// 0 applies it at once
+	confirmationDepth uint64
+	// The epoch boundary whose governance change waits for the confirmation. 0 means none
+	pendingGovernanceBlock uint64
+
+	// The maximum change of the committee size by a vote. 0 means no limitation
+	committeeSizeRamp uint64
+
+	// The number of recent epochs whose governance items are cached when the blockchain is set. 0 disables warmup
+	cacheWarmupEpochs uint64
+
+	// The validator set of the latest snapshot, which is used to check the votes submitted by AddVote
+	valSet     istanbul.ValidatorSet
+	valSetLock sync.RWMutex
+
+	// Watchers notified when an applied governance value satisfies their predicate
+	valueWatchers   map[uint64]*valueWatcher
+	valueWatchersID uint64
+	valueWatchersMu sync.Mutex
+
+	// The applied governance items loaded on boot and the callbacks waiting for them
+	initializedSet    map[string]interface{}
+	initCallbacks     []func(map[string]interface{})
+	initCallbacksLock sync.Mutex
+
+	// Metrics of the applied governance items registered by RegisterMetrics
+	metrics     *governanceMetrics
+	metricsLock sync.Mutex
+}
+
+// valueWatcher is a subscription made by WatchValue
+type valueWatcher struct {
+	key       string
+	predicate func(interface{}) bool
+	ch        chan uint64
+}
+
+// GovernanceKeysByCategory returns the keys in GovernanceKeyMap grouped by their namespace such as "reward".
+// The keys in each category are sorted.
+func GovernanceKeysByCategory() map[string][]string {
+	ret := make(map[string][]string)
+	for key := range GovernanceKeyMap {
+		category := key
+		if idx := strings.Index(key, "."); idx >= 0 {
+			category = key[:idx]
+		}
+		ret[category] = append(ret[category], key)
+	}
+	for _, keys := range ret {
+		sort.Strings(keys)
+	}
+	return ret
+}
+
+// GovernanceItemMeta returns whether each key in GovernanceKeyMap can be changed by a vote and the type of its value,
+// so that clients can disable editing of the items in GovernanceForbiddenKeyMap.
+func GovernanceItemMeta() map[string]struct {
+	Mutable bool
+	Type    string
+} {
+	ret := make(map[string]struct {
+		Mutable bool
+		Type    string
+	}, len(GovernanceKeyMap))
+	for key, item := range GovernanceKeyMap {
+		meta := ret[key]
+		_, forbidden := GovernanceForbiddenKeyMap[key]
+		meta.Mutable = !forbidden
+		if c, ok := GovernanceItems[item]; ok {
+			meta.Type = c.t.String()
+		}
+		ret[key] = meta
+	}
+	return ret
 }
 
 func NewGovernanceTallies() GovernanceTallyList {
@@ -241,100 +440,347 @@ func (gv *GovernanceVotes) Import(src []GovernanceVote) {
 	copy(gv.items, src)
 }
 
+// ByValidator returns the votes cast by the given validator.
+func (gv *GovernanceVotes) ByValidator(addr common.Address) []GovernanceVote {
+	gv.mu.RLock()
+	defer gv.mu.RUnlock()
+
+	ret := make([]GovernanceVote, 0)
+	for _, vote := range gv.items {
+		if vote.Validator == addr {
+			ret = append(ret, vote)
+		}
+	}
+	return ret
+}
+
+// SortedByBlock returns a copy of the votes ordered by the block number where they were recorded.
+// Votes recorded at the same block keep their order.
+func (gv *GovernanceVotes) SortedByBlock() []GovernanceVote {
+	ret := gv.Copy()
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].BlockNumber < ret[j].BlockNumber
+	})
+	return ret
+}
+
+// maxGovernanceSetSize is the maximum number of items a GovernanceSet can hold
+var maxGovernanceSetSize = 64
+
 func NewGovernanceSet() GovernanceSet {
-	return GovernanceSet{
-		items: map[string]interface{}{},
-		mu:    new(sync.RWMutex),
+	gs := GovernanceSet{
+		items: new(atomic.Value),
+		mu:    new(sync.Mutex),
+	}
+	gs.items.Store(map[string]interface{}{})
+	return gs
+}
+
+// load returns the last published items. The returned map must not be modified.
+func (gs *GovernanceSet) load() map[string]interface{} {
+	return gs.items.Load().(map[string]interface{})
+}
+
+// copyForWrite returns a copy of the last published items to be modified and published by a writer holding mu
+func (gs *GovernanceSet) copyForWrite() map[string]interface{} {
+	items := gs.load()
+	ret := make(map[string]interface{}, len(items)+1)
+	for k, v := range items {
+		ret[k] = v
 	}
+	return ret
 }
 
 func (gs *GovernanceSet) Clear() {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
 
-	gs.items = make(map[string]interface{})
+	gs.items.Store(make(map[string]interface{}))
 }
 
 func (gs *GovernanceSet) SetValue(itemType int, value interface{}) error {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
 
-	key := GovernanceKeyMapReverse[itemType]
-
+	key, ok := GovernanceKeyMapReverse[itemType]
+	if _, registered := GovernanceKeyMap[key]; !ok || !registered {
+		return ErrUnknownKey
+	}
 	if GovernanceItems[itemType].t != reflect.TypeOf(value) {
 		return ErrValueTypeMismatch
 	}
-	gs.items[key] = value
+	items := gs.load()
+	if _, exists := items[key]; !exists && len(items) >= maxGovernanceSetSize {
+		return ErrGovernanceSetFull
+	}
+	newItems := gs.copyForWrite()
+	newItems[key] = value
+	gs.items.Store(newItems)
 	return nil
 }
 
+// SetValues sets all the given items. Unlike SetValue, it doesn't stop at an invalid item;
+// the valid items are set and the errors of the invalid ones are returned in the order of the item types.
+func (gs *GovernanceSet) SetValues(items map[int]interface{}) []error {
+	keys := make([]int, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	var errs []error
+	for _, k := range keys {
+		if err := gs.SetValue(k, items[k]); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to set %s(%d)", GovernanceKeyMapReverse[k], k))
+		}
+	}
+	return errs
+}
+
 func (gs *GovernanceSet) GetValue(key int) (interface{}, bool) {
 	sKey, ok := GovernanceKeyMapReverse[key]
 	if !ok {
 		return nil, false
 	}
 
-	gs.mu.RLock()
-	defer gs.mu.RUnlock()
-	ret, ok := gs.items[sKey]
+	ret, ok := gs.load()[sKey]
 	return ret, ok
 }
 
+// GetValueByName returns the value of the item of the given key name such as "governance.unitprice".
+// The name is case-insensitive and surrounding whitespaces are ignored.
+func (gs *GovernanceSet) GetValueByName(name string) (interface{}, bool) {
+	key, ok := GovernanceKeyMap[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, false
+	}
+	return gs.GetValue(key)
+}
+
+// GetUint64 returns the uint64 value of the given key. If the key is absent or its type mismatches, def is returned.
+func (gs *GovernanceSet) GetUint64(key int, def uint64) uint64 {
+	if v, ok := gs.GetValue(key); ok {
+		if ret, ok := v.(uint64); ok {
+			return ret
+		}
+	}
+	return def
+}
+
+// GetString returns the string value of the given key. If the key is absent or its type mismatches, def is returned.
+func (gs *GovernanceSet) GetString(key int, def string) string {
+	if v, ok := gs.GetValue(key); ok {
+		if ret, ok := v.(string); ok {
+			return ret
+		}
+	}
+	return def
+}
+
+// GetBool returns the bool value of the given key. If the key is absent or its type mismatches, def is returned.
+func (gs *GovernanceSet) GetBool(key int, def bool) bool {
+	if v, ok := gs.GetValue(key); ok {
+		if ret, ok := v.(bool); ok {
+			return ret
+		}
+	}
+	return def
+}
+
+// GetAddress returns the address value of the given key. If the key is absent or its type mismatches, def is returned.
+func (gs *GovernanceSet) GetAddress(key int, def common.Address) common.Address {
+	if v, ok := gs.GetValue(key); ok {
+		if ret, ok := v.(common.Address); ok {
+			return ret
+		}
+	}
+	return def
+}
+
 func (gs *GovernanceSet) RemoveItem(key string) {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
 
-	delete(gs.items, key)
+	items := gs.copyForWrite()
+	delete(items, key)
+	gs.items.Store(items)
 }
 
-func (gs *GovernanceSet) Size() int {
-	gs.mu.RLock()
-	defer gs.mu.RUnlock()
+// RemoveItems removes the items of the given keys at once, so readers never see only some of them removed.
+func (gs *GovernanceSet) RemoveItems(keys []string) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	items := gs.copyForWrite()
+	for _, key := range keys {
+		delete(items, key)
+	}
+	gs.items.Store(items)
+}
 
-	return len(gs.items)
+func (gs *GovernanceSet) Size() int {
+	return len(gs.load())
 }
 
 func (gs *GovernanceSet) Import(src map[string]interface{}) {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
 
-	gs.items = make(map[string]interface{})
+	items := make(map[string]interface{}, len(src))
 	for k, v := range src {
-		gs.items[k] = v
+		if _, ok := GovernanceKeyMap[k]; !ok {
+			logger.Warn("Unknown governance key is ignored", "key", k)
+			continue
+		}
+		items[k] = v
 	}
+	gs.items.Store(items)
 }
 
 func (gs *GovernanceSet) Items() map[string]interface{} {
-	gs.mu.Lock()
-	defer gs.mu.Unlock()
-
-	ret := make(map[string]interface{})
-	for k, v := range gs.items {
+	items := gs.load()
+	ret := make(map[string]interface{}, len(items))
+	for k, v := range items {
 		ret[k] = v
 	}
 	return ret
 }
 
 func (gs *GovernanceSet) Merge(change map[string]interface{}) {
+	gs.MergeWithReport(change)
+}
+
+// GovernanceItemChange is an old and a new value of a governance item overwritten by MergeWithReport
+type GovernanceItemChange struct {
+	Old, New interface{}
+}
+
+// MergeWithReport merges the change into the set and returns the items whose existing values were overwritten
+// by different values. Newly added items are not reported. Unknown keys and new items exceeding
+// the size limit are ignored.
+func (gs *GovernanceSet) MergeWithReport(change map[string]interface{}) map[string]GovernanceItemChange {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
 
+	items := gs.copyForWrite()
+	report := make(map[string]GovernanceItemChange)
 	for k, v := range change {
-		gs.items[k] = v
+		if _, ok := GovernanceKeyMap[k]; !ok {
+			logger.Warn("Unknown governance key is ignored", "key", k)
+			continue
+		}
+		old, exists := items[k]
+		if !exists && len(items) >= maxGovernanceSetSize {
+			logger.Warn("Governance set is full. The item is ignored", "key", k, "size", len(items))
+			continue
+		}
+		if exists && !reflect.DeepEqual(old, v) {
+			report[k] = GovernanceItemChange{Old: old, New: v}
+		}
+		items[k] = v
 	}
+	gs.items.Store(items)
+	return report
 }
 
-func NewGovernance(chainConfig *params.ChainConfig, dbm database.DBManager) *Governance {
-	ret := Governance{
+// CacheConfig contains the sizes of caches used by Governance.
+type CacheConfig struct {
+	ItemCacheSize int // The number of governance items cached
+	IdxCacheLimit int // The number of block numbers of governance changes cached
+}
+
+// DefaultCacheConfig returns the cache config used by NewGovernance.
+func DefaultCacheConfig() CacheConfig {
+	return CacheConfig{
+		ItemCacheSize: params.GovernanceCacheLimit,
+		IdxCacheLimit: params.GovernanceIdxCacheLimit,
+	}
+}
+
+func newGovernance(chainConfig *params.ChainConfig, dbm database.DBManager) *Governance {
+	return newGovernanceWithCacheConfig(chainConfig, dbm, DefaultCacheConfig())
+}
+
+func newGovernanceWithCacheConfig(chainConfig *params.ChainConfig, dbm database.DBManager, cacheConfig CacheConfig) *Governance {
+	// Keep the genesis items since ChainConfig is changed by governance
+	var genesisItems map[string]interface{}
+	if chainConfig != nil {
+		genesisSet := getGovernanceItemsFromChainConfig(chainConfig)
+		genesisItems = genesisSet.Items()
+		// Missing items are not defaulted, so they should be noticed before they surface as nil
+		if missing := missingGovernanceItems(chainConfig, genesisSet); len(missing) > 0 {
+			logger.Warn("Required governance items are missing in chain config", "items", missing)
+		}
+	}
+
+	return &Governance{
+		genesisItems:             genesisItems,
 		ChainConfig:              chainConfig,
 		voteMap:                  make(map[string]VoteStatus),
 		db:                       dbm,
-		itemCache:                newGovernanceCache(),
+		itemCache:                newGovernanceCache(cacheConfig.ItemCacheSize, nil),
+		itemCacheSize:            cacheConfig.ItemCacheSize,
+		idxCacheLimit:            cacheConfig.IdxCacheLimit,
 		currentSet:               NewGovernanceSet(),
 		changeSet:                NewGovernanceSet(),
 		lastGovernanceStateBlock: 0,
 		GovernanceTallies:        NewGovernanceTallies(),
 		GovernanceVotes:          NewGovernanceVotes(),
+		valueWatchers:            make(map[uint64]*valueWatcher),
+		dbReadRetries:            defaultDBReadRetries,
+		dbReadBackoff:            defaultDBReadBackoff,
+	}
+}
+
+// NewGovernanceReadOnly returns a governance which reads governance information from an existing database.
+// Unlike NewGovernance, it never writes to the database and returns an error if no governance is found.
+// It can be used by tools inspecting a database.
+func NewGovernanceReadOnly(chainConfig *params.ChainConfig, dbm database.DBManager) (*Governance, error) {
+	if dbm == nil {
+		return nil, ErrNotInitialized
+	}
+	if chainConfig == nil || chainConfig.Istanbul == nil {
+		return nil, ErrNoIstanbulConfig
+	}
+	ret := newGovernance(chainConfig, dbm)
+	ret.readOnly = true
+
+	if err := ret.loadCache(); err != nil {
+		return nil, err
+	}
+	if b, err := dbm.ReadGovernanceState(); err == nil {
+		if err := ret.loadGovernanceState(b); err != nil {
+			return nil, err
+		}
+	}
+	ret.markInitialized()
+	return ret, nil
+}
+
+func NewGovernance(chainConfig *params.ChainConfig, dbm database.DBManager) *Governance {
+	ret, _ := NewGovernanceWithCacheConfig(chainConfig, dbm, DefaultCacheConfig())
+	return ret
+}
+
+// NewGovernanceInMemory returns a governance backed by a memory database instead of a real one.
+// Unlike NewGovernance with a nil database, governance items and state are written and read as usual,
+// so the whole governance lifecycle can be run for simulations. Everything is lost when it's dropped.
+func NewGovernanceInMemory(chainConfig *params.ChainConfig) *Governance {
+	return NewGovernance(chainConfig, database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB}))
+}
+
+// NewGovernanceWithCacheConfig returns a governance using caches of the given sizes.
+// For example, archive nodes can keep more indices cached for fast historical reads.
+// It returns an error if a cache size is not positive.
+func NewGovernanceWithCacheConfig(chainConfig *params.ChainConfig, dbm database.DBManager, cacheConfig CacheConfig) (*Governance, error) {
+	if cacheConfig.ItemCacheSize <= 0 || cacheConfig.IdxCacheLimit <= 0 {
+		return nil, ErrInvalidCacheConfig
+	}
+	ret := newGovernanceWithCacheConfig(chainConfig, dbm, cacheConfig)
+	if chainConfig != nil {
+		if err := checkConsensusEngine(chainConfig); err != nil {
+			logger.Crit("Invalid governance configuration", "err", err)
+		}
 	}
 	// nil is for testing or simple function usage
 	if dbm != nil {
@@ -350,8 +796,47 @@ func NewGovernance(chainConfig *params.ChainConfig, dbm database.DBManager) *Gov
 			}
 		}
 		ret.ReadGovernanceState()
+		ret.markInitialized()
+	}
+	return ret, nil
+}
+
+// OnInitialized registers a callback invoked once with the applied governance items loaded on boot,
+// so that dependent subsystems can be initialized from them deterministically.
+// If the items are already loaded, the callback is invoked immediately. A governance without a database
+// never loads the items, so the callback is never invoked.
+func (g *Governance) OnInitialized(fn func(map[string]interface{})) {
+	g.initCallbacksLock.Lock()
+	if g.initializedSet == nil {
+		g.initCallbacks = append(g.initCallbacks, fn)
+		g.initCallbacksLock.Unlock()
+		return
+	}
+	items := copyItems(g.initializedSet)
+	g.initCallbacksLock.Unlock()
+
+	fn(items)
+}
+
+// markInitialized keeps the applied governance items loaded on boot and invokes the callbacks waiting for them.
+func (g *Governance) markInitialized() {
+	g.initCallbacksLock.Lock()
+	g.initializedSet = g.currentSet.Items()
+	callbacks := g.initCallbacks
+	g.initCallbacks = nil
+	g.initCallbacksLock.Unlock()
+
+	for _, fn := range callbacks {
+		fn(copyItems(g.initializedSet))
+	}
+}
+
+func copyItems(src map[string]interface{}) map[string]interface{} {
+	dst := make(map[string]interface{}, len(src))
+	for k, v := range src {
+		dst[k] = v
 	}
-	return &ret
+	return dst
 }
 
 func (g *Governance) SetNodeAddress(addr common.Address) {
@@ -366,14 +851,203 @@ func (g *Governance) SetMyVotingPower(t uint64) {
 	atomic.StoreUint64(&g.votingPower, t)
 }
 
+// SetValidatorSet sets the validator set of the latest snapshot. AddVote rejects a vote adding a validator
+// already in the set or removing a validator not in the set.
+func (g *Governance) SetValidatorSet(valset istanbul.ValidatorSet) {
+	g.valSetLock.Lock()
+	defer g.valSetLock.Unlock()
+	g.valSet = valset
+}
+
+// validatorSet returns the validator set set by SetValidatorSet. It returns nil if it isn't set yet.
+func (g *Governance) validatorSet() istanbul.ValidatorSet {
+	g.valSetLock.RLock()
+	defer g.valSetLock.RUnlock()
+	return g.valSet
+}
+
+// SetVoteAuthorizer sets a function which confirms that the claimed validator of a vote was actually
+// in the committee at the given block. Votes from unauthorized validators are dropped before tallying.
+func (g *Governance) SetVoteAuthorizer(fn func(common.Address, uint64) bool) {
+	g.voteAuthorizer = fn
+}
+
+// SetChangeCooldown sets the number of epochs in which a changed governance item can't be changed again.
+// Votes for an item changed within the last `epochs` epochs are rejected. 0 disables the limitation.
+// Because it affects the tally, all nodes in a network should have the same value.
+func (g *Governance) SetChangeCooldown(epochs uint64) {
+	g.changeCooldownEpochs = epochs
+}
+
+// SetCacheWarmup sets the number of recent epochs whose governance items are read into the item cache
+// when the blockchain is set, so that the first reads of recent blocks don't access the database.
+// 0 disables the warmup.
+func (g *Governance) SetCacheWarmup(epochs uint64) {
+	g.cacheWarmupEpochs = epochs
+}
+
+// SetConfirmationDepth sets the number of blocks which should be built on an epoch boundary block before
+// UpdateCurrentGovernance applies the governance change of the boundary. It keeps a shallow reorganization around
+// an epoch boundary from flapping the governance. A change which is not confirmed yet is applied by
+// ApplyPendingGovernance on a later block. 0 applies a change at once, which is the default.
+func (g *Governance) SetConfirmationDepth(depth uint64) {
+	g.confirmationDepth = depth
+}
+
+// isConfirmed returns true if the given block is buried by the confirmation depth from the head of the blockchain
+func (g *Governance) isConfirmed(num uint64) bool {
+	if g.confirmationDepth == 0 || g.blockChain == nil {
+		return true
+	}
+	return g.blockChain.CurrentHeader().Number.Uint64() >= num+g.confirmationDepth
+}
+
+// ApplyPendingGovernance applies the governance change of the epoch boundary deferred by the confirmation depth
+// once the boundary block is confirmed. It is called for the blocks after an epoch boundary.
+func (g *Governance) ApplyPendingGovernance() {
+	num := atomic.LoadUint64(&g.pendingGovernanceBlock)
+	if num == 0 || !g.isConfirmed(num) {
+		return
+	}
+	g.UpdateCurrentGovernance(num)
+}
+
+// SetCommitteeSizeRamp sets the maximum change of the committee size per epoch. A vote for a committee size which differs
+// from the one used for the block of the vote by more than maxDelta is rejected rather than clamped,
+// because a clamped value wouldn't be what the validators voted for. 0 disables the limitation.
+// Because it affects the tally, all nodes in a network should have the same value.
+func (g *Governance) SetCommitteeSizeRamp(maxDelta uint64) {
+	g.committeeSizeRamp = maxDelta
+}
+
+// SetDBReadRetry sets how many times reading governance items from the database is retried on a transient error.
+// The backoff is doubled after each retry, and the total wait of a read is capped by maxDBReadWait, since governance
+// is read while blocks are processed. 0 retries disables retrying.
+func (g *Governance) SetDBReadRetry(retries int, backoff time.Duration) {
+	g.dbReadRetries = retries
+	g.dbReadBackoff = backoff
+}
+
+// SetStateCompression sets whether governance state is written compressed.
+// Governance state is read regardless of whether it was written compressed or not.
+func (g *Governance) SetStateCompression(enabled bool) {
+	g.compressState = enabled
+}
+
+// SetResetToGenesisAllowed sets whether ResetToGenesis is allowed. It is disallowed by default.
+// It must not be allowed in production networks, since a node which resets its governance diverges from the others.
+func (g *Governance) SetResetToGenesisAllowed(allowed bool) {
+	g.allowResetToGenesis = allowed
+}
+
+// SetForbiddenKeysAllowed sets whether the keys in GovernanceForbiddenKeyMap can be voted, e.g., to change
+// reward.stakingupdateinterval on a test chain. They are forbidden by default. It must not be allowed in production
+// networks, and all nodes in a network should have the same value since it affects the tally.
+func (g *Governance) SetForbiddenKeysAllowed(allowed bool) {
+	g.allowForbiddenKeys = allowed
+}
+
+// isForbiddenKey returns true if a vote for the key is not allowed
+func (g *Governance) isForbiddenKey(key string) bool {
+	if g.allowForbiddenKeys {
+		return false
+	}
+	_, ok := GovernanceForbiddenKeyMap[key]
+	return ok
+}
+
+// ResetToGenesis discards all governance changes and votes and applies the governance of the genesis again.
+// The genesis governance is written as a governance change at the epoch boundary of the current block,
+// so the governance of the past blocks is kept and the genesis governance is used for the following blocks.
+// If governance is already written at or after the boundary, it returns ErrResetBehindGovernance, since the change
+// may be committed in a header. The reset is local to this node and isn't propagated through headers, so every node
+// of the network should be reset at the same epoch. Otherwise the nodes fork.
+// It is for test networks and returns ErrResetNotAllowed unless it's allowed by SetResetToGenesisAllowed.
+func (g *Governance) ResetToGenesis() error {
+	if !g.allowResetToGenesis {
+		return ErrResetNotAllowed
+	}
+	if g.readOnly {
+		return ErrReadOnly
+	}
+	if g.db == nil || g.blockChain == nil || g.genesisItems == nil {
+		return ErrNotInitialized
+	}
+
+	head := g.blockChain.CurrentHeader().Number.Uint64()
+	num := head - head%g.ChainConfig.Istanbul.Epoch
+	indices, err := g.db.ReadRecentGovernanceIdx(1)
+	if err != nil {
+		return err
+	}
+	if len(indices) > 0 && indices[len(indices)-1] >= num {
+		return ErrResetBehindGovernance
+	}
+
+	genesis := NewGovernanceSet()
+	genesis.Import(g.genesisItems)
+	if err := g.db.WriteGovernance(genesis.Items(), num); err != nil {
+		return err
+	}
+
+	g.voteMapLock.Lock()
+	g.voteMap = make(map[string]VoteStatus)
+	g.voteMapLock.Unlock()
+	g.GovernanceVotes.Clear()
+	g.GovernanceTallies.Clear()
+	g.changeSet.Clear()
+
+	g.preloadedLock.Lock()
+	g.preloadedIdx = nil
+	g.preloadedLock.Unlock()
+
+	g.itemCache.Add(getGovernanceCacheKey(num), genesis.Items())
+	g.addIdxCache(num)
+	atomic.StoreUint64(&g.actualGovernanceBlock, num)
+	g.currentSet.Import(genesis.Items())
+	g.triggerChange(genesis.Items())
+	g.notifyValueWatchers(num, genesis.Items())
+
+	logger.Warn("Governance is reset to the genesis", "num", num)
+	return nil
+}
+
+// SetVotingPowers sets the voting powers of validators. Votes are weighted by these powers when they are tallied,
+// and a validator not in the map has no voting power. Setting nil or an empty map makes the voting powers
+// in the validator set used again.
+func (g *Governance) SetVotingPowers(powers map[common.Address]uint64) {
+	copied := make(map[common.Address]uint64, len(powers))
+	for addr, power := range powers {
+		copied[addr] = power
+	}
+
+	g.votingPowersLock.Lock()
+	defer g.votingPowersLock.Unlock()
+	g.votingPowers = copied
+}
+
+// GetEncodedVote returns an encoded vote which is not casted yet to be put into the header of the given block.
+// A vote emitted at another block in the same epoch is not emitted again, even if it was not marked as casted
+// because RemoveVote failed to match its value.
+// Votes are emitted in the order of their keys, so the order is deterministic.
 func (g *Governance) GetEncodedVote(addr common.Address, number uint64) []byte {
 	// TODO-Klaytn-Governance Change this part to add all votes to the header at once
-	g.voteMapLock.RLock()
-	defer g.voteMapLock.RUnlock()
+	g.voteMapLock.Lock()
+	defer g.voteMapLock.Unlock()
 
 	if len(g.voteMap) > 0 {
-		for key, val := range g.voteMap {
+		keys := make([]string, 0, len(g.voteMap))
+		for key := range g.voteMap {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			val := g.voteMap[key]
 			if val.Casted == false {
+				if g.isEmittedInEpoch(val, number) {
+					continue
+				}
 				vote := new(GovernanceVote)
 				vote.Validator = addr
 				vote.Key = key
@@ -381,9 +1055,11 @@ func (g *Governance) GetEncodedVote(addr common.Address, number uint64) []byte {
 				encoded, err := rlp.EncodeToBytes(vote)
 				if err != nil {
 					logger.Error("Failed to RLP Encode a vote", "vote", vote)
-					g.RemoveVote(key, val, number)
+					g.voteMap[key] = VoteStatus{Value: val.Value, Casted: true, Num: number}
 					continue
 				}
+				val.Emitted = number
+				g.voteMap[key] = val
 				return encoded
 			}
 		}
@@ -391,6 +1067,46 @@ func (g *Governance) GetEncodedVote(addr common.Address, number uint64) []byte {
 	return nil
 }
 
+// GetEncodedVoteForKey returns the encoded vote for the given key if it is not casted yet.
+// Unlike GetEncodedVote, the vote is returned even if it was emitted in the same epoch, so it can be used to retry
+// a specific governance change. It returns ErrVoteNotFound if there is no vote for the key
+// and ErrVoteAlreadyCasted if the vote is already casted.
+func (g *Governance) GetEncodedVoteForKey(addr common.Address, number uint64, key string) ([]byte, error) {
+	key = g.getKey(key)
+
+	g.voteMapLock.Lock()
+	defer g.voteMapLock.Unlock()
+
+	val, ok := g.voteMap[key]
+	if !ok {
+		return nil, ErrVoteNotFound
+	}
+	if val.Casted {
+		return nil, ErrVoteAlreadyCasted
+	}
+
+	vote := &GovernanceVote{Validator: addr, Key: key, Value: val.Value}
+	encoded, err := rlp.EncodeToBytes(vote)
+	if err != nil {
+		logger.Error("Failed to RLP Encode a vote", "vote", vote)
+		g.voteMap[key] = VoteStatus{Value: val.Value, Casted: true, Num: number}
+		return nil, err
+	}
+	val.Emitted = number
+	g.voteMap[key] = val
+	return encoded, nil
+}
+
+// isEmittedInEpoch returns true if the vote was emitted at another block in the same epoch with the given block.
+// Emitting again at the same block is allowed since the previous proposal of the block could have failed.
+func (g *Governance) isEmittedInEpoch(status VoteStatus, number uint64) bool {
+	if status.Emitted == 0 || status.Emitted == number || g.ChainConfig.Istanbul == nil || g.ChainConfig.Istanbul.Epoch == 0 {
+		return false
+	}
+	epoch := g.ChainConfig.Istanbul.Epoch
+	return status.Emitted/epoch == number/epoch
+}
+
 func (g *Governance) getKey(k string) string {
 	return strings.Trim(strings.ToLower(k), " ")
 }
@@ -398,7 +1114,6 @@ func (g *Governance) getKey(k string) string {
 // RemoveVote remove a vote from the voteMap to prevent repetitive addition of same vote
 func (g *Governance) RemoveVote(key string, value interface{}, number uint64) {
 	g.voteMapLock.Lock()
-	defer g.voteMapLock.Unlock()
 
 	key = g.getKey(key)
 	if g.voteMap[key].Value == value {
@@ -408,9 +1123,9 @@ func (g *Governance) RemoveVote(key string, value interface{}, number uint64) {
 			Num:    number,
 		}
 	}
-	if g.CanWriteGovernanceState(number) {
-		g.WriteGovernanceState(number, false)
-	}
+	g.voteMapLock.Unlock()
+
+	g.WriteGovernanceStateIfNewer(number, false)
 }
 
 func (g *Governance) ClearVotes(num uint64) {
@@ -435,11 +1150,12 @@ func (g *Governance) ParseVoteValue(gVote *GovernanceVote) (*GovernanceVote, err
 	}
 
 	switch k {
-	case params.GovernanceMode, params.MintingAmount, params.MinimumStake, params.Ratio:
+	case params.GovernanceMode, params.MintingAmount, params.MinimumStake, params.Ratio, params.ForkSchedule:
 		val = string(gVote.Value.([]uint8))
-	case params.GoverningNode, params.AddValidator, params.RemoveValidator:
+	case params.GoverningNode, params.AddValidator, params.RemoveValidator, params.KIRAddress, params.PoCAddress, params.AddressBookAddress:
 		val = common.BytesToAddress(gVote.Value.([]uint8))
-	case params.Epoch, params.CommitteeSize, params.UnitPrice, params.StakeUpdateInterval, params.ProposerRefreshInterval, params.ConstTxGasHumanReadable, params.Policy:
+	case params.Epoch, params.CommitteeSize, params.UnitPrice, params.StakeUpdateInterval, params.ProposerRefreshInterval, params.ConstTxGasHumanReadable, params.Policy, params.BlockGasLimit,
+		params.TargetGasPerBlock, params.BaseFeeDenominator, params.ConstMaxTxGas:
 		gVote.Value = append(make([]byte, 8-len(gVote.Value.([]uint8))), gVote.Value.([]uint8)...)
 		val = binary.BigEndian.Uint64(gVote.Value.([]uint8))
 	case params.UseGiniCoeff, params.DeferredTxFee:
@@ -449,6 +1165,20 @@ func (g *Governance) ParseVoteValue(gVote *GovernanceVote) (*GovernanceVote, err
 		} else {
 			val = false
 		}
+	case params.ConstHumanReadableAddress:
+		// Only 0 and 1 are accepted, which are the encodings of false and true
+		b := gVote.Value.([]uint8)
+		if len(b) > 8 {
+			return nil, ErrValueTypeMismatch
+		}
+		switch binary.BigEndian.Uint64(append(make([]byte, 8-len(b)), b...)) {
+		case 0:
+			val = false
+		case 1:
+			val = true
+		default:
+			return nil, ErrValueTypeMismatch
+		}
 	default:
 		logger.Warn("Unknown key was given", "key", k)
 	}
@@ -464,13 +1194,14 @@ func (gov *Governance) ReflectVotes(vote GovernanceVote) {
 
 func (gov *Governance) updateChangeSet(vote GovernanceVote) bool {
 	switch GovernanceKeyMap[vote.Key] {
-	case params.GoverningNode:
+	case params.GoverningNode, params.KIRAddress, params.PoCAddress, params.AddressBookAddress:
 		gov.changeSet.SetValue(GovernanceKeyMap[vote.Key], vote.Value.(common.Address))
 		return true
-	case params.GovernanceMode, params.Ratio:
+	case params.GovernanceMode, params.Ratio, params.ForkSchedule:
 		gov.changeSet.SetValue(GovernanceKeyMap[vote.Key], vote.Value.(string))
 		return true
-	case params.Epoch, params.StakeUpdateInterval, params.ProposerRefreshInterval, params.CommitteeSize, params.UnitPrice, params.ConstTxGasHumanReadable:
+	case params.Epoch, params.StakeUpdateInterval, params.ProposerRefreshInterval, params.CommitteeSize, params.UnitPrice, params.ConstTxGasHumanReadable, params.BlockGasLimit,
+		params.TargetGasPerBlock, params.BaseFeeDenominator, params.ConstMaxTxGas:
 		gov.changeSet.SetValue(GovernanceKeyMap[vote.Key], vote.Value.(uint64))
 		return true
 	case params.Policy:
@@ -479,7 +1210,7 @@ func (gov *Governance) updateChangeSet(vote GovernanceVote) bool {
 	case params.MintingAmount, params.MinimumStake:
 		gov.changeSet.SetValue(GovernanceKeyMap[vote.Key], vote.Value.(string))
 		return true
-	case params.UseGiniCoeff, params.DeferredTxFee:
+	case params.UseGiniCoeff, params.DeferredTxFee, params.ConstHumanReadableAddress:
 		gov.changeSet.SetValue(GovernanceKeyMap[vote.Key], vote.Value.(bool))
 		return true
 	default:
@@ -524,57 +1255,107 @@ func GetDefaultCliqueConfig() *params.CliqueConfig {
 	}
 }
 
-func CheckGenesisValues(c *params.ChainConfig) error {
-	gov := NewGovernance(c, nil)
-
-	var tstMap = map[string]interface{}{
-		"istanbul.epoch":                c.Istanbul.Epoch,
-		"istanbul.committeesize":        c.Istanbul.SubGroupSize,
-		"istanbul.policy":               uint64(c.Istanbul.ProposerPolicy),
-		"governance.governancemode":     c.Governance.GovernanceMode,
-		"governance.governingnode":      c.Governance.GoverningNode,
-		"governance.unitprice":          c.UnitPrice,
-		"reward.ratio":                  c.Governance.Reward.Ratio,
-		"reward.useginicoeff":           c.Governance.Reward.UseGiniCoeff,
-		"reward.deferredtxfee":          c.Governance.Reward.DeferredTxFee,
-		"reward.mintingamount":          c.Governance.Reward.MintingAmount.String(),
-		"reward.minimumstake":           c.Governance.Reward.MinimumStake.String(),
-		"reward.stakingupdateinterval":  c.Governance.Reward.StakingUpdateInterval,
-		"reward.proposerupdateinterval": c.Governance.Reward.ProposerUpdateInterval,
-	}
-
-	for k, v := range tstMap {
-		if _, ok := gov.ValidateVote(&GovernanceVote{Key: k, Value: v}); !ok {
-			return errors.New(k + " value is wrong")
-		}
+// checkConsensusEngine checks that the config doesn't have governance items of both clique and istanbul.
+func checkConsensusEngine(c *params.ChainConfig) error {
+	if c.Clique != nil && c.Istanbul != nil {
+		return ErrConflictingEngines
 	}
 	return nil
 }
 
-func newGovernanceCache() common.Cache {
-	cache := common.NewCache(common.LRUConfig{CacheSize: params.GovernanceCacheLimit})
-	return cache
+// CheckGenesisValues returns the first error found in the governance values of the genesis.
+func CheckGenesisValues(c *params.ChainConfig) error {
+	if errs := ValidateGenesisGovernance(c); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
 }
 
-func (g *Governance) initializeCache() error {
-	// get last n governance change block number
-	indices, err := g.db.ReadRecentGovernanceIdx(params.GovernanceCacheLimit)
-	if err != nil {
-		return ErrNotInitialized
+// ValidateGenesisGovernance validates all governance values of the genesis and returns every error found,
+// so that all of them can be fixed at once. Errors are ordered by the key of the governance item.
+func ValidateGenesisGovernance(c *params.ChainConfig) []error {
+	var errs []error
+
+	if err := checkConsensusEngine(c); err != nil {
+		errs = append(errs, err)
+		// Values are validated as an Istanbul network, since NewGovernance doesn't accept conflicting engines
+		copied := *c
+		copied.Clique = nil
+		c = &copied
 	}
-	g.idxCache = indices
-	// Put governance items into the itemCache
-	for _, v := range indices {
-		if num, data, err := g.ReadGovernance(v); err == nil {
-			g.itemCache.Add(getGovernanceCacheKey(num), data)
-			g.actualGovernanceBlock = num
+
+	gov := NewGovernance(c, nil)
+
+	var tstMap = map[string]interface{}{
+		"governance.unitprice": c.UnitPrice,
+	}
+
+	if c.Istanbul != nil {
+		// A committee can't be made without any member, so consensus would halt from the genesis block
+		if c.Istanbul.SubGroupSize == 0 {
+			errs = append(errs, ErrZeroCommitteeSize)
 		} else {
-			logger.Crit("Couldn't read governance cache from database. Check database consistency", "index", v, "err", err)
+			tstMap["istanbul.committeesize"] = c.Istanbul.SubGroupSize
+		}
+		tstMap["istanbul.epoch"] = c.Istanbul.Epoch
+		tstMap["istanbul.policy"] = uint64(c.Istanbul.ProposerPolicy)
+	}
+
+	if c.Governance != nil {
+		tstMap["governance.governancemode"] = c.Governance.GovernanceMode
+		tstMap["governance.governingnode"] = c.Governance.GoverningNode
+		tstMap["reward.ratio"] = c.Governance.Reward.Ratio
+		tstMap["reward.useginicoeff"] = c.Governance.Reward.UseGiniCoeff
+		tstMap["reward.deferredtxfee"] = c.Governance.Reward.DeferredTxFee
+		tstMap["reward.mintingamount"] = c.Governance.Reward.MintingAmount.String()
+		tstMap["reward.minimumstake"] = c.Governance.Reward.MinimumStake.String()
+		tstMap["reward.stakingupdateinterval"] = c.Governance.Reward.StakingUpdateInterval
+		tstMap["reward.proposerupdateinterval"] = c.Governance.Reward.ProposerUpdateInterval
+	}
+
+	keys := make([]string, 0, len(tstMap))
+	for k := range tstMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if _, ok := gov.ValidateVote(&GovernanceVote{Key: k, Value: tstMap[k]}); !ok {
+			errs = append(errs, errors.New(k+" value is wrong"))
+		}
+	}
+	return errs
+}
+
+func (g *Governance) initializeCache() error {
+	err := g.loadCache()
+	if err != nil && err != ErrNotInitialized {
+		logger.Crit("Couldn't read governance cache from database. Check database consistency", "err", err)
+	}
+	return err
+}
+
+// loadCache loads recent governance items from the database into the cache.
+// It returns ErrNotInitialized if no governance is stored in the database.
+func (g *Governance) loadCache() error {
+	// get last n governance change block number
+	indices, err := g.db.ReadRecentGovernanceIdx(g.itemCacheSize)
+	if err != nil || len(indices) == 0 {
+		return ErrNotInitialized
+	}
+	g.idxCache = indices
+	// Put governance items into the itemCache
+	for _, v := range indices {
+		num, data, err := g.ReadGovernance(v)
+		if err != nil {
+			return fmt.Errorf("failed to read governance at %d: %v", v, err)
 		}
+		g.itemCache.Add(getGovernanceCacheKey(num), data)
+		atomic.StoreUint64(&g.actualGovernanceBlock, num)
 	}
 
 	// the last one is the one to be used now
-	ret, _ := g.itemCache.Get(getGovernanceCacheKey(g.actualGovernanceBlock))
+	ret, _ := g.itemCache.Get(getGovernanceCacheKey(atomic.LoadUint64(&g.actualGovernanceBlock)))
 	g.currentSet.Import(ret.(map[string]interface{}))
 	return nil
 }
@@ -589,6 +1370,69 @@ func (g *Governance) getGovernanceCache(num uint64) (map[string]interface{}, boo
 	return nil, false
 }
 
+// VerifyCacheConsistency compares cached governance items with the ones stored in the database
+// and returns block numbers of governance items which are different from the database.
+func (g *Governance) VerifyCacheConsistency() []uint64 {
+	divergent := []uint64{}
+	if g.db == nil {
+		return divergent
+	}
+
+	for _, num := range g.idxCache {
+		cached, ok := g.getGovernanceCache(num)
+		if !ok {
+			continue
+		}
+		stored, err := g.db.ReadGovernance(num)
+		if err != nil || !reflect.DeepEqual(cached, adjustDecodedSet(stored)) {
+			logger.Warn("Governance cache mismatches with the database", "num", num, "err", err)
+			divergent = append(divergent, num)
+		}
+	}
+	return divergent
+}
+
+// selfTestRequiredKeys are the governance items which should be applied in any network
+var selfTestRequiredKeys = []int{params.GovernanceMode, params.GoverningNode, params.UnitPrice}
+
+// selfTestIstanbulKeys are the governance items which should be applied in an istanbul network
+var selfTestIstanbulKeys = []int{params.Epoch, params.Policy, params.CommitteeSize}
+
+// SelfTest checks whether the governance was booted correctly. It returns an error describing the first problem
+// found among the cache, the applied governance items and the epoch, so it can be called by node startup diagnostics.
+func (g *Governance) SelfTest() error {
+	if len(g.idxCache) == 0 {
+		return errors.New("governance cache has no governance block")
+	}
+	last := g.idxCache[len(g.idxCache)-1]
+	if _, ok := g.getGovernanceCache(last); !ok {
+		return fmt.Errorf("governance items of the last governance block %d are not cached", last)
+	}
+
+	if g.currentSet.Size() == 0 {
+		return errors.New("no governance item is applied")
+	}
+	required := selfTestRequiredKeys
+	if g.ChainConfig.Istanbul != nil {
+		required = append(append([]int{}, required...), selfTestIstanbulKeys...)
+	}
+	for _, key := range required {
+		if _, ok := g.currentSet.GetValue(key); !ok {
+			return fmt.Errorf("required governance item %s is not applied", GovernanceKeyMapReverse[key])
+		}
+	}
+
+	if g.ChainConfig.Istanbul != nil {
+		if epoch := g.currentSet.GetUint64(params.Epoch, 0); epoch == 0 {
+			return errors.New("applied istanbul.epoch is zero")
+		}
+		if g.ChainConfig.Istanbul.Epoch == 0 {
+			return errors.New("epoch of the istanbul config is zero")
+		}
+	}
+	return nil
+}
+
 func (g *Governance) addGovernanceCache(num uint64, data GovernanceSet) {
 	// Don't update cache if num (block number) is smaller than the biggest number of cached block number
 	if len(g.idxCache) > 0 && num <= g.idxCache[len(g.idxCache)-1] {
@@ -605,27 +1449,247 @@ func getGovernanceCacheKey(num uint64) common.GovernanceCacheKey {
 	return common.GovernanceCacheKey(params.GovernanceCachePrefix + "_" + v)
 }
 
+// newGovernanceCache returns an LRU cache of governance items of the given size.
+// The block number of an evicted entry is logged and counted by governanceCacheEvictedCounter,
+// then passed to onEvicted if it is not nil. Entries removed by purging the cache are handled the same.
+func newGovernanceCache(size int, onEvicted func(num uint64)) common.Cache {
+	return common.NewCache(common.LRUConfig{
+		CacheSize: size,
+		OnEvicted: func(key interface{}, value interface{}) {
+			k, ok := key.(common.GovernanceCacheKey)
+			if !ok {
+				return
+			}
+			num, err := strconv.ParseUint(strings.TrimPrefix(string(k), params.GovernanceCachePrefix+"_"), 10, 64)
+			if err != nil {
+				return
+			}
+			logger.Trace("Governance cache entry is evicted", "blockNumber", num)
+			governanceCacheEvictedCounter.Inc(1)
+			if onEvicted != nil {
+				onEvicted(num)
+			}
+		},
+	})
+}
+
+// InvalidateCacheAbove drops the cached and preloaded governance items of the governance blocks above num,
+// e.g., orphaned by a reorg, so that they are read from the database again.
+func (g *Governance) InvalidateCacheAbove(num uint64) {
+	g.preloadedLock.Lock()
+	defer g.preloadedLock.Unlock()
+
+	kept := make(map[uint64]map[string]interface{})
+	for _, idx := range append(append([]uint64{}, g.idxCache...), g.preloadedIdx...) {
+		if idx > num {
+			continue
+		}
+		if data, ok := g.getGovernanceCache(idx); ok {
+			kept[idx] = data
+		}
+	}
+
+	pos := sort.Search(len(g.idxCache), func(i int) bool { return g.idxCache[i] > num })
+	g.idxCache = g.idxCache[:pos]
+
+	pos = sort.Search(len(g.preloadedIdx), func(i int) bool { return g.preloadedIdx[i] > num })
+	g.preloadedIdx = g.preloadedIdx[:pos]
+	if g.preloadedUntil > num+1 {
+		g.preloadedUntil = num + 1
+	}
+
+	// The cache can't remove an entry, so the entries to be kept are added again after purging it
+	g.itemCache.Purge()
+	for idx, data := range kept {
+		g.itemCache.Add(getGovernanceCacheKey(idx), data)
+	}
+	logger.Info("Governance cache is invalidated", "above", num)
+}
+
 func (g *Governance) addIdxCache(num uint64) {
 	g.idxCache = append(g.idxCache, num)
-	if len(g.idxCache) > params.GovernanceIdxCacheLimit {
-		g.idxCache = g.idxCache[len(g.idxCache)-params.GovernanceIdxCacheLimit:]
+	if len(g.idxCache) > g.idxCacheLimit {
+		g.idxCache = g.idxCache[len(g.idxCache)-g.idxCacheLimit:]
 	}
 }
 
 // Store new governance data on DB. This updates Governance cache too.
 func (g *Governance) WriteGovernance(num uint64, data GovernanceSet, delta GovernanceSet) error {
+	if g.readOnly {
+		return ErrReadOnly
+	}
 
 	new := NewGovernanceSet()
 	new.Import(data.Items())
 
 	// merge delta into data
 	if delta.Size() > 0 {
-		new.Merge(delta.Items())
+		for k, c := range new.MergeWithReport(delta.Items()) {
+			logger.Info("Governance item is changed", "num", num, "key", k, "old", c.Old, "new", c.New)
+		}
 	}
 	g.addGovernanceCache(num, new)
 	return g.db.WriteGovernance(new.Items(), num)
 }
 
+// MigrateAddressEncoding rewrites the governance items stored in the database whose address values are
+// in a legacy string form (e.g., checksummed) to the canonical encoding of common.Address.
+// The governance state is written again as a checkpoint, so it doesn't keep the legacy form either.
+// Values which are not valid addresses are left as they are.
+func (g *Governance) MigrateAddressEncoding() error {
+	if g.readOnly {
+		return ErrReadOnly
+	}
+	if g.db == nil {
+		return ErrNotInitialized
+	}
+
+	indices, err := g.db.ReadRecentGovernanceIdx(0)
+	if err != nil {
+		return err
+	}
+	migrated := 0
+	for _, num := range indices {
+		data, err := g.db.ReadGovernance(num)
+		if err != nil {
+			return err
+		}
+		if !migrateAddressItems(data) {
+			continue
+		}
+		if err := g.db.OverwriteGovernance(data, num); err != nil {
+			return err
+		}
+		migrated++
+		logger.Info("Migrated the address encoding of governance items", "num", num)
+	}
+
+	// The items in memory are normalized before the governance state is written again
+	g.currentSet.Import(adjustDecodedSet(g.currentSet.Items()))
+	g.changeSet.Import(adjustDecodedSet(g.changeSet.Items()))
+	if _, err := g.db.ReadGovernanceState(); err == nil {
+		if err := g.WriteGovernanceState(atomic.LoadUint64(&g.lastGovernanceStateBlock), true); err != nil {
+			return err
+		}
+	}
+	logger.Info("Finished migrating the address encoding of governance items", "migrated", migrated, "total", len(indices))
+	return nil
+}
+
+// migrateAddressItems replaces the address values of the given items in a legacy string form with common.Address.
+// It returns true if any item is replaced.
+func migrateAddressItems(data map[string]interface{}) bool {
+	changed := false
+	for k, v := range data {
+		key, ok := GovernanceKeyMap[k]
+		if !ok || GovernanceItems[key].t != addressT {
+			continue
+		}
+		s, isString := v.(string)
+		if !isString {
+			continue
+		}
+		if !common.IsHexAddress(s) {
+			logger.Warn("Governance item is not a valid address", "key", k, "value", s)
+			continue
+		}
+		addr := common.HexToAddress(s)
+		if canonical, _ := addr.MarshalText(); string(canonical) != s {
+			data[k] = addr
+			changed = true
+		}
+	}
+	return changed
+}
+
+// readGovernanceAtIdx returns governance items stored at the given governance block number
+func (g *Governance) readGovernanceAtIdx(num uint64) (map[string]interface{}, error) {
+	if data, ok := g.getGovernanceCache(num); ok {
+		return withDefaultItems(data), nil
+	}
+	if g.db == nil {
+		return nil, ErrNotInitialized
+	}
+	data, err := g.db.ReadGovernance(num)
+	if err != nil {
+		return nil, err
+	}
+	return withDefaultItems(adjustDecodedSet(data)), nil
+}
+
+// ChangeEvent is a change of a governance item at a governance block
+type ChangeEvent struct {
+	EpochBlock uint64      `json:"epochBlock"`
+	Key        string      `json:"key"`
+	Old        interface{} `json:"old"`
+	New        interface{} `json:"new"`
+}
+
+// ChangeStream returns every change of governance items written at the governance blocks in [from, to]
+// in the order of block numbers and keys. Each governance block is compared with the previous one, so
+// the governance written at genesis isn't a change. It returns an empty slice if nothing was changed.
+func (g *Governance) ChangeStream(from, to uint64) ([]ChangeEvent, error) {
+	if from > to {
+		return nil, ErrInvalidBlockRange
+	}
+	if g.db == nil {
+		return nil, ErrNotInitialized
+	}
+	indices, err := g.db.ReadRecentGovernanceIdx(0)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []ChangeEvent{}
+	start := sort.Search(len(indices), func(i int) bool { return indices[i] >= from })
+	if start == len(indices) || indices[start] > to {
+		return events, nil
+	}
+
+	var prev map[string]interface{}
+	if start > 0 {
+		if prev, err = g.readGovernanceAtIdx(indices[start-1]); err != nil {
+			return nil, err
+		}
+	}
+	for _, idx := range indices[start:] {
+		if idx > to {
+			break
+		}
+		cur, err := g.readGovernanceAtIdx(idx)
+		if err != nil {
+			return nil, err
+		}
+		if prev != nil {
+			events = append(events, diffGovernanceItems(idx, prev, cur)...)
+		}
+		prev = cur
+	}
+	return events, nil
+}
+
+// diffGovernanceItems returns the changes from prev to cur sorted by keys.
+func diffGovernanceItems(num uint64, prev, cur map[string]interface{}) []ChangeEvent {
+	keys := make([]string, 0, len(cur))
+	for k := range cur {
+		keys = append(keys, k)
+	}
+	for k := range prev {
+		if _, ok := cur[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var events []ChangeEvent
+	for _, k := range keys {
+		if !reflect.DeepEqual(prev[k], cur[k]) {
+			events = append(events, ChangeEvent{EpochBlock: num, Key: k, Old: prev[k], New: cur[k]})
+		}
+	}
+	return events
+}
+
 func (g *Governance) searchCache(num uint64) (uint64, bool) {
 	for i := len(g.idxCache) - 1; i >= 0; i-- {
 		if g.idxCache[i] <= num {
@@ -635,27 +1699,254 @@ func (g *Governance) searchCache(num uint64) (uint64, bool) {
 	return 0, false
 }
 
+// searchPreloaded returns the governance block number for the given block if it is in the preloaded range.
+func (g *Governance) searchPreloaded(num uint64) (uint64, bool) {
+	g.preloadedLock.RLock()
+	defer g.preloadedLock.RUnlock()
+
+	if len(g.preloadedIdx) == 0 || num < g.preloadedIdx[0] || num >= g.preloadedUntil {
+		return 0, false
+	}
+	for i := len(g.preloadedIdx) - 1; i >= 0; i-- {
+		if g.preloadedIdx[i] <= num {
+			return g.preloadedIdx[i], true
+		}
+	}
+	return 0, false
+}
+
+// PreloadAround loads governance items of the governance block numbers within radius of the one
+// used for the block num into the itemCache, so that reading governance around num doesn't access the database.
+// Items which don't fit in the itemCache are evicted as usual.
+func (g *Governance) PreloadAround(num uint64, radius int) error {
+	if radius < 0 {
+		return ErrNegativeRadius
+	}
+	if g.ChainConfig.Istanbul == nil {
+		return ErrNoIstanbulConfig
+	}
+	if g.db == nil {
+		return ErrNotInitialized
+	}
+	indices, err := g.db.ReadRecentGovernanceIdx(0)
+	if err != nil || len(indices) == 0 {
+		return ErrNotInitialized
+	}
+
+	blockNum := CalcGovernanceInfoBlock(num, g.ChainConfig.Istanbul.Epoch)
+	pos := sort.Search(len(indices), func(i int) bool { return indices[i] > blockNum }) - 1
+	if pos < 0 {
+		return ErrItemNotFound
+	}
+
+	from, to := pos-radius, pos+radius
+	if from < 0 {
+		from = 0
+	}
+	if to > len(indices)-1 {
+		to = len(indices) - 1
+	}
+	if to-from+1 > g.itemCacheSize {
+		logger.Warn("Preloaded governance items exceed the cache size", "count", to-from+1, "cacheSize", g.itemCacheSize)
+	}
+
+	for _, idx := range indices[from : to+1] {
+		data, err := g.db.ReadGovernance(idx)
+		if err != nil {
+			return fmt.Errorf("failed to read governance at %d: %v", idx, err)
+		}
+		g.itemCache.Add(getGovernanceCacheKey(idx), adjustDecodedSet(data))
+	}
+
+	until := uint64(math.MaxUint64)
+	if to+1 < len(indices) {
+		until = indices[to+1]
+	}
+
+	g.preloadedLock.Lock()
+	g.preloadedIdx = append([]uint64{}, indices[from:to+1]...)
+	g.preloadedUntil = until
+	g.preloadedLock.Unlock()
+	return nil
+}
+
+// ReadSource is where governance items are read from
+type ReadSource string
+
+const (
+	ReadSourceCache     ReadSource = "cache"     // the recent governance items in the cache
+	ReadSourcePreloaded ReadSource = "preloaded" // the governance items preloaded by PreloadAround
+	ReadSourceDB        ReadSource = "db"        // the database
+	ReadSourceNone      ReadSource = "none"      // nowhere, since there is no database
+)
+
+// ReadTrace describes how ReadGovernanceTraced resolved the governance items of a block.
+type ReadTrace struct {
+	Num             uint64     // The block number requested
+	InfoBlock       uint64     // The block number whose governance is used for Num
+	Source          ReadSource // Where the governance items were read from
+	GovernanceBlock uint64     // The block number where the governance items were written
+}
+
 func (g *Governance) ReadGovernance(num uint64) (uint64, map[string]interface{}, error) {
+	return g.readGovernance(num, nil)
+}
+
+// ReadGovernanceTraced is the same as ReadGovernance, but it also returns how the governance items were resolved.
+// It can be used to debug which governance is used for a block.
+func (g *Governance) ReadGovernanceTraced(num uint64) (uint64, map[string]interface{}, ReadTrace, error) {
+	trace := ReadTrace{Num: num}
+	bn, data, err := g.readGovernance(num, &trace)
+	return bn, data, trace, err
+}
+
+func (g *Governance) readGovernance(num uint64, trace *ReadTrace) (uint64, map[string]interface{}, error) {
 	if g.ChainConfig.Istanbul == nil {
 		logger.Crit("Failed to read governance. ChainConfig.Istanbul == nil")
 	}
 	blockNum := CalcGovernanceInfoBlock(num, g.ChainConfig.Istanbul.Epoch)
+	record := func(source ReadSource, gBlockNum uint64) {
+		if trace != nil {
+			trace.InfoBlock, trace.Source, trace.GovernanceBlock = blockNum, source, gBlockNum
+		}
+	}
+
 	// Check cache first
 	if gBlockNum, ok := g.searchCache(blockNum); ok {
 		if data, okay := g.getGovernanceCache(gBlockNum); okay {
-			return gBlockNum, data, nil
+			record(ReadSourceCache, gBlockNum)
+			return gBlockNum, withDefaultItems(data), nil
+		}
+	}
+	if gBlockNum, ok := g.searchPreloaded(blockNum); ok {
+		if data, okay := g.getGovernanceCache(gBlockNum); okay {
+			record(ReadSourcePreloaded, gBlockNum)
+			return gBlockNum, withDefaultItems(data), nil
 		}
 	}
 	if g.db != nil {
-		bn, result, err := g.db.ReadGovernanceAtNumber(num, g.ChainConfig.Istanbul.Epoch)
-		result = adjustDecodedSet(result)
+		bn, result, err := g.readGovernanceAtNumberWithRetry(num, g.ChainConfig.Istanbul.Epoch)
+		result = withDefaultItems(adjustDecodedSet(result))
+		record(ReadSourceDB, bn)
 		return bn, result, err
 	} else {
 		// For CI tests which don't have a database
+		record(ReadSourceNone, 0)
 		return 0, nil, nil
 	}
 }
 
+// ReadGovernanceBatch returns the governance items used for each of the given blocks like ReadGovernance.
+// The governance block numbers are read once for all the blocks, and the items of a governance block
+// are read once even if it's used for several blocks, e.g., blocks in the same epoch.
+// The blocks using the same governance block share the same map, so it must not be modified.
+func (g *Governance) ReadGovernanceBatch(nums []uint64) (map[uint64]map[string]interface{}, error) {
+	if g.ChainConfig.Istanbul == nil {
+		return nil, ErrNoIstanbulConfig
+	}
+	indices := g.idxCache
+	if g.db != nil {
+		var err error
+		if indices, err = g.db.ReadRecentGovernanceIdx(0); err != nil {
+			return nil, err
+		}
+	}
+
+	epoch := g.ChainConfig.Istanbul.Epoch
+	read := make(map[uint64]map[string]interface{})
+	ret := make(map[uint64]map[string]interface{}, len(nums))
+	for _, num := range nums {
+		if _, ok := ret[num]; ok {
+			continue
+		}
+		infoBlock := CalcGovernanceInfoBlock(num, epoch)
+		pos := sort.Search(len(indices), func(i int) bool { return indices[i] > infoBlock })
+		if pos == 0 {
+			return nil, ErrItemNotFound
+		}
+		gBlockNum := indices[pos-1]
+
+		data, ok := read[gBlockNum]
+		if !ok {
+			var err error
+			if data, err = g.readGovernanceAtIdx(gBlockNum); err != nil {
+				return nil, err
+			}
+			read[gBlockNum] = data
+		}
+		ret[num] = data
+	}
+	return ret, nil
+}
+
+// GovernanceAtBlock returns the governance items in effect at the block num.
+// They are the items written at or before the governance info block of num (see CalcGovernanceInfoBlock),
+// which is the first block of the previous epoch, so that a change is applied after a whole epoch has passed:
+//   - A change written exactly at an epoch boundary block E (E % epoch == 0) is in effect from E+epoch.
+//   - A change written at a block in the middle of the epoch starting at E is in effect from E+2*epoch.
+//   - At an epoch boundary block E, a change written at E or E-1 is not in effect yet.
+//
+// GovernanceEffectiveBlock returns the first block where a change written at a block is in effect.
+func (g *Governance) GovernanceAtBlock(num uint64) (map[string]interface{}, error) {
+	if g.ChainConfig.Istanbul == nil {
+		return nil, ErrNoIstanbulConfig
+	}
+	_, data, err := g.ReadGovernance(num)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrNotInitialized
+	}
+	return data, nil
+}
+
+// GovernanceEffectiveBlock returns the first block where a governance change written at the block num is in effect.
+// See GovernanceAtBlock for the details.
+func GovernanceEffectiveBlock(num uint64, epoch uint64) uint64 {
+	epochStart := num - num%epoch
+	if epochStart == num {
+		return num + epoch
+	}
+	return epochStart + 2*epoch
+}
+
+var (
+	defaultDBReadRetries = 3
+	defaultDBReadBackoff = 100 * time.Millisecond
+)
+
+// maxDBReadWait is the maximum total time a read of governance items waits for retries.
+// Governance is read while blocks are processed, so a read shouldn't be delayed longer than it.
+const maxDBReadWait = time.Second
+
+// readGovernanceAtNumberWithRetry reads governance items from the database with exponential backoff on transient errors.
+// An error meaning that the items don't exist or are corrupted is returned immediately. It gives up when the retries
+// are exhausted or the next backoff would make the total wait exceed maxDBReadWait.
+func (g *Governance) readGovernanceAtNumberWithRetry(num uint64, epoch uint64) (uint64, map[string]interface{}, error) {
+	backoff, waited := g.dbReadBackoff, time.Duration(0)
+	for attempt := 0; ; attempt++ {
+		bn, result, err := g.db.ReadGovernanceAtNumber(num, epoch)
+		if err == nil || attempt >= g.dbReadRetries || !isTransientDBError(err) || waited+backoff > maxDBReadWait {
+			return bn, result, err
+		}
+		logger.Warn("Failed to read governance from database. Retrying", "num", num, "attempt", attempt+1, "backoff", backoff, "err", err)
+		time.Sleep(backoff)
+		waited += backoff
+		backoff *= 2
+	}
+}
+
+// isTransientDBError returns false if the error is returned because data is not found or can't be decoded,
+// since reading again doesn't help in these cases.
+func isTransientDBError(err error) bool {
+	switch err.(type) {
+	case *json.SyntaxError, *json.UnmarshalTypeError:
+		return false
+	}
+	return !database.IsNotFound(err)
+}
+
 func CalcGovernanceInfoBlock(num uint64, epoch uint64) uint64 {
 	governanceInfoBlock := num - (num % epoch)
 	if governanceInfoBlock >= epoch {
@@ -672,14 +1963,10 @@ func (g *Governance) GetGovernanceChange() map[string]interface{} {
 }
 
 func (gov *Governance) UpdateGovernance(number uint64, governance []byte) {
-	var epoch uint64
-	var ok bool
-
-	if epoch, ok = gov.GetGovernanceValue(params.Epoch).(uint64); !ok {
-		if epoch, ok = gov.GetGovernanceValue(params.CliqueEpoch).(uint64); !ok {
-			logger.Error("Couldn't find epoch from governance items")
-			return
-		}
+	epoch := gov.currentSet.GetUint64(params.Epoch, gov.currentSet.GetUint64(params.CliqueEpoch, 0))
+	if epoch == 0 {
+		logger.Error("Couldn't find epoch from governance items")
+		return
 	}
 
 	// Store updated governance information if exist
@@ -692,12 +1979,18 @@ func (gov *Governance) UpdateGovernance(number uint64, governance []byte) {
 				logger.Error("Failed to decode governance data", "number", number, "err", err, "data", governance)
 				return
 			}
-			if err := json.Unmarshal(tempData, &tempItems); err != nil {
+			if err := unmarshalUseNumber(tempData, &tempItems); err != nil {
 				logger.Error("Failed to unmarshal governance data", "number", number, "err", err, "data", tempData)
 				return
 
 			}
 			tempItems = adjustDecodedSet(tempItems)
+			for k := range tempItems {
+				if isImmutableKey(k) {
+					logger.Warn("Ignored an immutable field in governance data", "number", number, "key", k)
+					delete(tempItems, k)
+				}
+			}
 			tempSet.Import(tempItems)
 
 			// Store new currentSet to governance database
@@ -714,46 +2007,439 @@ func (gov *Governance) removeDuplicatedVote(vote *GovernanceVote, number uint64)
 
 func (gov *Governance) UpdateCurrentGovernance(num uint64) {
 	newNumber, newGovernanceSet, _ := gov.ReadGovernance(num)
+	atomic.StoreUint64(&gov.pendingGovernanceBlock, 0)
 
 	// Do the change only when the governance actually changed
-	if newGovernanceSet != nil && newNumber != gov.actualGovernanceBlock {
-		gov.actualGovernanceBlock = newNumber
-		gov.currentSet.Import(newGovernanceSet)
-		gov.triggerChange(newGovernanceSet)
+	if newGovernanceSet != nil && newNumber != atomic.LoadUint64(&gov.actualGovernanceBlock) {
+		if !gov.isConfirmed(num) {
+			atomic.StoreUint64(&gov.pendingGovernanceBlock, num)
+			logger.Debug("Governance change is not confirmed yet", "num", num, "governanceBlock", newNumber, "depth", gov.confirmationDepth)
+			return
+		}
+		gov.applyGovernance(num, newNumber, newGovernanceSet)
 	}
 }
 
+// applyGovernance makes the given governance, which was written at newNumber, the current one.
+func (gov *Governance) applyGovernance(num uint64, newNumber uint64, newGovernanceSet map[string]interface{}) {
+	start := time.Now()
+	prevMode := gov.currentSet.GetString(params.GovernanceMode, "")
+	atomic.StoreUint64(&gov.actualGovernanceBlock, newNumber)
+	gov.currentSet.Import(newGovernanceSet)
+
+	// Votes and tallies made in the previous mode are meaningless in the new mode.
+	// They are cleared at an epoch boundary anyway, but a change deferred by the confirmation depth is applied later
+	if mode := gov.currentSet.GetString(params.GovernanceMode, ""); mode != prevMode {
+		logger.Info("Governance mode changed. Clearing votes", "num", num, "prev", prevMode, "new", mode)
+		gov.ClearVotes(num)
+	}
+
+	triggerStart := time.Now()
+	gov.triggerChange(newGovernanceSet)
+	governanceTriggerTimer.UpdateSince(triggerStart)
+
+	gov.notifyValueWatchers(newNumber, newGovernanceSet)
+	gov.updateMetrics(newGovernanceSet)
+
+	governanceAppliedCounter.Inc(1)
+	governanceApplyTimer.UpdateSince(start)
+	logger.Debug("Applied governance change", "num", num, "governanceBlock", newNumber, "elapsed", time.Since(start))
+}
+
+// WatchValue subscribes to the applied value of the given key. The returned channel receives the block number
+// where the value was applied when the value first satisfies the predicate, and it is closed afterwards.
+// Only values applied after the subscription are checked. The returned function cancels the subscription.
+func (gov *Governance) WatchValue(key int, predicate func(interface{}) bool) (<-chan uint64, func()) {
+	w := &valueWatcher{
+		key:       GovernanceKeyMapReverse[key],
+		predicate: predicate,
+		ch:        make(chan uint64, 1),
+	}
+
+	gov.valueWatchersMu.Lock()
+	if gov.valueWatchers == nil {
+		gov.valueWatchers = make(map[uint64]*valueWatcher)
+	}
+	id := gov.valueWatchersID
+	gov.valueWatchersID++
+	gov.valueWatchers[id] = w
+	gov.valueWatchersMu.Unlock()
+
+	unsubscribe := func() {
+		gov.valueWatchersMu.Lock()
+		defer gov.valueWatchersMu.Unlock()
+		if _, ok := gov.valueWatchers[id]; ok {
+			delete(gov.valueWatchers, id)
+			close(w.ch)
+		}
+	}
+	return w.ch, unsubscribe
+}
+
+// notifyValueWatchers sends the block number to the watchers whose predicate is satisfied by the applied set
+func (gov *Governance) notifyValueWatchers(num uint64, applied map[string]interface{}) {
+	gov.valueWatchersMu.Lock()
+	defer gov.valueWatchersMu.Unlock()
+
+	for id, w := range gov.valueWatchers {
+		value, ok := applied[w.key]
+		if !ok || !w.predicate(value) {
+			continue
+		}
+		w.ch <- num
+		close(w.ch)
+		delete(gov.valueWatchers, id)
+	}
+}
+
+// validatorSetReader is implemented by a consensus engine which provides the validator set of its snapshot at a block.
+type validatorSetReader interface {
+	ValidatorSetAt(num uint64) (istanbul.ValidatorSet, error)
+}
+
+// RollbackTo makes the governance go back to the state which was applied at the given block.
+// It is used when the chain is reorganized below the block where current governance was applied.
+// Governance written above the block is removed from the database and the caches, since it came from the headers
+// which are no longer in the chain. Votes casted after the block are marked as not casted so they can be casted again.
+// Votes and tallies of the epoch are rebuilt from the headers of the epoch up to the block, starting from
+// the validator set of the consensus snapshot at the epoch boundary, so the ones made at or before the block are kept.
+func (gov *Governance) RollbackTo(num uint64) error {
+	if gov.blockChain == nil || gov.db == nil {
+		return ErrNotInitialized
+	}
+	reader, ok := gov.blockChain.Engine().(validatorSetReader)
+	if !ok {
+		return ErrNotInitialized
+	}
+	if gov.readOnly {
+		return ErrReadOnly
+	}
+
+	// Votes are cleared at every epoch boundary, so only the headers after the last boundary have votes
+	_, items, err := gov.ReadGovernance(num)
+	if err != nil {
+		return err
+	}
+	epoch, ok := items[GovernanceKeyMapReverse[params.Epoch]].(uint64)
+	if !ok || epoch == 0 {
+		epoch = gov.ChainConfig.Istanbul.Epoch
+	}
+	valset, err := reader.ValidatorSetAt(num - num%epoch)
+	if err != nil {
+		return err
+	}
+	headers := make([]*types.Header, 0, num%epoch)
+	for n := num - num%epoch + 1; n <= num; n++ {
+		header := gov.blockChain.GetHeaderByNumber(n)
+		if header == nil {
+			return ErrMissingHeader
+		}
+		headers = append(headers, header)
+	}
+
+	if err := gov.db.DeleteGovernanceAbove(num); err != nil {
+		return err
+	}
+	gov.InvalidateCacheAbove(num)
+
+	newNumber, newGovernanceSet, err := gov.ReadGovernance(num)
+	if err != nil {
+		return err
+	}
+	if newGovernanceSet == nil {
+		return ErrNotInitialized
+	}
+
+	gov.voteMapLock.Lock()
+	for k, v := range gov.voteMap {
+		if v.Casted && v.Num > num {
+			gov.voteMap[k] = VoteStatus{Value: v.Value, Casted: false, Num: 0}
+		}
+	}
+	gov.GovernanceVotes.Clear()
+	gov.GovernanceTallies.Clear()
+	gov.changeSet.Clear()
+	gov.voteMapLock.Unlock()
+
+	if num < atomic.LoadUint64(&gov.lastGovernanceStateBlock) {
+		atomic.StoreUint64(&gov.lastGovernanceStateBlock, num)
+	}
+	if num < atomic.LoadUint64(&gov.pendingGovernanceBlock) {
+		atomic.StoreUint64(&gov.pendingGovernanceBlock, 0)
+	}
+	if newNumber != atomic.LoadUint64(&gov.actualGovernanceBlock) {
+		gov.applyGovernance(num, newNumber, newGovernanceSet)
+	}
+
+	// The votes are handled again as the snapshot handles them, but this node doesn't cast its votes again
+	votes, tally := []GovernanceVote{}, []GovernanceTallyItem{}
+	for _, header := range headers {
+		proposer, err := gov.blockChain.Engine().Author(header)
+		if err != nil {
+			return err
+		}
+		valset, votes, tally = gov.HandleGovernanceVote(valset, votes, tally, header, proposer, common.Address{})
+	}
+	gov.GovernanceVotes.Import(votes)
+	gov.GovernanceTallies.Import(tally)
+
+	logger.Info("Governance is rolled back", "num", num, "governanceBlock", newNumber, "votes", len(votes))
+	return nil
+}
+
+// OnBatchChange registers a callback invoked with all the governance items changed at once (e.g., at an epoch boundary).
+// It is invoked after the change of each item is triggered, so that dependent values can be recomputed once.
+func (gov *Governance) OnBatchChange(fn func(map[string]interface{})) {
+	gov.batchTriggersLock.Lock()
+	defer gov.batchTriggersLock.Unlock()
+
+	gov.batchTriggers = append(gov.batchTriggers, fn)
+}
+
+// triggerChange triggers the change of each governance item whose value differs from the one triggered lastly,
+// so applying the same set again (e.g., after governance state is reloaded) doesn't trigger anything.
+// Then the batch callbacks are invoked with the changed items.
 func (gov *Governance) triggerChange(src map[string]interface{}) {
+	changed := gov.triggerItems(src)
+	if len(changed) == 0 {
+		return
+	}
+
+	gov.batchTriggersLock.Lock()
+	callbacks := make([]func(map[string]interface{}), len(gov.batchTriggers))
+	copy(callbacks, gov.batchTriggers)
+	gov.batchTriggersLock.Unlock()
+
+	for _, fn := range callbacks {
+		fn(copyItems(changed))
+	}
+	gov.notifyIstanbulChange(changed)
+}
+
+// istanbulChangeBufferSize is the number of istanbul changes buffered for a subscriber
+const istanbulChangeBufferSize = 16
+
+// SubscribeIstanbulChange returns a channel which receives the istanbul.* items whose changes are triggered together,
+// e.g., istanbul.committeesize and istanbul.epoch, so that the consensus engine can recompute committees at once.
+// Changes of the other items are not delivered. A change is dropped if the subscriber falls behind
+// by istanbulChangeBufferSize changes, since triggering changes shouldn't be blocked by a subscriber.
+func (gov *Governance) SubscribeIstanbulChange() <-chan map[string]interface{} {
+	ch := make(chan map[string]interface{}, istanbulChangeBufferSize)
+
+	gov.istanbulSubsLock.Lock()
+	defer gov.istanbulSubsLock.Unlock()
+	gov.istanbulSubs = append(gov.istanbulSubs, ch)
+	return ch
+}
+
+// notifyIstanbulChange sends the changed istanbul.* items to the subscribers if there are any
+func (gov *Governance) notifyIstanbulChange(changed map[string]interface{}) {
+	istanbul := make(map[string]interface{})
+	for k, v := range changed {
+		if strings.HasPrefix(k, "istanbul.") {
+			istanbul[k] = v
+		}
+	}
+	if len(istanbul) == 0 {
+		return
+	}
+
+	gov.istanbulSubsLock.Lock()
+	defer gov.istanbulSubsLock.Unlock()
+	for _, ch := range gov.istanbulSubs {
+		select {
+		case ch <- copyItems(istanbul):
+		default:
+			logger.Warn("Istanbul governance change is dropped since the subscriber is busy", "items", istanbul)
+		}
+	}
+}
+
+// triggerItems triggers the change of each item in src and returns the items whose changes were triggered.
+func (gov *Governance) triggerItems(src map[string]interface{}) map[string]interface{} {
+	gov.triggeredLock.Lock()
+	defer gov.triggeredLock.Unlock()
+
+	if gov.triggeredSet == nil {
+		gov.triggeredSet = make(map[string]interface{})
+	}
+	changed := make(map[string]interface{})
 	for k, v := range src {
+		if prev, ok := gov.triggeredSet[k]; ok && reflect.DeepEqual(prev, v) {
+			continue
+		}
 		GovernanceItems[GovernanceKeyMap[k]].trigger(gov, k, v)
+		gov.triggeredSet[k] = v
+		changed[k] = v
 	}
+	return changed
+}
+
+// unmarshalUseNumber is the same as json.Unmarshal, but it decodes numbers into interface{} values as json.Number.
+// A uint64 above 2^53 decoded as a float64 loses its precision, so governance items should be decoded with it.
+func unmarshalUseNumber(b []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
 }
 
 func adjustDecodedSet(src map[string]interface{}) map[string]interface{} {
 	for k, v := range src {
-		x := reflect.ValueOf(v)
-		if x.Kind() == reflect.Float64 {
-			src[k] = uint64(v.(float64))
-		}
-		if GovernanceKeyMap[k] == params.GoverningNode {
-			if reflect.TypeOf(v) == stringT {
-				src[k] = common.HexToAddress(v.(string))
-			} else {
-				src[k] = v
+		src[k] = normalizeGovernanceItem(k, v)
+	}
+	return src
+}
+
+// normalizeGovernanceItem coerces a decoded governance value into the type registered in GovernanceItems.
+// Depending on the version of a node, a numeric item can be delivered as a float64, a json.Number or a string.
+// If the value can't be coerced, it is returned as it is.
+func normalizeGovernanceItem(k string, v interface{}) interface{} {
+	key, ok := GovernanceKeyMap[k]
+	if !ok {
+		// Keys out of the registry (e.g., clique.epoch) are handled as before
+		switch x := v.(type) {
+		case float64:
+			return uint64(x)
+		case json.Number:
+			if n, err := strconv.ParseUint(x.String(), 10, 64); err == nil {
+				return n
 			}
 		}
+		return v
 	}
-	return src
+
+	switch GovernanceItems[key].t {
+	case uint64T:
+		switch x := v.(type) {
+		case float64:
+			return uint64(x)
+		case json.Number:
+			if n, err := strconv.ParseUint(x.String(), 10, 64); err == nil {
+				return n
+			}
+		case string:
+			if n, err := strconv.ParseUint(strings.TrimSpace(x), 10, 64); err == nil {
+				return n
+			}
+		}
+	case stringT:
+		switch x := v.(type) {
+		case float64:
+			return strconv.FormatFloat(x, 'f', -1, 64)
+		case json.Number:
+			return x.String()
+		}
+	case addressT:
+		// Hex digits are decoded regardless of their case, so checksum-cased addresses are the same address
+		if x, isString := v.(string); isString {
+			return common.HexToAddress(strings.TrimSpace(x))
+		}
+	case boolT:
+		if x, isString := v.(string); isString {
+			if b, err := strconv.ParseBool(x); err == nil {
+				return b
+			}
+		}
+	}
+	return v
+}
+
+// equalGovernanceValue returns true if a and b are the same value of the given key after being normalized,
+// so that a value is not regarded as changed by how it was decoded (e.g., an address as a checksum-cased hex string).
+func equalGovernanceValue(key string, a, b interface{}) bool {
+	a, b = normalizeGovernanceItem(key, a), normalizeGovernanceItem(key, b)
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// FormatGovernanceValue returns the string representation of a governance value which doesn't depend on
+// how the value was decoded. For example, an epoch decoded as a float64 and as a uint64 are formatted the same.
+func FormatGovernanceValue(key string, v interface{}) string {
+	switch x := normalizeGovernanceItem(key, v).(type) {
+	case nil:
+		return ""
+	case common.Address:
+		return strings.ToLower(x.Hex())
+	case uint64:
+		return strconv.FormatUint(x, 10)
+	case bool:
+		return strconv.FormatBool(x)
+	case string:
+		return x
+	default:
+		return fmt.Sprintf("%v", x)
+	}
+}
+
+// ItemsFingerprint returns currently applied governance items formatted by FormatGovernanceValue,
+// so that governance of two nodes can be compared with a simple map comparison.
+func (g *Governance) ItemsFingerprint() map[string]string {
+	items := g.currentSet.Items()
+	ret := make(map[string]string, len(items))
+	for k, v := range items {
+		ret[k] = FormatGovernanceValue(k, v)
+	}
+	return ret
 }
 
 func (gov *Governance) GetGovernanceValue(key int) interface{} {
 	if v, ok := gov.currentSet.GetValue(key); !ok {
-		return nil
+		// Governance applied by an older node doesn't have the items added later
+		return defaultGovernanceItems[key]
 	} else {
 		return v
 	}
 }
 
+// CurrentUnitPrice returns the unit price of the currently applied governance.
+// It is available right after the governance is made from a database, so the txpool can be initialized with it.
+func (gov *Governance) CurrentUnitPrice() (uint64, bool) {
+	price, ok := gov.GetGovernanceValue(params.UnitPrice).(uint64)
+	return price, ok
+}
+
+// ForkBlock returns the block number where the named fork is activated by the governance fork schedule
+// used for the given block. It returns false if the fork is not scheduled at the block.
+// The schedule is read from the governance of the block, so it doesn't depend on the current head.
+func (gov *Governance) ForkBlock(name string, num uint64) (uint64, bool) {
+	forkBlock, ok := gov.forkSchedule(num)[name]
+	return forkBlock, ok
+}
+
+// IsForkEnabled returns true if the named fork is activated at the given block by the governance fork schedule.
+func (gov *Governance) IsForkEnabled(name string, num uint64) bool {
+	forkBlock, ok := gov.ForkBlock(name, num)
+	return ok && num >= forkBlock
+}
+
+// forkSchedule returns the governance fork schedule used for the given block.
+// It returns an empty schedule if the governance of the block can't be read.
+func (gov *Governance) forkSchedule(num uint64) map[string]uint64 {
+	_, items, err := gov.ReadGovernance(num)
+	if err != nil {
+		logger.Warn("Failed to read the fork schedule", "num", num, "err", err)
+		return map[string]uint64{}
+	}
+	v, _ := items[GovernanceKeyMapReverse[params.ForkSchedule]].(string)
+	schedule, err := parseForkSchedule(v)
+	if err != nil {
+		return map[string]uint64{}
+	}
+	return schedule
+}
+
+// HasValue returns true if the item of the given key is explicitly set in the current governance.
+// Unlike GetGovernanceValue, it distinguishes an item set to its default value from an unset item.
+func (gov *Governance) HasValue(key int) bool {
+	_, ok := gov.currentSet.GetValue(key)
+	return ok
+}
+
 func (gov *Governance) VerifyGovernance(received []byte) error {
 	change := []byte{}
 	if rlp.DecodeBytes(received, &change) != nil {
@@ -761,22 +2447,25 @@ func (gov *Governance) VerifyGovernance(received []byte) error {
 	}
 
 	rChangeSet := make(map[string]interface{})
-	if json.Unmarshal(change, &rChangeSet) != nil {
+	if unmarshalUseNumber(change, &rChangeSet) != nil {
 		return ErrUnmarshalGovChange
 	}
 	rChangeSet = adjustDecodedSet(rChangeSet)
+	for k := range rChangeSet {
+		if isImmutableKey(k) {
+			logger.Error("Chain identity can't be changed by governance", "key", k)
+			return ErrImmutableGovChange
+		}
+	}
 
 	if len(rChangeSet) == gov.changeSet.Size() {
 		for k, v := range rChangeSet {
-			if GovernanceKeyMap[k] == params.GoverningNode {
-				if reflect.TypeOf(v) == stringT {
-					v = common.HexToAddress(v.(string))
-				}
-			}
-
 			have, _ := gov.changeSet.GetValue(GovernanceKeyMap[k])
-			if have != v {
-				logger.Error("Verification Error", "key", k, "received", rChangeSet[k], "have", have, "receivedType", reflect.TypeOf(rChangeSet[k]), "haveType", reflect.TypeOf(have))
+			if !equalGovernanceValue(k, have, v) {
+				// The local changeSet is never replaced by the received one. The header isn't verified yet, and
+				// the changeSet converges through the votes of the committed chain.
+				logger.Error("Verification Error", "key", k, "received", rChangeSet[k], "have", have, "receivedType", reflect.TypeOf(rChangeSet[k]), "haveType", reflect.TypeOf(have),
+					"haveSet", gov.changeSet.Items(), "receivedSet", rChangeSet)
 				return ErrVoteValueMismatch
 			}
 		}
@@ -784,7 +2473,67 @@ func (gov *Governance) VerifyGovernance(received []byte) error {
 	return nil
 }
 
+// GovernanceSnapshot is an immutable copy of the governance status.
+// It can be read by RPC handlers without racing with block processing.
+type GovernanceSnapshot struct {
+	CurrentSet            map[string]interface{}
+	ChangeSet             map[string]interface{}
+	VoteMap               map[string]VoteStatus
+	Votes                 []GovernanceVote
+	Tallies               []GovernanceTallyItem
+	NodeAddress           common.Address
+	VotingPower           uint64
+	TotalVotingPower      uint64
+	ActualGovernanceBlock uint64
+}
+
+// Snapshot returns a copy of the governance status. All related locks are held together while copying,
+// so the returned snapshot is consistent.
+func (gov *Governance) Snapshot() GovernanceSnapshot {
+	// Locks are acquired in the same order with ClearVotes
+	gov.voteMapLock.RLock()
+	defer gov.voteMapLock.RUnlock()
+	gov.GovernanceVotes.mu.RLock()
+	defer gov.GovernanceVotes.mu.RUnlock()
+	gov.GovernanceTallies.mu.RLock()
+	defer gov.GovernanceTallies.mu.RUnlock()
+	// Governance sets are copied on write, so their published items are consistent without locks
+	currentSet, changeSet := gov.currentSet.load(), gov.changeSet.load()
+
+	snap := GovernanceSnapshot{
+		CurrentSet:            make(map[string]interface{}, len(currentSet)),
+		ChangeSet:             make(map[string]interface{}, len(changeSet)),
+		VoteMap:               make(map[string]VoteStatus, len(gov.voteMap)),
+		Votes:                 make([]GovernanceVote, len(gov.GovernanceVotes.items)),
+		Tallies:               make([]GovernanceTallyItem, len(gov.GovernanceTallies.items)),
+		NodeAddress:           gov.nodeAddress,
+		VotingPower:           atomic.LoadUint64(&gov.votingPower),
+		TotalVotingPower:      atomic.LoadUint64(&gov.totalVotingPower),
+		ActualGovernanceBlock: atomic.LoadUint64(&gov.actualGovernanceBlock),
+	}
+	for k, v := range currentSet {
+		snap.CurrentSet[k] = v
+	}
+	for k, v := range changeSet {
+		snap.ChangeSet[k] = v
+	}
+	for k, v := range gov.voteMap {
+		snap.VoteMap[k] = v
+	}
+	copy(snap.Votes, gov.GovernanceVotes.items)
+	copy(snap.Tallies, gov.GovernanceTallies.items)
+
+	return snap
+}
+
+// governanceStateVersion is the version of the serialized governance state.
+// Increase it when the shape of governanceJSON changes and add a migration to migrateGovernanceJSON.
+//   - 0: the initial version which doesn't have the version field
+//   - 1: the version field is added
+const governanceStateVersion = 1
+
 type governanceJSON struct {
+	Version         int                    `json:"version"`
 	BlockNumber     uint64                 `json:"blockNumber"`
 	ChainConfig     *params.ChainConfig    `json:"chainConfig"`
 	VoteMap         map[string]VoteStatus  `json:"voteMap"`
@@ -796,10 +2545,18 @@ type governanceJSON struct {
 }
 
 func (gov *Governance) toJSON(num uint64) ([]byte, error) {
+	gov.voteMapLock.RLock()
+	voteMap := make(map[string]VoteStatus, len(gov.voteMap))
+	for k, v := range gov.voteMap {
+		voteMap[k] = v
+	}
+	gov.voteMapLock.RUnlock()
+
 	ret := &governanceJSON{
+		Version:         governanceStateVersion,
 		BlockNumber:     num,
 		ChainConfig:     gov.ChainConfig,
-		VoteMap:         gov.voteMap,
+		VoteMap:         voteMap,
 		NodeAddress:     gov.nodeAddress,
 		GovernanceVotes: gov.GovernanceVotes.Copy(),
 		GovernanceTally: gov.GovernanceTallies.Copy(),
@@ -812,9 +2569,23 @@ func (gov *Governance) toJSON(num uint64) ([]byte, error) {
 
 func (gov *Governance) UnmarshalJSON(b []byte) error {
 	var j governanceJSON
-	if err := json.Unmarshal(b, &j); err != nil {
+	if err := unmarshalUseNumber(b, &j); err != nil {
 		return err
 	}
+	if err := migrateGovernanceJSON(&j); err != nil {
+		return err
+	}
+	// The chain config is dereferenced once the state is loaded
+	if j.ChainConfig == nil {
+		return ErrIncompleteGovernanceState
+	}
+	for k, v := range j.VoteMap {
+		v.Value = normalizeGovernanceItem(k, v.Value)
+		j.VoteMap[k] = v
+	}
+	for i := range j.GovernanceTally {
+		j.GovernanceTally[i].Value = normalizeGovernanceItem(j.GovernanceTally[i].Key, j.GovernanceTally[i].Value)
+	}
 	gov.ChainConfig = j.ChainConfig
 	gov.voteMap = j.VoteMap
 	gov.nodeAddress = j.NodeAddress
@@ -827,6 +2598,171 @@ func (gov *Governance) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// compressedStateMagic is the header of compressed governance state.
+// Governance state written uncompressed is a JSON object, so it never starts with the header.
+var compressedStateMagic = []byte{0xff, 'k', 'g', 'z'}
+
+// compressGovernanceState compresses serialized governance state with gzip and prepends compressedStateMagic.
+func compressGovernanceState(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(compressedStateMagic)
+
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressGovernanceState returns serialized governance state as it is if it doesn't start with compressedStateMagic.
+// Otherwise, it returns the decompressed one.
+func decompressGovernanceState(b []byte) ([]byte, error) {
+	if !bytes.HasPrefix(b, compressedStateMagic) {
+		return b, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b[len(compressedStateMagic):]))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// migrateGovernanceJSON migrates a governance state of an older version to the current version step by step.
+func migrateGovernanceJSON(j *governanceJSON) error {
+	if j.Version > governanceStateVersion {
+		return ErrUnknownStateVersion
+	}
+	for ; j.Version < governanceStateVersion; j.Version++ {
+		switch j.Version {
+		case 0:
+			// Empty fields could be stored as null in version 0
+			if j.VoteMap == nil {
+				j.VoteMap = make(map[string]VoteStatus)
+			}
+			if j.GovernanceVotes == nil {
+				j.GovernanceVotes = []GovernanceVote{}
+			}
+			if j.GovernanceTally == nil {
+				j.GovernanceTally = []GovernanceTallyItem{}
+			}
+			if j.CurrentSet == nil {
+				j.CurrentSet = make(map[string]interface{})
+			}
+			if j.ChangeSet == nil {
+				j.ChangeSet = make(map[string]interface{})
+			}
+		}
+	}
+	return nil
+}
+
+// Hash returns the hash over the validator, the key and the canonical value of the vote, which identifies
+// the same proposal to dedupe proposals and index tallies. A vote read from a header should be parsed
+// by ParseVoteValue before hashing, so that it hashes the same as the same vote in another encoding.
+func (v GovernanceVote) Hash() common.Hash {
+	b, _ := rlp.EncodeToBytes([]interface{}{v.Validator, v.Key, FormatGovernanceValue(v.Key, v.Value)})
+	return crypto.Keccak256Hash(b)
+}
+
+// Equals returns true if the votes have the same validator, key and value. Keys are compared case-insensitively
+// and values are compared after being normalized to the type of the governance item, e.g., an address given as
+// a hex string equals the address. The block numbers of the votes are not compared.
+func (v GovernanceVote) Equals(other GovernanceVote) bool {
+	key := strings.ToLower(strings.TrimSpace(v.Key))
+	if v.Validator != other.Validator || key != strings.ToLower(strings.TrimSpace(other.Key)) {
+		return false
+	}
+	// Values not parsed yet (e.g., raw bytes from a header) are equal only if they are identical
+	return equalGovernanceValue(key, v.Value, other.Value) || reflect.DeepEqual(v.Value, other.Value)
+}
+
+// Type tags of a vote value in JSON
+const (
+	voteTypeAddress = "addr"
+	voteTypeUint64  = "uint64"
+	voteTypeString  = "string"
+	voteTypeBool    = "bool"
+	voteTypeBytes   = "bytes"
+)
+
+var voteValueTypes = map[string]reflect.Type{
+	voteTypeAddress: addressT,
+	voteTypeUint64:  uint64T,
+	voteTypeString:  stringT,
+	voteTypeBool:    boolT,
+	voteTypeBytes:   reflect.TypeOf([]byte{}),
+}
+
+type governanceVoteJSON struct {
+	Validator   common.Address  `json:"validator"`
+	Key         string          `json:"key"`
+	Type        string          `json:"type,omitempty"`
+	Value       json.RawMessage `json:"value"`
+	BlockNumber uint64          `json:"blockNumber,omitempty"`
+	VotingPower uint64          `json:"votingPower,omitempty"`
+}
+
+// MarshalJSON encodes a vote with the type of its value, so that the value is decoded into the same type.
+// A value not parsed yet (raw bytes from a header) is encoded as bytes.
+// A value of any other type (e.g., a float64 of a vote loaded from an older state) is normalized first,
+// and it is encoded without a type if it still has no type tag.
+func (v GovernanceVote) MarshalJSON() ([]byte, error) {
+	var t string
+	val := normalizeGovernanceItem(v.Key, v.Value)
+	switch val.(type) {
+	case common.Address:
+		t = voteTypeAddress
+	case uint64:
+		t = voteTypeUint64
+	case string:
+		t = voteTypeString
+	case bool:
+		t = voteTypeBool
+	case []byte:
+		t = voteTypeBytes
+	}
+	value, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(governanceVoteJSON{Validator: v.Validator, Key: v.Key, Type: t, Value: value, BlockNumber: v.BlockNumber, VotingPower: v.VotingPower})
+}
+
+// UnmarshalJSON decodes a vote encoded by MarshalJSON.
+// A vote without a type, which was written by an older version, is decoded as it was before.
+// A vote without a block number or a voting power is decoded with 0.
+func (v *GovernanceVote) UnmarshalJSON(b []byte) error {
+	var j governanceVoteJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+
+	var value interface{}
+	switch j.Type {
+	case "":
+		if len(j.Value) > 0 {
+			if err := json.Unmarshal(j.Value, &value); err != nil {
+				return err
+			}
+		}
+	case voteTypeAddress, voteTypeUint64, voteTypeString, voteTypeBool, voteTypeBytes:
+		ptr := reflect.New(voteValueTypes[j.Type])
+		if err := json.Unmarshal(j.Value, ptr.Interface()); err != nil {
+			return err
+		}
+		value = ptr.Elem().Interface()
+	default:
+		return ErrUnknownVoteType
+	}
+
+	v.Validator, v.Key, v.Value, v.BlockNumber, v.VotingPower = j.Validator, j.Key, value, j.BlockNumber, j.VotingPower
+	return nil
+}
+
 func (gov *Governance) CanWriteGovernanceState(num uint64) bool {
 	if num <= atomic.LoadUint64(&gov.lastGovernanceStateBlock) {
 		return false
@@ -834,8 +2770,35 @@ func (gov *Governance) CanWriteGovernanceState(num uint64) bool {
 	return true
 }
 
+// WriteGovernanceStateIfNewer writes governance state only if it can be written at the given block.
+// A checkpoint is written if no checkpoint was written at the block or after, and a non-checkpoint state
+// is written only once per block. Checking and writing are serialized, so concurrent callers can't write
+// the same block twice. It returns true if the state was written.
+func (gov *Governance) WriteGovernanceStateIfNewer(num uint64, isCheckpoint bool) (bool, error) {
+	gov.governanceStateLock.Lock()
+	defer gov.governanceStateLock.Unlock()
+
+	if !gov.CanWriteGovernanceState(num) || (!isCheckpoint && num <= gov.lastVoteStateBlock) {
+		return false, nil
+	}
+	if err := gov.WriteGovernanceState(num, isCheckpoint); err != nil {
+		return false, err
+	}
+	if !isCheckpoint {
+		gov.lastVoteStateBlock = num
+	}
+	return true, nil
+}
+
 func (gov *Governance) WriteGovernanceState(num uint64, isCheckpoint bool) error {
-	if b, err := gov.toJSON(num); err != nil {
+	if gov.readOnly {
+		return ErrReadOnly
+	}
+	b, err := gov.toJSON(num)
+	if err == nil && gov.compressState {
+		b, err = compressGovernanceState(b)
+	}
+	if err != nil {
 		logger.Error("Error in marshaling governance state", "err", err)
 		return err
 	} else {
@@ -858,47 +2821,200 @@ func (gov *Governance) ReadGovernanceState() {
 		logger.Info("No governance state found in a database")
 		return
 	}
-	gov.UnmarshalJSON(b)
+	if err := gov.loadGovernanceState(b); err != nil {
+		logger.Error("Failed to load governance state from database. Recovering it from governance items", "err", err)
+		if err := gov.recoverGovernanceState(); err != nil {
+			logger.Error("Failed to recover governance state", "err", err)
+			return
+		}
+	}
 	params.SetStakingUpdateInterval(gov.ChainConfig.Governance.Reward.StakingUpdateInterval)
 	params.SetProposerUpdateInterval(gov.ChainConfig.Governance.Reward.ProposerUpdateInterval)
 
-	if txGasHumanReadable, ok := gov.currentSet.GetValue(params.ConstTxGasHumanReadable); ok {
-		params.TxGasHumanReadable = txGasHumanReadable.(uint64)
+	params.TxGasHumanReadable = gov.currentSet.GetUint64(params.ConstTxGasHumanReadable, params.TxGasHumanReadable)
+	params.MaxTxGas = gov.currentSet.GetUint64(params.ConstMaxTxGas, params.MaxTxGas)
+	params.HumanReadableAddress = gov.currentSet.GetBool(params.ConstHumanReadableAddress, params.HumanReadableAddress)
+	if price, ok := gov.CurrentUnitPrice(); ok {
+		gov.ChainConfig.UnitPrice = price
+		if gov.TxPool != nil {
+			gov.TxPool.SetGasPrice(new(big.Int).SetUint64(price))
+		}
 	}
 	logger.Info("Successfully loaded governance state from database", "blockNumber", atomic.LoadUint64(&gov.lastGovernanceStateBlock))
 }
 
+// loadGovernanceState loads the governance state stored in a database.
+// Nothing is loaded if the state is corrupted, so governance is never half-initialized.
+func (gov *Governance) loadGovernanceState(b []byte) error {
+	b, err := decompressGovernanceState(b)
+	if err != nil {
+		return err
+	}
+	return gov.UnmarshalJSON(b)
+}
+
+// recoverGovernanceState reconstructs the governance state from the governance items in the database,
+// when the stored state can't be loaded. The applied items are resolved from the last governance index
+// as if the node booted without a governance state, and they are reflected to the chain config.
+// Votes and tallies are not recovered since they aren't in governance items.
+func (gov *Governance) recoverGovernanceState() error {
+	if err := gov.loadCache(); err != nil {
+		return err
+	}
+	for k, v := range gov.currentSet.Items() {
+		GovernanceItems[GovernanceKeyMap[k]].trigger(gov, k, v)
+	}
+	logger.Warn("Recovered governance state from governance items", "blockNumber", atomic.LoadUint64(&gov.actualGovernanceBlock))
+	return nil
+}
+
 func (gov *Governance) SetBlockchain(bc *blockchain.BlockChain) {
 	gov.blockChain = bc
+	if bc != nil && bc.CurrentBlock() != nil {
+		gov.reconcileGovernanceStateBlock(bc.CurrentBlock().NumberU64())
+		gov.warmupCache(bc.CurrentBlock().NumberU64())
+	}
+}
+
+// warmupCache reads the governance items used for the epoch boundaries of the last cacheWarmupEpochs epochs
+// up to head into the item cache. It returns the number of epochs warmed up.
+// Failing to read governance items is not fatal, since they are read from the database on demand.
+func (gov *Governance) warmupCache(head uint64) int {
+	if gov.cacheWarmupEpochs == 0 || gov.db == nil || gov.ChainConfig.Istanbul == nil || gov.ChainConfig.Istanbul.Epoch == 0 {
+		return 0
+	}
+	epoch := gov.ChainConfig.Istanbul.Epoch
+	boundary := head - head%epoch
+
+	warmed := 0
+	for i := uint64(0); i < gov.cacheWarmupEpochs && i*epoch <= boundary; i++ {
+		num := boundary - i*epoch
+		gBlockNum, data, err := gov.ReadGovernance(num)
+		if err != nil {
+			logger.Debug("Failed to warm up governance cache", "num", num, "err", err)
+			continue
+		}
+		if _, ok := gov.getGovernanceCache(gBlockNum); !ok {
+			gov.itemCache.Add(getGovernanceCacheKey(gBlockNum), data)
+		}
+		warmed++
+	}
+	logger.Debug("Warmed up governance cache", "head", head, "epochs", warmed)
+	return warmed
+}
+
+// governanceStateCheckpointInterval is the interval of governance state checkpoints written by the consensus engine.
+// It should be the same as checkpointInterval of the istanbul engine.
+const governanceStateCheckpointInterval = 1024
+
+// reconcileGovernanceStateBlock validates the block number of the loaded governance state against the chain head.
+// A state ahead of the head (e.g., restored from a backup of another chain) would prevent writing governance state
+// until the chain catches up, so it's clamped to the head. A state behind the head by more than a checkpoint interval
+// can't be fixed here, since votes after it are not known; it's overwritten at the next checkpoint.
+// It returns true if the state mismatches with the head.
+func (gov *Governance) reconcileGovernanceStateBlock(head uint64) bool {
+	gov.governanceStateLock.Lock()
+	defer gov.governanceStateLock.Unlock()
+
+	stateBlock := atomic.LoadUint64(&gov.lastGovernanceStateBlock)
+	switch {
+	case stateBlock > head:
+		logger.Warn("Governance state is ahead of the chain head. Clamping it to the head", "stateBlock", stateBlock, "head", head)
+		atomic.StoreUint64(&gov.lastGovernanceStateBlock, head)
+		if gov.lastVoteStateBlock > head {
+			gov.lastVoteStateBlock = head
+		}
+		return true
+	case head-stateBlock > governanceStateCheckpointInterval:
+		logger.Warn("Governance state is behind the chain head. Votes after it may be missing", "stateBlock", stateBlock, "head", head)
+		return true
+	}
+	return false
 }
 
 func (gov *Governance) SetTxPool(txpool *blockchain.TxPool) {
 	gov.TxPool = txpool
+	txpool.SetGovernance(gov)
 }
 
 func getGovernanceItemsFromChainConfig(config *params.ChainConfig) GovernanceSet {
+	g, errs := governanceItemsFromChainConfig(config)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			logger.Error("Invalid governance item in chain config", "err", err)
+		}
+		logger.Crit("Failed to get governance items from chain config", "errors", len(errs))
+	}
+	return g
+}
+
+// defaultGovernanceItems are the governance items added after networks were launched, with their default values.
+// Governance stored by an older node doesn't have them, so the default values are used for the missing ones.
+var defaultGovernanceItems = map[int]interface{}{
+	params.BlockGasLimit:             params.DefaultBlockGasLimit,
+	params.TargetGasPerBlock:         params.DefaultTargetGasPerBlock,
+	params.BaseFeeDenominator:        params.DefaultBaseFeeDenominator,
+	params.KIRAddress:                common.HexToAddress(params.DefaultKIRAddress),
+	params.PoCAddress:                common.HexToAddress(params.DefaultPoCAddress),
+	params.ConstMaxTxGas:             params.DefaultMaxTxGas,
+	params.ForkSchedule:              params.DefaultForkSchedule,
+	params.ConstHumanReadableAddress: params.DefaultHumanReadableAddress,
+	params.AddressBookAddress:        common.HexToAddress(params.DefaultAddressBookAddress),
+}
+
+// withDefaultItems returns the given items with the default values of the missing items in defaultGovernanceItems.
+// The given items aren't modified, since they may be shared with the cache.
+func withDefaultItems(items map[string]interface{}) map[string]interface{} {
+	if items == nil {
+		return nil
+	}
+	ret, copied := items, false
+	for key, v := range defaultGovernanceItems {
+		k := GovernanceKeyMapReverse[key]
+		if _, ok := items[k]; ok {
+			continue
+		}
+		if !copied {
+			ret, copied = copyItems(items), true
+		}
+		ret[k] = v
+	}
+	return ret
+}
+
+// governanceItemsFromChainConfig extracts governance items from the chain config
+// and returns them with the errors of invalid items.
+func governanceItemsFromChainConfig(config *params.ChainConfig) (GovernanceSet, []error) {
 	g := NewGovernanceSet()
+	var errs []error
 
 	if config.Governance != nil {
 		governance := config.Governance
 		governanceMap := map[int]interface{}{
-			params.GovernanceMode:          governance.GovernanceMode,
-			params.GoverningNode:           governance.GoverningNode,
-			params.UnitPrice:               config.UnitPrice,
-			params.MintingAmount:           governance.Reward.MintingAmount.String(),
-			params.Ratio:                   governance.Reward.Ratio,
-			params.UseGiniCoeff:            governance.Reward.UseGiniCoeff,
-			params.DeferredTxFee:           governance.Reward.DeferredTxFee,
-			params.MinimumStake:            governance.Reward.MinimumStake.String(),
-			params.StakeUpdateInterval:     governance.Reward.StakingUpdateInterval,
-			params.ProposerRefreshInterval: governance.Reward.ProposerUpdateInterval,
-		}
-
-		for k, v := range governanceMap {
-			if err := g.SetValue(k, v); err != nil {
-				writeFailLog(k, err)
+			params.GovernanceMode: governance.GovernanceMode,
+			params.GoverningNode:  governance.GoverningNode,
+			params.UnitPrice:      config.UnitPrice,
+		}
+		for key, v := range defaultGovernanceItems {
+			governanceMap[key] = v
+		}
+
+		// Only the available items are extracted from a partial config
+		if reward := governance.Reward; reward != nil {
+			governanceMap[params.Ratio] = reward.Ratio
+			governanceMap[params.UseGiniCoeff] = reward.UseGiniCoeff
+			governanceMap[params.DeferredTxFee] = reward.DeferredTxFee
+			governanceMap[params.StakeUpdateInterval] = reward.StakingUpdateInterval
+			governanceMap[params.ProposerRefreshInterval] = reward.ProposerUpdateInterval
+			if reward.MintingAmount != nil {
+				governanceMap[params.MintingAmount] = reward.MintingAmount.String()
+			}
+			if reward.MinimumStake != nil {
+				governanceMap[params.MinimumStake] = reward.MinimumStake.String()
 			}
 		}
+
+		errs = append(errs, g.SetValues(governanceMap)...)
 	}
 
 	if config.Istanbul != nil {
@@ -909,18 +3025,104 @@ func getGovernanceItemsFromChainConfig(config *params.ChainConfig) GovernanceSet
 			params.CommitteeSize: istanbul.SubGroupSize,
 		}
 
-		for k, v := range istanbulMap {
-			if err := g.SetValue(k, v); err != nil {
-				writeFailLog(k, err)
-			}
+		errs = append(errs, g.SetValues(istanbulMap)...)
+	}
+	return g, errs
+}
+
+// requiredGovernanceItems are the governance items which every chain config should have,
+// since they are read from the applied set without a fallback.
+var requiredGovernanceItems = []int{
+	params.GovernanceMode,
+	params.GoverningNode,
+	params.UnitPrice,
+	params.MintingAmount,
+	params.Ratio,
+	params.UseGiniCoeff,
+	params.DeferredTxFee,
+	params.MinimumStake,
+	params.StakeUpdateInterval,
+	params.ProposerRefreshInterval,
+}
+
+// requiredIstanbulItems are the governance items which the chain config of an istanbul network should have.
+var requiredIstanbulItems = []int{
+	params.Epoch,
+	params.Policy,
+	params.CommitteeSize,
+}
+
+// MissingGovernanceItems returns the keys of the required governance items absent from the chain config
+// in alphabetical order. Those items would be nil once the genesis governance is applied,
+// so they should be set explicitly in the genesis. Istanbul items are not required for a clique network.
+// Invalid values are not reported here; see ValidateGenesisGovernance.
+func MissingGovernanceItems(config *params.ChainConfig) []string {
+	g, _ := governanceItemsFromChainConfig(config)
+	return missingGovernanceItems(config, g)
+}
+
+// missingGovernanceItems returns the keys of the required governance items which g extracted from config doesn't have.
+func missingGovernanceItems(config *params.ChainConfig, g GovernanceSet) []string {
+	required := requiredGovernanceItems
+	if config.Clique == nil {
+		required = append(required[:len(required):len(required)], requiredIstanbulItems...)
+	}
+
+	var missing []string
+	for _, key := range required {
+		if _, ok := g.GetValue(key); !ok {
+			missing = append(missing, GovernanceKeyMapReverse[key])
 		}
 	}
-	return g
+	sort.Strings(missing)
+	return missing
 }
 
-func writeFailLog(key int, err error) {
-	msg := "Failed to set " + GovernanceKeyMapReverse[key]
-	logger.Crit(msg, "err", err)
+// ToGenesisConfig reconstructs a governance config from currently applied governance items.
+// It is the inverse of getGovernanceItemsFromChainConfig and missing items are filled with default values.
+func (g *Governance) ToGenesisConfig() *params.GovernanceConfig {
+	def := GetDefaultGovernanceConfig(params.UseIstanbul)
+
+	return &params.GovernanceConfig{
+		GovernanceMode: g.currentSet.GetString(params.GovernanceMode, def.GovernanceMode),
+		GoverningNode:  g.currentSet.GetAddress(params.GoverningNode, def.GoverningNode),
+		Reward:         g.ToRewardConfig(),
+	}
+}
+
+// ToRewardConfig reconstructs a reward config from currently applied governance items.
+func (g *Governance) ToRewardConfig() *params.RewardConfig {
+	def := GetDefaultRewardConfig()
+
+	mintingAmount, ok := new(big.Int).SetString(g.currentSet.GetString(params.MintingAmount, ""), 10)
+	if !ok {
+		mintingAmount = def.MintingAmount
+	}
+	minimumStake, ok := new(big.Int).SetString(g.currentSet.GetString(params.MinimumStake, ""), 10)
+	if !ok {
+		minimumStake = def.MinimumStake
+	}
+
+	return &params.RewardConfig{
+		MintingAmount:          mintingAmount,
+		Ratio:                  g.currentSet.GetString(params.Ratio, def.Ratio),
+		UseGiniCoeff:           g.currentSet.GetBool(params.UseGiniCoeff, def.UseGiniCoeff),
+		DeferredTxFee:          g.currentSet.GetBool(params.DeferredTxFee, def.DeferredTxFee),
+		StakingUpdateInterval:  g.currentSet.GetUint64(params.StakeUpdateInterval, def.StakingUpdateInterval),
+		ProposerUpdateInterval: g.currentSet.GetUint64(params.ProposerRefreshInterval, def.ProposerUpdateInterval),
+		MinimumStake:           minimumStake,
+	}
+}
+
+// ToIstanbulConfig reconstructs an istanbul config from currently applied governance items.
+func (g *Governance) ToIstanbulConfig() *params.IstanbulConfig {
+	def := GetDefaultIstanbulConfig()
+
+	return &params.IstanbulConfig{
+		Epoch:          g.currentSet.GetUint64(params.Epoch, def.Epoch),
+		ProposerPolicy: g.currentSet.GetUint64(params.Policy, def.ProposerPolicy),
+		SubGroupSize:   g.currentSet.GetUint64(params.CommitteeSize, def.SubGroupSize),
+	}
 }
 
 func AddGovernanceCacheForTest(g *Governance, num uint64, config *params.ChainConfig) {
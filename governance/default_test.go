@@ -17,17 +17,34 @@
 package governance
 
 import (
+	"bytes"
+	"encoding/json"
 	"github.com/klaytn/klaytn/blockchain"
+	"github.com/klaytn/klaytn/blockchain/vm"
 	"github.com/klaytn/klaytn/common"
+	"github.com/klaytn/klaytn/consensus/gxhash"
+	"github.com/klaytn/klaytn/consensus/istanbul"
+	"github.com/klaytn/klaytn/metrics"
 	"github.com/klaytn/klaytn/params"
 	"github.com/klaytn/klaytn/ser/rlp"
 	"github.com/klaytn/klaytn/storage/database"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"math"
 	"math/big"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
+func init() {
+	// Reading governance from a memory database doesn't fail transiently
+	defaultDBReadRetries = 0
+}
+
 type voteValue struct {
 	k string
 	v interface{}
@@ -93,8 +110,58 @@ var tstData = []voteValue{
 	{k: "reward.deferredtxfee", v: "true", e: false},
 	{k: "reward.minimumstake", v: "2000000000000000000000000", e: true},
 	{k: "reward.minimumstake", v: 200000000000000, e: false},
+	{k: "reward.minimumstake", v: "100000000000000000000000000000", e: true},
+	{k: "reward.minimumstake", v: "100000000000000000000000000001", e: false},
 	{k: "reward.stakingupdateinterval", v: uint64(20), e: false},
 	{k: "reward.proposerupdateinterval", v: uint64(20), e: false},
+	{k: "governance.blockgaslimit", v: uint64(84000000), e: true},
+	{k: "governance.blockgaslimit", v: float64(84000000), e: true},
+	{k: "governance.blockgaslimit", v: uint64(30000000), e: true},
+	{k: "governance.blockgaslimit", v: uint64(29999999), e: false},
+	{k: "governance.blockgaslimit", v: uint64(4999), e: false},
+	{k: "governance.blockgaslimit", v: uint64(0), e: false},
+	{k: "governance.blockgaslimit", v: "84000000", e: false},
+	{k: "governance.targetgasperblock", v: uint64(30000000), e: true},
+	{k: "governance.targetgasperblock", v: uint64(4999), e: false},
+	{k: "governance.targetgasperblock", v: "30000000", e: false},
+	{k: "governance.basefeedenominator", v: uint64(8), e: true},
+	{k: "governance.basefeedenominator", v: uint64(1), e: true},
+	{k: "governance.basefeedenominator", v: uint64(1000), e: true},
+	{k: "governance.basefeedenominator", v: uint64(0), e: false},
+	{k: "governance.basefeedenominator", v: uint64(1001), e: false},
+	{k: "governance.basefeedenominator", v: true, e: false},
+	{k: "reward.kiraddress", v: common.HexToAddress("0x1234567890123456789012345678901234567890"), e: true},
+	{k: "reward.kiraddress", v: "0x1234567890123456789012345678901234567890", e: true},
+	{k: "reward.kiraddress", v: common.HexToAddress("0x0000000000000000000000000000000000000000"), e: false},
+	{k: "reward.kiraddress", v: "0x0000000000000000000000000000000000000000", e: false},
+	{k: "reward.kiraddress", v: uint64(1), e: false},
+	{k: "reward.pocaddress", v: common.HexToAddress("0x1234567890123456789012345678901234567890"), e: true},
+	{k: "reward.pocaddress", v: common.HexToAddress("0x0000000000000000000000000000000000000000"), e: false},
+	{k: "reward.pocaddress", v: "not an address", e: false},
+	{k: "reward.addressbook", v: common.HexToAddress("0x0000000000000000000000000000000000000401"), e: true},
+	{k: "reward.addressbook", v: "0x0000000000000000000000000000000000000401", e: true},
+	{k: "reward.addressbook", v: common.HexToAddress("0x0000000000000000000000000000000000000000"), e: false},
+	{k: "reward.addressbook", v: "0x0000000000000000000000000000000000000000", e: false},
+	{k: "reward.addressbook", v: uint64(1), e: false},
+	{k: "param.maxtxgas", v: uint64(100000000), e: true},
+	{k: "param.maxtxgas", v: float64(100000000), e: true},
+	{k: "param.maxtxgas", v: uint64(21000), e: true},
+	{k: "param.maxtxgas", v: uint64(20999), e: false},
+	{k: "param.maxtxgas", v: uint64(0), e: false},
+	{k: "param.maxtxgas", v: "100000000", e: false},
+	{k: "governance.forkschedule", v: "fork1:1000", e: true},
+	{k: "governance.forkschedule", v: "Fork_1:1000,fork2:2000", e: true},
+	{k: "governance.forkschedule", v: "", e: true},
+	{k: "governance.forkschedule", v: "fork1", e: false},
+	{k: "governance.forkschedule", v: "fork1:-1", e: false},
+	{k: "governance.forkschedule", v: "fork1:1000,fork1:2000", e: false},
+	{k: "governance.forkschedule", v: "fork 1:1000", e: false},
+	{k: "governance.forkschedule", v: uint64(1000), e: false},
+	{k: "param.humanreadableaddress", v: true, e: true},
+	{k: "param.humanreadableaddress", v: false, e: true},
+	{k: "param.humanreadableaddress", v: 0, e: false},
+	{k: "param.humanreadableaddress", v: 1, e: false},
+	{k: "param.humanreadableaddress", v: "true", e: false},
 }
 
 var goodVotes = []voteValue{
@@ -106,6 +173,15 @@ var goodVotes = []voteValue{
 	{k: "reward.useginicoeff", v: false, e: true},
 	{k: "reward.mintingamount", v: "9600000000000000000", e: true},
 	{k: "reward.ratio", v: "10/10/80", e: true},
+	{k: "governance.blockgaslimit", v: uint64(84000000), e: true},
+	{k: "governance.targetgasperblock", v: uint64(20000000), e: true},
+	{k: "governance.basefeedenominator", v: uint64(8), e: true},
+	{k: "reward.kiraddress", v: common.HexToAddress("0x1234567890123456789012345678901234567890"), e: true},
+	{k: "reward.pocaddress", v: common.HexToAddress("0x1234567890123456789012345678901234567891"), e: true},
+	{k: "param.maxtxgas", v: uint64(100000000), e: true},
+	{k: "governance.forkschedule", v: "fork1:1000", e: true},
+	{k: "param.humanreadableaddress", v: true, e: true},
+	{k: "reward.addressbook", v: common.HexToAddress("0x0000000000000000000000000000000000000401"), e: true},
 }
 
 func getTestConfig() *params.ChainConfig {
@@ -115,6 +191,22 @@ func getTestConfig() *params.ChainConfig {
 	return config
 }
 
+// newTestBlockChain makes a blockchain which has n blocks on the given database
+func newTestBlockChain(t *testing.T, dbm database.DBManager, config *params.ChainConfig, n int) *blockchain.BlockChain {
+	genesis := (&blockchain.Genesis{Config: config}).MustCommit(dbm)
+	engine := gxhash.NewFaker()
+
+	bc, err := blockchain.NewBlockChain(dbm, nil, config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create a blockchain: %v", err)
+	}
+	blocks, _ := blockchain.GenerateChain(config, genesis, engine, dbm, n, nil)
+	if _, err := bc.InsertChain(blocks); err != nil {
+		t.Fatalf("Failed to insert blocks: %v", err)
+	}
+	return bc
+}
+
 func getGovernance() *Governance {
 	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
 	config := getTestConfig()
@@ -271,6 +363,128 @@ func TestGovernance_GetEncodedVote(t *testing.T) {
 	}
 }
 
+func TestGovernance_GetEncodedVote_Order(t *testing.T) {
+	addr := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	votes := []voteValue{
+		{k: "reward.useginicoeff", v: false},
+		{k: "istanbul.epoch", v: uint64(20000)},
+		{k: "governance.unitprice", v: uint64(25000000000)},
+		{k: "reward.ratio", v: "10/10/80"},
+		{k: "istanbul.committeesize", v: uint64(7)},
+		{k: "governance.governancemode", v: "single"},
+		{k: "reward.mintingamount", v: "9600000000000000000"},
+	}
+	expected := []string{
+		"governance.governancemode",
+		"governance.unitprice",
+		"istanbul.committeesize",
+		"istanbul.epoch",
+		"reward.mintingamount",
+		"reward.ratio",
+		"reward.useginicoeff",
+	}
+
+	// The order should be same regardless of the iteration order of the map
+	for i := 0; i < 10; i++ {
+		gov := getGovernance()
+		for _, val := range votes {
+			gov.AddVote(val.k, val.v)
+		}
+
+		emitted := []string{}
+		for num := uint64(1); ; num++ {
+			voteData := gov.GetEncodedVote(addr, num)
+			if voteData == nil {
+				break
+			}
+			v := new(GovernanceVote)
+			rlp.DecodeBytes(voteData, v)
+			emitted = append(emitted, v.Key)
+			gov.RemoveVote(v.Key, gov.voteMap[v.Key].Value, num)
+		}
+		assert.Equal(t, expected, emitted)
+	}
+}
+
+func TestGovernance_GetEncodedVoteForKey(t *testing.T) {
+	gov := getGovernance()
+	addr := common.HexToAddress("0x1234567890123456789012345678901234567890")
+
+	gov.AddVote("governance.unitprice", uint64(25000000000))
+	gov.AddVote("istanbul.committeesize", uint64(7))
+
+	// The vote for the key is returned even if another vote comes first
+	voteData, err := gov.GetEncodedVoteForKey(addr, 1000, "istanbul.committeesize")
+	assert.NoError(t, err)
+	v := new(GovernanceVote)
+	assert.NoError(t, rlp.DecodeBytes(voteData, v))
+	assert.Equal(t, addr, v.Validator)
+	assert.Equal(t, "istanbul.committeesize", v.Key)
+	v, err = gov.ParseVoteValue(v)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), v.Value)
+	assert.Equal(t, uint64(1000), gov.voteMap["istanbul.committeesize"].Emitted)
+
+	// It can be emitted again in the same epoch to retry
+	_, err = gov.GetEncodedVoteForKey(addr, 1001, "istanbul.committeesize")
+	assert.NoError(t, err)
+
+	// A casted vote isn't returned
+	gov.RemoveVote("istanbul.committeesize", uint64(7), 1001)
+	voteData, err = gov.GetEncodedVoteForKey(addr, 1002, "istanbul.committeesize")
+	assert.Nil(t, voteData)
+	assert.Equal(t, ErrVoteAlreadyCasted, err)
+
+	// Nothing is returned for an absent key
+	voteData, err = gov.GetEncodedVoteForKey(addr, 1002, "reward.ratio")
+	assert.Nil(t, voteData)
+	assert.Equal(t, ErrVoteNotFound, err)
+}
+
+func TestGovernance_GetEncodedVote_Replay(t *testing.T) {
+	gov := getGovernance()
+	epoch := gov.ChainConfig.Istanbul.Epoch
+	addr := common.HexToAddress("0x1234567890123456789012345678901234567890")
+
+	gov.AddVote("governance.unitprice", uint64(25000000000))
+	decode := func(b []byte) *GovernanceVote {
+		v := new(GovernanceVote)
+		if err := rlp.DecodeBytes(b, v); err != nil {
+			t.Fatalf("Failed to decode a vote: %v", err)
+		}
+		return v
+	}
+
+	// The vote is emitted at block 1000
+	voteData := gov.GetEncodedVote(addr, 1000)
+	assert.NotNil(t, voteData)
+	assert.Equal(t, "governance.unitprice", decode(voteData).Key)
+
+	// The vote was included but RemoveVote failed to match the value, so it is still not casted
+	gov.RemoveVote("governance.unitprice", float64(25000000000), 1000)
+	assert.False(t, gov.voteMap["governance.unitprice"].Casted)
+
+	// It is not emitted again in the same epoch
+	assert.Nil(t, gov.GetEncodedVote(addr, 1001))
+	assert.Nil(t, gov.GetEncodedVote(addr, epoch-1))
+
+	// Other votes are emitted meanwhile
+	gov.AddVote("istanbul.committeesize", uint64(7))
+	voteData = gov.GetEncodedVote(addr, 1002)
+	assert.Equal(t, "istanbul.committeesize", decode(voteData).Key)
+	gov.RemoveVote("istanbul.committeesize", uint64(7), 1002)
+
+	// It can be emitted again for the same block, e.g., when the block is proposed again after a round change
+	gov.voteMap["governance.unitprice"] = VoteStatus{Value: uint64(25000000000), Emitted: 2000}
+	assert.NotNil(t, gov.GetEncodedVote(addr, 2000))
+
+	// It is emitted again in the next epoch
+	assert.Nil(t, gov.GetEncodedVote(addr, 2001))
+	voteData = gov.GetEncodedVote(addr, epoch+1)
+	assert.Equal(t, "governance.unitprice", decode(voteData).Key)
+	assert.Equal(t, epoch+1, gov.voteMap["governance.unitprice"].Emitted)
+}
+
 func TestGovernance_ParseVoteValue(t *testing.T) {
 	var err error
 	gov := getGovernance()
@@ -343,9 +557,10 @@ func TestGovernancePersistence(t *testing.T) {
 	for i := 1; i < length; i++ {
 		num := tstIdx[i]
 		compMap, _ := gov.db.ReadGovernance(num)
+		compMap = adjustDecodedSet(compMap)
 
 		expected := testGovernanceMap["governance.unitprice"].(uint64) + uint64(i)*params.DefaultEpoch
-		if uint64(compMap["governance.unitprice"].(float64)) != expected {
+		if compMap["governance.unitprice"].(uint64) != expected {
 			t.Errorf("Retrieved %v, Expected %v", compMap["governance.unitprice"], expected)
 		}
 	}
@@ -546,3 +761,2900 @@ func TestCypressGenesisHash(t *testing.T) {
 		t.Errorf("Generated hash is not equal to Baobab's hash. Want %v, Have %v", cypressHash.String(), block.Hash().String())
 	}
 }
+
+func TestNormalizeGovernanceItem(t *testing.T) {
+	testCases := []struct {
+		key      string
+		value    interface{}
+		expected interface{}
+	}{
+		{"istanbul.epoch", float64(604800), uint64(604800)},
+		{"istanbul.epoch", json.Number("604800"), uint64(604800)},
+		{"istanbul.epoch", "604800", uint64(604800)},
+		{"istanbul.epoch", uint64(604800), uint64(604800)},
+		{"istanbul.epoch", "not a number", "not a number"},
+		{"governance.unitprice", json.Number("25000000000"), uint64(25000000000)},
+		{"reward.mintingamount", float64(9600000000000000000), "9600000000000000000"},
+		{"reward.mintingamount", json.Number("9600000000000000000"), "9600000000000000000"},
+		{"reward.mintingamount", "9600000000000000000", "9600000000000000000"},
+		{"reward.useginicoeff", "true", true},
+		{"reward.useginicoeff", false, false},
+		{"governance.governingnode", "0x1234567890123456789012345678901234567890", common.HexToAddress("0x1234567890123456789012345678901234567890")},
+		{"clique.epoch", float64(30000), uint64(30000)},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, normalizeGovernanceItem(tc.key, tc.value), "key: %v, value: %v", tc.key, tc.value)
+	}
+
+	decoded := adjustDecodedSet(map[string]interface{}{
+		"istanbul.epoch":         json.Number("30000"),
+		"istanbul.committeesize": "7",
+		"governance.unitprice":   float64(25000000000),
+	})
+	assert.Equal(t, uint64(30000), decoded["istanbul.epoch"])
+	assert.Equal(t, uint64(7), decoded["istanbul.committeesize"])
+	assert.Equal(t, uint64(25000000000), decoded["governance.unitprice"])
+}
+
+func TestGovernance_HasValue(t *testing.T) {
+	gov := getGovernance()
+
+	// Items from the genesis config are set even if they have default values
+	assert.True(t, gov.HasValue(params.UnitPrice))
+	assert.True(t, gov.HasValue(params.BlockGasLimit))
+	assert.Equal(t, params.DefaultBlockGasLimit, gov.GetGovernanceValue(params.BlockGasLimit))
+
+	// Items which are not in the current set
+	assert.False(t, gov.HasValue(params.AddValidator))
+	assert.False(t, gov.HasValue(params.CliqueEpoch))
+	assert.False(t, gov.HasValue(-1))
+
+	// An empty governance has no value
+	empty := NewGovernance(getTestConfig(), nil)
+	assert.False(t, empty.HasValue(params.UnitPrice))
+	empty.currentSet.SetValue(params.UnitPrice, uint64(0))
+	assert.True(t, empty.HasValue(params.UnitPrice))
+}
+
+func TestGovernanceItemMeta(t *testing.T) {
+	meta := GovernanceItemMeta()
+	assert.Equal(t, len(GovernanceKeyMap), len(meta))
+
+	for key := range GovernanceForbiddenKeyMap {
+		assert.False(t, meta[key].Mutable, key)
+	}
+	assert.True(t, meta["governance.unitprice"].Mutable)
+	assert.Equal(t, "uint64", meta["governance.unitprice"].Type)
+	assert.Equal(t, "string", meta["reward.mintingamount"].Type)
+	assert.Equal(t, "bool", meta["reward.useginicoeff"].Type)
+	assert.Equal(t, "common.Address", meta["governance.governingnode"].Type)
+	assert.Equal(t, "uint64", meta["istanbul.policy"].Type)
+}
+
+func TestGovernanceKeysByCategory(t *testing.T) {
+	categories := GovernanceKeysByCategory()
+
+	expected := map[string][]string{
+		"governance": {
+			"governance.addvalidator",
+			"governance.basefeedenominator",
+			"governance.blockgaslimit",
+			"governance.forkschedule",
+			"governance.governancemode",
+			"governance.governingnode",
+			"governance.removevalidator",
+			"governance.targetgasperblock",
+			"governance.unitprice",
+		},
+		"istanbul": {
+			"istanbul.committeesize",
+			"istanbul.epoch",
+			"istanbul.policy",
+		},
+		"reward": {
+			"reward.addressbook",
+			"reward.deferredtxfee",
+			"reward.kiraddress",
+			"reward.minimumstake",
+			"reward.mintingamount",
+			"reward.pocaddress",
+			"reward.proposerupdateinterval",
+			"reward.ratio",
+			"reward.stakingupdateinterval",
+			"reward.useginicoeff",
+		},
+		"param": {
+			"param.humanreadableaddress",
+			"param.maxtxgas",
+			"param.txgashumanreadable",
+		},
+	}
+	assert.Equal(t, expected, categories)
+
+	// Every key should be listed exactly once
+	count := 0
+	for category, keys := range categories {
+		for _, key := range keys {
+			assert.True(t, strings.HasPrefix(key, category+"."))
+			_, ok := GovernanceKeyMap[key]
+			assert.True(t, ok)
+		}
+		count += len(keys)
+	}
+	assert.Equal(t, len(GovernanceKeyMap), count)
+}
+
+func TestGovernanceSet_MergeWithReport(t *testing.T) {
+	gs := NewGovernanceSet()
+	gs.SetValue(params.UnitPrice, uint64(25000000000))
+	gs.SetValue(params.Epoch, uint64(30000))
+	gs.SetValue(params.GovernanceMode, "single")
+
+	report := gs.MergeWithReport(map[string]interface{}{
+		"governance.unitprice":      uint64(50000000000), // overwritten
+		"istanbul.epoch":            uint64(30000),       // overwritten by the same value
+		"istanbul.committeesize":    uint64(7),           // new
+		"governance.governancemode": "ballot",            // overwritten
+	})
+
+	expected := map[string]GovernanceItemChange{
+		"governance.unitprice":      {Old: uint64(25000000000), New: uint64(50000000000)},
+		"governance.governancemode": {Old: "single", New: "ballot"},
+	}
+	assert.Equal(t, expected, report)
+
+	// Every change is merged
+	assert.Equal(t, map[string]interface{}{
+		"governance.unitprice":      uint64(50000000000),
+		"istanbul.epoch":            uint64(30000),
+		"istanbul.committeesize":    uint64(7),
+		"governance.governancemode": "ballot",
+	}, gs.Items())
+
+	// Nothing is reported for an empty set
+	empty := NewGovernanceSet()
+	assert.Equal(t, 0, len(empty.MergeWithReport(gs.Items())))
+}
+
+func TestGovernanceSet_GetValueByName(t *testing.T) {
+	gs := NewGovernanceSet()
+	gs.SetValue(params.UnitPrice, uint64(25000000000))
+	gs.SetValue(params.GovernanceMode, "single")
+
+	testCases := []struct {
+		name  string
+		value interface{}
+		ok    bool
+	}{
+		{"governance.unitprice", uint64(25000000000), true},
+		{"  Governance.UnitPrice ", uint64(25000000000), true},
+		{"\tGOVERNANCE.GOVERNANCEMODE\n", "single", true},
+		// known key, but not in the set
+		{"istanbul.epoch", nil, false},
+		// unknown keys
+		{"governance.unknown", nil, false},
+		{"governance. unitprice", nil, false},
+		{"", nil, false},
+	}
+	for _, tc := range testCases {
+		v, ok := gs.GetValueByName(tc.name)
+		assert.Equal(t, tc.ok, ok, "name: %q", tc.name)
+		assert.Equal(t, tc.value, v, "name: %q", tc.name)
+	}
+}
+
+func TestGovernanceSet_TypedGetters(t *testing.T) {
+	addr := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	defAddr := common.HexToAddress("0x0000000000000000000000000000000000000001")
+
+	gs := NewGovernanceSet()
+	gs.SetValue(params.UnitPrice, uint64(25000000000))
+	gs.SetValue(params.Ratio, "34/54/12")
+	gs.SetValue(params.UseGiniCoeff, true)
+	gs.SetValue(params.GoverningNode, addr)
+
+	// present
+	assert.Equal(t, uint64(25000000000), gs.GetUint64(params.UnitPrice, 1))
+	assert.Equal(t, "34/54/12", gs.GetString(params.Ratio, "100/0/0"))
+	assert.Equal(t, true, gs.GetBool(params.UseGiniCoeff, false))
+	assert.Equal(t, addr, gs.GetAddress(params.GoverningNode, defAddr))
+
+	// absent
+	assert.Equal(t, uint64(1), gs.GetUint64(params.Epoch, 1))
+	assert.Equal(t, "100", gs.GetString(params.MintingAmount, "100"))
+	assert.Equal(t, true, gs.GetBool(params.DeferredTxFee, true))
+	assert.Equal(t, defAddr, gs.GetAddress(params.AddValidator, defAddr))
+
+	// wrong type
+	assert.Equal(t, uint64(1), gs.GetUint64(params.Ratio, 1))
+	assert.Equal(t, "100", gs.GetString(params.UnitPrice, "100"))
+	assert.Equal(t, false, gs.GetBool(params.GoverningNode, false))
+	assert.Equal(t, defAddr, gs.GetAddress(params.UseGiniCoeff, defAddr))
+}
+
+// testSnapshotEngine is a faker engine whose snapshot has the same validator set at every block
+type testSnapshotEngine struct {
+	*gxhash.Gxhash
+	valset istanbul.ValidatorSet
+}
+
+func (e *testSnapshotEngine) ValidatorSetAt(num uint64) (istanbul.ValidatorSet, error) {
+	return e.valset.Copy(), nil
+}
+
+func TestGovernance_RollbackTo(t *testing.T) {
+	validators := []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2"), common.HexToAddress("0x3")}
+	config := getTestConfig()
+	config.Istanbul.Epoch = 10
+	config.Governance.GovernanceMode = "ballot"
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	gov := NewGovernance(config, dbm)
+	genesisPrice := gov.GetGovernanceValue(params.UnitPrice)
+
+	// Validators vote in the headers of the first two epochs
+	headerVotes := map[int]GovernanceVote{
+		15: {Validator: validators[0], Key: "istanbul.committeesize", Value: uint64(7)},
+		21: {Validator: validators[0], Key: "governance.unitprice", Value: uint64(75000000000)},
+		23: {Validator: validators[1], Key: "governance.unitprice", Value: uint64(75000000000)},
+	}
+	genesis := (&blockchain.Genesis{Config: config}).MustCommit(dbm)
+	engine := &testSnapshotEngine{Gxhash: gxhash.NewFaker(), valset: newTestValidatorSet(validators...)}
+	bc, err := blockchain.NewBlockChain(dbm, nil, config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create a blockchain: %v", err)
+	}
+	blocks, _ := blockchain.GenerateChain(config, genesis, engine.Gxhash, dbm, 25, func(i int, b *blockchain.BlockGen) {
+		if vote, ok := headerVotes[i+1]; ok {
+			data, _ := rlp.EncodeToBytes(&vote)
+			b.SetVoteData(data)
+		}
+	})
+	if _, err := bc.InsertChain(blocks); err != nil {
+		t.Fatalf("Failed to insert blocks: %v", err)
+	}
+
+	// Without the blockchain, votes can't be rebuilt
+	assert.Equal(t, ErrNotInitialized, gov.RollbackTo(22))
+	gov.SetBlockchain(bc)
+
+	// The validator set of the node may differ from the one of the snapshot at the epoch boundary
+	gov.SetValidatorSet(newTestValidatorSet(validators[0]))
+
+	// A governance change is stored at the first epoch block and applied after an epoch
+	delta := NewGovernanceSet()
+	delta.SetValue(params.UnitPrice, uint64(50000000000))
+	if err := gov.WriteGovernance(10, gov.currentSet, delta); err != nil {
+		t.Fatalf("Failed to write governance: %v", err)
+	}
+	gov.UpdateCurrentGovernance(20)
+	assert.Equal(t, uint64(10), gov.actualGovernanceBlock)
+	assert.Equal(t, uint64(50000000000), gov.GetGovernanceValue(params.UnitPrice))
+
+	// The votes of the epoch are tallied up to the block 25, and this node casted its vote at the block 23
+	gov.AddVote("governance.unitprice", uint64(75000000000))
+	gov.RemoveVote("governance.unitprice", uint64(75000000000), 23)
+	assert.NoError(t, gov.RollbackTo(25))
+	assert.Equal(t, 2, len(gov.GovernanceVotes.Copy()))
+	assert.Equal(t, []GovernanceTallyItem{{Key: "governance.unitprice", Value: uint64(75000000000), Votes: 2000}}, gov.GovernanceTallies.Copy())
+	assert.Equal(t, map[string]interface{}{"governance.unitprice": uint64(75000000000)}, gov.changeSet.Items())
+
+	// The chain is reorganized in the middle of the epoch. Only the votes after the block are rolled back
+	assert.NoError(t, gov.RollbackTo(22))
+	assert.Equal(t, uint64(10), gov.actualGovernanceBlock)
+	votes := gov.GovernanceVotes.Copy()
+	assert.Equal(t, 1, len(votes))
+	assert.Equal(t, validators[0], votes[0].Validator)
+	assert.Equal(t, uint64(21), votes[0].BlockNumber)
+	assert.Equal(t, []GovernanceTallyItem{{Key: "governance.unitprice", Value: uint64(75000000000), Votes: 1000}}, gov.GovernanceTallies.Copy())
+	assert.Equal(t, 0, gov.changeSet.Size())
+	assert.Equal(t, false, gov.voteMap["governance.unitprice"].Casted)
+
+	// Governance written at the epoch boundary 20 came from a header which is reorganized away
+	if err := gov.WriteGovernance(20, gov.currentSet, delta); err != nil {
+		t.Fatalf("Failed to write governance: %v", err)
+	}
+	applied, unsubscribe := gov.WatchValue(params.UnitPrice, func(v interface{}) bool { return v == genesisPrice })
+	defer unsubscribe()
+
+	// The chain is reorganized below the epoch boundary where the change was applied
+	assert.NoError(t, gov.RollbackTo(19))
+	assert.Equal(t, uint64(0), gov.actualGovernanceBlock)
+	assert.Equal(t, genesisPrice, gov.GetGovernanceValue(params.UnitPrice))
+	assert.Equal(t, genesisPrice, gov.ChainConfig.UnitPrice)
+	assert.Equal(t, uint64(0), <-applied)
+	indices, err := dbm.ReadRecentGovernanceIdx(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{0, 10}, indices)
+	assert.Equal(t, []uint64{0, 10}, gov.idxCache)
+	_, err = dbm.ReadGovernance(20)
+	assert.Error(t, err)
+	votes = gov.GovernanceVotes.Copy()
+	assert.Equal(t, 1, len(votes))
+	assert.Equal(t, "istanbul.committeesize", votes[0].Key)
+	assert.Equal(t, uint64(15), votes[0].BlockNumber)
+
+	// Nothing is left at an epoch boundary, since votes are cleared there
+	assert.NoError(t, gov.RollbackTo(20))
+	assert.Equal(t, 0, len(gov.GovernanceVotes.Copy()))
+	assert.Equal(t, 0, len(gov.GovernanceTallies.Copy()))
+}
+
+func TestGovernance_BaseFeeItems(t *testing.T) {
+	gov := getGovernance()
+
+	// Genesis governance has the default base fee parameters while unitprice is kept
+	assert.Equal(t, params.DefaultTargetGasPerBlock, gov.GetGovernanceValue(params.TargetGasPerBlock))
+	assert.Equal(t, params.DefaultBaseFeeDenominator, gov.GetGovernanceValue(params.BaseFeeDenominator))
+	assert.Equal(t, gov.ChainConfig.UnitPrice, gov.GetGovernanceValue(params.UnitPrice))
+
+	testCases := []struct {
+		key   string
+		value uint64
+	}{
+		{"governance.targetgasperblock", 20000000},
+		{"governance.basefeedenominator", 8},
+	}
+	for _, tc := range testCases {
+		v := &GovernanceVote{Key: tc.key, Value: tc.value}
+		b, _ := rlp.EncodeToBytes(v)
+		d := new(GovernanceVote)
+		rlp.DecodeBytes(b, d)
+		d, err := gov.ParseVoteValue(d)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, tc.value, d.Value)
+
+		gov.ReflectVotes(*d)
+		changed, ok := gov.changeSet.GetValue(GovernanceKeyMap[tc.key])
+		assert.True(t, ok)
+		assert.Equal(t, tc.value, changed)
+	}
+}
+
+func TestGovernance_RewardAddressItems(t *testing.T) {
+	gov := getGovernance()
+
+	// Genesis governance has zero addresses, which means the AddressBook is used
+	assert.Equal(t, common.Address{}, gov.GetGovernanceValue(params.KIRAddress))
+	assert.Equal(t, common.Address{}, gov.GetGovernanceValue(params.PoCAddress))
+	assert.Equal(t, common.Address{}, gov.GetGovernanceValue(params.AddressBookAddress))
+
+	testCases := []struct {
+		key   string
+		value common.Address
+	}{
+		{"reward.kiraddress", common.HexToAddress("0x1234567890123456789012345678901234567890")},
+		{"reward.pocaddress", common.HexToAddress("0x1234567890123456789012345678901234567891")},
+		{"reward.addressbook", common.HexToAddress("0x0000000000000000000000000000000000000401")},
+	}
+	for _, tc := range testCases {
+		v := &GovernanceVote{Key: tc.key, Value: tc.value}
+		b, _ := rlp.EncodeToBytes(v)
+		d := new(GovernanceVote)
+		rlp.DecodeBytes(b, d)
+		d, err := gov.ParseVoteValue(d)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, tc.value, d.Value)
+
+		gov.ReflectVotes(*d)
+		changed, ok := gov.changeSet.GetValue(GovernanceKeyMap[tc.key])
+		assert.True(t, ok)
+		assert.Equal(t, tc.value, changed)
+
+		// A zero address can't be voted
+		assert.False(t, gov.AddVote(tc.key, common.Address{}))
+		assert.False(t, gov.AddVote(tc.key, "0x0000000000000000000000000000000000000000"))
+		assert.True(t, gov.AddVote(tc.key, tc.value.Hex()))
+	}
+}
+
+func TestCheckGenesisValues_CommitteeSize(t *testing.T) {
+	config := getTestConfig()
+	assert.NoError(t, CheckGenesisValues(config))
+
+	config.Istanbul.SubGroupSize = 0
+	assert.Equal(t, ErrZeroCommitteeSize, CheckGenesisValues(config))
+
+	config.Istanbul.SubGroupSize = 1
+	assert.NoError(t, CheckGenesisValues(config))
+}
+
+func TestCheckGenesisValues_ConsensusEngine(t *testing.T) {
+	// istanbul only
+	config := getTestConfig()
+	defer func() { config.Clique = nil }()
+	assert.NoError(t, CheckGenesisValues(config))
+
+	// clique only
+	config.Istanbul = nil
+	config.Clique = GetDefaultCliqueConfig()
+	assert.NoError(t, CheckGenesisValues(config))
+
+	// both
+	config.Istanbul = GetDefaultIstanbulConfig()
+	assert.Equal(t, ErrConflictingEngines, CheckGenesisValues(config))
+}
+
+func TestValidateGenesisGovernance(t *testing.T) {
+	config := getTestConfig()
+	defer func() { config.Clique = nil }()
+	assert.Empty(t, ValidateGenesisGovernance(config))
+
+	// Several wrong values at once
+	config.Clique = GetDefaultCliqueConfig()
+	config.Istanbul.SubGroupSize = 0
+	config.Istanbul.Epoch = 0
+	config.Governance.GovernanceMode = "unknown"
+	config.Governance.Reward.Ratio = "30/70"
+
+	errs := ValidateGenesisGovernance(config)
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	assert.Equal(t, []string{
+		ErrConflictingEngines.Error(),
+		ErrZeroCommitteeSize.Error(),
+		"governance.governancemode value is wrong",
+		"istanbul.epoch value is wrong",
+		"reward.ratio value is wrong",
+	}, msgs)
+
+	// CheckGenesisValues returns the first one
+	assert.Equal(t, ErrConflictingEngines, CheckGenesisValues(config))
+	// The given config is not modified
+	assert.NotNil(t, config.Clique)
+}
+
+func TestGovernance_WatchValue(t *testing.T) {
+	gov := getGovernance()
+	epoch := gov.ChainConfig.Istanbul.Epoch
+
+	target, _ := gov.WatchValue(params.UnitPrice, func(v interface{}) bool { return v == uint64(75000000000) })
+	never, unsubscribe := gov.WatchValue(params.UnitPrice, func(v interface{}) bool { return v == uint64(1) })
+
+	// unitprice is changed at the first epoch and changed again at the second epoch
+	for i, price := range []uint64{50000000000, 75000000000} {
+		delta := NewGovernanceSet()
+		delta.SetValue(params.UnitPrice, price)
+		if err := gov.WriteGovernance(uint64(i+1)*epoch, gov.currentSet, delta); err != nil {
+			t.Fatalf("Failed to write governance: %v", err)
+		}
+	}
+
+	// The first change doesn't satisfy the predicate
+	gov.UpdateCurrentGovernance(2*epoch + 1)
+	select {
+	case num := <-target:
+		t.Fatalf("Watcher fired too early at block %d", num)
+	default:
+	}
+
+	// The second change satisfies the predicate and the watch is finished
+	gov.UpdateCurrentGovernance(3*epoch + 1)
+	num, ok := <-target
+	assert.True(t, ok)
+	assert.Equal(t, 2*epoch, num)
+	_, ok = <-target
+	assert.False(t, ok)
+
+	// A predicate which is never satisfied is notified nothing until unsubscribed
+	select {
+	case num := <-never:
+		t.Fatalf("Watcher should not fire, but fired at block %d", num)
+	default:
+	}
+	unsubscribe()
+	_, ok = <-never
+	assert.False(t, ok)
+	unsubscribe()
+	assert.Equal(t, 0, len(gov.valueWatchers))
+}
+
+func TestGovernance_BlockGasLimit(t *testing.T) {
+	gov := getGovernance()
+
+	// Genesis governance has the default block gas limit
+	assert.Equal(t, params.DefaultBlockGasLimit, gov.GetGovernanceValue(params.BlockGasLimit))
+
+	// A vote is parsed into uint64
+	v := &GovernanceVote{Key: "governance.blockgaslimit", Value: uint64(84000000)}
+	b, _ := rlp.EncodeToBytes(v)
+	d := new(GovernanceVote)
+	rlp.DecodeBytes(b, d)
+	d, err := gov.ParseVoteValue(d)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint64(84000000), d.Value)
+
+	// A passed vote is reflected into the change set
+	gov.ReflectVotes(*d)
+	changed, ok := gov.changeSet.GetValue(params.BlockGasLimit)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(84000000), changed)
+}
+
+// divergentDBManager returns modified governance items for the given block numbers
+type divergentDBManager struct {
+	database.DBManager
+	divergent map[uint64]bool
+}
+
+func (dbm *divergentDBManager) ReadGovernance(num uint64) (map[string]interface{}, error) {
+	data, err := dbm.DBManager.ReadGovernance(num)
+	if err == nil && dbm.divergent[num] {
+		data["governance.unitprice"] = float64(1)
+	}
+	return data, err
+}
+
+func TestGovernance_VerifyCacheConsistency(t *testing.T) {
+	gov := getGovernance()
+	epoch := gov.ChainConfig.Istanbul.Epoch
+
+	for i := uint64(1); i <= 3; i++ {
+		delta := NewGovernanceSet()
+		delta.SetValue(params.UnitPrice, uint64(25000000000)+i)
+		if err := gov.WriteGovernance(i*epoch, gov.currentSet, delta); err != nil {
+			t.Fatalf("Failed to write governance: %v", err)
+		}
+	}
+
+	// Cache and database are consistent
+	assert.Equal(t, []uint64{}, gov.VerifyCacheConsistency())
+
+	// Inject a divergence into the database
+	gov.db = &divergentDBManager{DBManager: gov.db, divergent: map[uint64]bool{2 * epoch: true}}
+	assert.Equal(t, []uint64{2 * epoch}, gov.VerifyCacheConsistency())
+}
+
+func TestGovernance_SetCacheWarmup(t *testing.T) {
+	gov := getGovernance()
+	gov.ChainConfig.Istanbul.Epoch = 10
+	defer func() { gov.ChainConfig.Istanbul.Epoch = params.DefaultEpoch }()
+
+	for _, num := range []uint64{10, 20, 30} {
+		delta := NewGovernanceSet()
+		delta.SetValue(params.UnitPrice, num)
+		if err := gov.WriteGovernance(num, gov.currentSet, delta); err != nil {
+			t.Fatalf("Failed to write governance: %v", err)
+		}
+	}
+	bc := newTestBlockChain(t, gov.db, gov.ChainConfig, 35)
+
+	// Nothing is cached without warmup
+	gov.itemCache.Purge()
+	gov.SetBlockchain(bc)
+	_, ok := gov.getGovernanceCache(10)
+	assert.False(t, ok)
+
+	// Blocks 30, 20 and 10 use the governance items of blocks 20, 10 and 0
+	gov.SetCacheWarmup(3)
+	gov.SetBlockchain(bc)
+	_, ok = gov.getGovernanceCache(0)
+	assert.True(t, ok)
+	for _, num := range []uint64{10, 20} {
+		data, ok := gov.getGovernanceCache(num)
+		assert.True(t, ok, "num: %d", num)
+		assert.Equal(t, num, data["governance.unitprice"])
+	}
+	_, ok = gov.getGovernanceCache(30)
+	assert.False(t, ok)
+
+	// Warmup stops at the genesis block
+	gov.itemCache.Purge()
+	assert.Equal(t, 3, gov.warmupCache(35))
+	gov.SetCacheWarmup(10)
+	assert.Equal(t, 4, gov.warmupCache(35))
+	assert.Equal(t, 1, gov.warmupCache(5))
+}
+
+func TestGovernance_SetChangeCooldown(t *testing.T) {
+	gov := getGovernance()
+	gov.ChainConfig.Istanbul.Epoch = 10
+	defer func() { gov.ChainConfig.Istanbul.Epoch = params.DefaultEpoch }()
+
+	// unitprice was changed at block 10 and committeesize was changed at block 30
+	changes := []struct {
+		num   uint64
+		key   int
+		value interface{}
+	}{
+		{10, params.UnitPrice, uint64(50000000000)},
+		{30, params.CommitteeSize, uint64(7)},
+	}
+	for _, c := range changes {
+		delta := NewGovernanceSet()
+		delta.SetValue(c.key, c.value)
+		if err := gov.WriteGovernance(c.num, gov.currentSet, delta); err != nil {
+			t.Fatalf("Failed to write governance: %v", err)
+		}
+		gov.currentSet.Merge(delta.Items())
+	}
+	gov.SetBlockchain(newTestBlockChain(t, gov.db, gov.ChainConfig, 35))
+
+	// No limitation by default
+	_, ok := gov.ValidateVote(&GovernanceVote{Key: "istanbul.committeesize", Value: uint64(9), BlockNumber: 35})
+	assert.True(t, ok)
+
+	gov.SetChangeCooldown(2)
+
+	// committeesize was changed within the last 2 epochs
+	_, ok = gov.ValidateVote(&GovernanceVote{Key: "istanbul.committeesize", Value: uint64(9), BlockNumber: 35})
+	assert.False(t, ok)
+	assert.False(t, gov.AddVote("istanbul.committeesize", uint64(9)))
+
+	// unitprice was changed before the last 2 epochs
+	_, ok = gov.ValidateVote(&GovernanceVote{Key: "governance.unitprice", Value: uint64(25000000000), BlockNumber: 35})
+	assert.True(t, ok)
+
+	// epoch has never been changed
+	_, ok = gov.ValidateVote(&GovernanceVote{Key: "istanbul.epoch", Value: uint64(20), BlockNumber: 35})
+	assert.True(t, ok)
+
+	// The cooldown is measured from the block of a vote, not from the local head
+	_, ok = gov.ValidateVote(&GovernanceVote{Key: "istanbul.committeesize", Value: uint64(9), BlockNumber: 50})
+	assert.True(t, ok)
+	_, ok = gov.ValidateVote(&GovernanceVote{Key: "governance.unitprice", Value: uint64(25000000000), BlockNumber: 29})
+	assert.False(t, ok)
+}
+
+func TestGovernance_SetConfirmationDepth(t *testing.T) {
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	gov, err := NewGovernanceWithCacheConfig(getTestConfig(), dbm, CacheConfig{ItemCacheSize: 10, IdxCacheLimit: 10})
+	if err != nil {
+		t.Fatalf("Failed to make governance: %v", err)
+	}
+	gov.ChainConfig.Istanbul.Epoch = 10
+	defer func() { gov.ChainConfig.Istanbul.Epoch = params.DefaultEpoch }()
+	genesisPrice := gov.GetGovernanceValue(params.UnitPrice)
+	genesis := NewGovernanceSet()
+	genesis.Import(gov.currentSet.Items())
+	genesis.SetValue(params.Epoch, uint64(10))
+
+	writeUnitPrice := func(num uint64, price uint64) {
+		delta := NewGovernanceSet()
+		delta.SetValue(params.UnitPrice, price)
+		if err := gov.WriteGovernance(num, genesis, delta); err != nil {
+			t.Fatalf("Failed to write governance: %v", err)
+		}
+	}
+	setHead := func(n int) {
+		dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+		gov.SetBlockchain(newTestBlockChain(t, dbm, gov.ChainConfig, n))
+	}
+
+	gov.SetConfirmationDepth(5)
+
+	// The epoch boundary 20 is processed while it's inserted, so the change at block 10 isn't applied yet
+	writeUnitPrice(10, 50000000000)
+	setHead(19)
+	gov.UpdateCurrentGovernance(20)
+	assert.Equal(t, uint64(0), gov.actualGovernanceBlock)
+	assert.Equal(t, genesisPrice, gov.GetGovernanceValue(params.UnitPrice))
+
+	// The boundary isn't buried by 5 blocks yet
+	setHead(24)
+	gov.ApplyPendingGovernance()
+	assert.Equal(t, genesisPrice, gov.GetGovernanceValue(params.UnitPrice))
+
+	// The chain is reorganized before the confirmation, so only the change of the new chain is applied
+	gov.InvalidateCacheAbove(9)
+	writeUnitPrice(10, 60000000000)
+	setHead(25)
+	gov.ApplyPendingGovernance()
+	assert.Equal(t, uint64(10), gov.actualGovernanceBlock)
+	assert.Equal(t, uint64(60000000000), gov.GetGovernanceValue(params.UnitPrice))
+
+	// Nothing is pending after the change is applied
+	writeUnitPrice(20, 70000000000)
+	gov.ApplyPendingGovernance()
+	assert.Equal(t, uint64(10), gov.actualGovernanceBlock)
+
+	// The change of the boundary 30 is applied at once if the boundary is already confirmed
+	setHead(35)
+	gov.UpdateCurrentGovernance(30)
+	assert.Equal(t, uint64(20), gov.actualGovernanceBlock)
+	assert.Equal(t, uint64(70000000000), gov.GetGovernanceValue(params.UnitPrice))
+
+	// A reorganization after the confirmation doesn't flap the applied governance
+	gov.InvalidateCacheAbove(19)
+	writeUnitPrice(20, 80000000000)
+	gov.UpdateCurrentGovernance(30)
+	assert.Equal(t, uint64(70000000000), gov.GetGovernanceValue(params.UnitPrice))
+
+	// No confirmation is needed by default
+	gov.SetConfirmationDepth(0)
+	writeUnitPrice(30, 90000000000)
+	setHead(39)
+	gov.UpdateCurrentGovernance(40)
+	assert.Equal(t, uint64(30), gov.actualGovernanceBlock)
+	assert.Equal(t, uint64(90000000000), gov.GetGovernanceValue(params.UnitPrice))
+}
+
+func TestGovernance_ToGenesisConfig(t *testing.T) {
+	config := &params.ChainConfig{
+		UnitPrice: 25000000000,
+		Governance: &params.GovernanceConfig{
+			GovernanceMode: "single",
+			GoverningNode:  common.HexToAddress("0x1234567890123456789012345678901234567890"),
+			Reward: &params.RewardConfig{
+				MintingAmount:          new(big.Int).SetUint64(9600000000000000000),
+				Ratio:                  "34/54/12",
+				UseGiniCoeff:           true,
+				DeferredTxFee:          true,
+				StakingUpdateInterval:  86400,
+				ProposerUpdateInterval: 3600,
+				MinimumStake:           big.NewInt(5000000),
+			},
+		},
+		Istanbul: &params.IstanbulConfig{Epoch: 30, ProposerPolicy: params.WeightedRandom, SubGroupSize: 7},
+	}
+
+	// config -> items -> config
+	gov := NewGovernance(config, nil)
+	items := getGovernanceItemsFromChainConfig(config)
+	gov.currentSet.Import(items.Items())
+
+	governanceConfig := gov.ToGenesisConfig()
+	assert.Equal(t, config.Governance.GovernanceMode, governanceConfig.GovernanceMode)
+	assert.Equal(t, config.Governance.GoverningNode, governanceConfig.GoverningNode)
+
+	reward := governanceConfig.Reward
+	assert.Equal(t, 0, config.Governance.Reward.MintingAmount.Cmp(reward.MintingAmount))
+	assert.Equal(t, 0, config.Governance.Reward.MinimumStake.Cmp(reward.MinimumStake))
+	assert.Equal(t, config.Governance.Reward.Ratio, reward.Ratio)
+	assert.Equal(t, config.Governance.Reward.UseGiniCoeff, reward.UseGiniCoeff)
+	assert.Equal(t, config.Governance.Reward.DeferredTxFee, reward.DeferredTxFee)
+	assert.Equal(t, config.Governance.Reward.StakingUpdateInterval, reward.StakingUpdateInterval)
+	assert.Equal(t, config.Governance.Reward.ProposerUpdateInterval, reward.ProposerUpdateInterval)
+
+	assert.Equal(t, *config.Istanbul, *gov.ToIstanbulConfig())
+
+	// missing items are filled with default values
+	gov.currentSet.Clear()
+	assert.Equal(t, params.DefaultGovernanceMode, gov.ToGenesisConfig().GovernanceMode)
+	assert.Equal(t, params.DefaultRatio, gov.ToRewardConfig().Ratio)
+	assert.Equal(t, *GetDefaultIstanbulConfig(), *gov.ToIstanbulConfig())
+}
+
+func TestGovernance_UnmarshalJSON_Migration(t *testing.T) {
+	// A governance state written before the version field was added
+	v0 := []byte(`{"blockNumber":1234,"chainConfig":{"chainId":1,"unitPrice":25000000000},"voteMap":null,` +
+		`"nodeAddress":"0x0000000000000000000000000000000000000001","governanceVotes":null,"governanceTally":null,` +
+		`"currentSet":{"governance.unitprice":25000000000,"istanbul.epoch":30000},"changeSet":null}`)
+
+	gov := getGovernance()
+	if err := gov.UnmarshalJSON(v0); err != nil {
+		t.Fatalf("Failed to load a governance state of version 0: %v", err)
+	}
+	assert.Equal(t, uint64(1234), gov.lastGovernanceStateBlock)
+	assert.Equal(t, common.HexToAddress("0x1"), gov.nodeAddress)
+	assert.Equal(t, uint64(25000000000), gov.GetGovernanceValue(params.UnitPrice))
+	assert.Equal(t, uint64(30000), gov.GetGovernanceValue(params.Epoch))
+	assert.Equal(t, 0, len(gov.changeSet.Items()))
+
+	// Null fields are migrated to empty ones, so votes can be added
+	assert.NotNil(t, gov.voteMap)
+	assert.True(t, gov.AddVote("governance.unitprice", uint64(50000000000)))
+
+	// A migrated state is written with the current version
+	b, err := gov.toJSON(1235)
+	assert.NoError(t, err)
+	var j governanceJSON
+	assert.NoError(t, json.Unmarshal(b, &j))
+	assert.Equal(t, governanceStateVersion, j.Version)
+
+	// A state of an unknown version can't be loaded
+	unknown := []byte(`{"version":100,"blockNumber":1234}`)
+	assert.Equal(t, ErrUnknownStateVersion, getGovernance().UnmarshalJSON(unknown))
+}
+
+func TestGovernance_VerifyGovernanceMismatch(t *testing.T) {
+	gov := getGovernance()
+	items, _ := json.Marshal(map[string]interface{}{
+		"governance.unitprice": uint64(50000000000),
+		"reward.kiraddress":    "0x0000000000000000000000000000000000000abc",
+	})
+	received, _ := rlp.EncodeToBytes(items)
+
+	// The local change set differs from the one in the header
+	gov.changeSet.SetValue(params.UnitPrice, uint64(25000000000))
+	gov.changeSet.SetValue(params.KIRAddress, common.HexToAddress("0xabc"))
+	assert.Equal(t, ErrVoteValueMismatch, gov.VerifyGovernance(received))
+
+	// A header under verification never changes the local change set
+	assert.Equal(t, map[string]interface{}{
+		"governance.unitprice": uint64(25000000000),
+		"reward.kiraddress":    common.HexToAddress("0xabc"),
+	}, gov.changeSet.Items())
+	assert.Equal(t, ErrVoteValueMismatch, gov.VerifyGovernance(received))
+}
+
+func TestGovernance_VerifyGovernanceMixedCaseAddress(t *testing.T) {
+	gov := getGovernance()
+	node := common.HexToAddress("0x52d41ca72af615a1ac3301b0a93efa222ecc7541")
+
+	// Checksum-cased, upper-cased and lower-cased hex strings are all the same address
+	for _, hex := range []string{
+		node.Hex(),
+		"0x52D41CA72AF615A1AC3301B0A93EFA222ECC7541",
+		"0x52d41ca72af615a1ac3301b0a93efa222ecc7541",
+		" 52D41ca72af615a1ac3301b0a93efa222ecc7541 ",
+	} {
+		assert.True(t, equalGovernanceValue("governance.governingnode", hex, node), hex)
+		assert.True(t, equalGovernanceValue("governance.governingnode", node.Hex(), hex), hex)
+
+		items, _ := json.Marshal(map[string]interface{}{"governance.governingnode": hex})
+		received, _ := rlp.EncodeToBytes(items)
+		gov.changeSet.SetValue(params.GoverningNode, node)
+		assert.NoError(t, gov.VerifyGovernance(received), hex)
+	}
+
+	assert.False(t, equalGovernanceValue("governance.governingnode", "0xabc", node))
+	assert.False(t, equalGovernanceValue("governance.governingnode", nil, node))
+	assert.True(t, equalGovernanceValue("governance.governingnode", nil, nil))
+	assert.False(t, equalGovernanceValue("governance.unitprice", []interface{}{}, []interface{}{}))
+}
+
+func TestGovernance_LargeUint64Precision(t *testing.T) {
+	// 2^53 + 1 can't be represented as a float64
+	price := uint64(1)<<53 + 1
+
+	gov := getGovernance()
+	epoch := gov.ChainConfig.Istanbul.Epoch
+	items, _ := json.Marshal(map[string]interface{}{"governance.unitprice": price})
+	received, _ := rlp.EncodeToBytes(items)
+
+	// VerifyGovernance compares the received change exactly
+	gov.changeSet.SetValue(params.UnitPrice, price)
+	assert.NoError(t, gov.VerifyGovernance(received))
+	gov.changeSet.SetValue(params.UnitPrice, price-1)
+	assert.Equal(t, ErrVoteValueMismatch, gov.VerifyGovernance(received))
+	gov.changeSet.Clear()
+
+	// UpdateGovernance stores the change exactly
+	gov.UpdateGovernance(epoch, received)
+	stored, err := gov.db.ReadGovernance(epoch)
+	assert.NoError(t, err)
+	assert.Equal(t, price, adjustDecodedSet(stored)["governance.unitprice"])
+
+	// Governance state keeps the value exactly
+	gov.currentSet.SetValue(params.UnitPrice, price)
+	assert.True(t, gov.AddVote("governance.unitprice", price))
+	assert.NoError(t, gov.WriteGovernanceState(100, true))
+
+	loaded := getGovernance()
+	b, err := gov.db.ReadGovernanceState()
+	assert.NoError(t, err)
+	assert.NoError(t, loaded.db.WriteGovernanceState(b))
+	loaded.ReadGovernanceState()
+	assert.Equal(t, price, loaded.currentSet.GetUint64(params.UnitPrice, 0))
+	assert.Equal(t, price, loaded.voteMap["governance.unitprice"].Value)
+}
+
+func TestGovernance_CurrentUnitPrice(t *testing.T) {
+	price := uint64(33000000000)
+
+	// A governance without a database doesn't have the applied unit price
+	gov := NewGovernance(getTestConfig(), nil)
+	_, ok := gov.CurrentUnitPrice()
+	assert.False(t, ok)
+
+	// The unit price applied before a restart is available right after construction
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	writer := NewGovernance(getTestConfig(), dbm)
+	writer.currentSet.SetValue(params.UnitPrice, price)
+	assert.NoError(t, writer.WriteGovernanceState(100, true))
+
+	gov = NewGovernance(getTestConfig(), dbm)
+	unitPrice, ok := gov.CurrentUnitPrice()
+	assert.True(t, ok)
+	assert.Equal(t, price, unitPrice)
+	assert.Equal(t, price, gov.ChainConfig.UnitPrice)
+}
+
+func TestGovernance_SelfTest(t *testing.T) {
+	// A healthy governance
+	assert.NoError(t, getGovernance().SelfTest())
+
+	testCases := []struct {
+		name    string
+		breakFn func(gov *Governance)
+	}{
+		{"empty cache", func(gov *Governance) { gov.idxCache = nil }},
+		{"uncached items", func(gov *Governance) { gov.itemCache.Purge() }},
+		{"empty set", func(gov *Governance) { gov.currentSet = NewGovernanceSet() }},
+		{"missing key", func(gov *Governance) {
+			items := gov.currentSet.Items()
+			delete(items, "governance.unitprice")
+			gov.currentSet = NewGovernanceSet()
+			gov.currentSet.Import(items)
+		}},
+		{"zero epoch", func(gov *Governance) { gov.currentSet.SetValue(params.Epoch, uint64(0)) }},
+		{"zero config epoch", func(gov *Governance) { gov.ChainConfig.Istanbul.Epoch = 0 }},
+	}
+	for _, tc := range testCases {
+		gov := getGovernance()
+		tc.breakFn(gov)
+		assert.Error(t, gov.SelfTest(), tc.name)
+	}
+
+	// A governance without a database has never loaded governance items
+	assert.Error(t, NewGovernance(getTestConfig(), nil).SelfTest())
+}
+
+func TestGovernance_StateCompression(t *testing.T) {
+	writer := getGovernance()
+	assert.True(t, writer.AddVote("istanbul.committeesize", uint64(19)))
+	legacy, err := writer.toJSON(100)
+	assert.NoError(t, err)
+
+	load := func(b []byte) *Governance {
+		gov := getGovernance()
+		assert.NoError(t, gov.db.WriteGovernanceState(b))
+		gov.ReadGovernanceState()
+		return gov
+	}
+
+	// Compressed state
+	writer.SetStateCompression(true)
+	assert.NoError(t, writer.WriteGovernanceState(100, true))
+	compressed, err := writer.db.ReadGovernanceState()
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(compressed, compressedStateMagic))
+
+	decompressed, err := decompressGovernanceState(compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, legacy, decompressed)
+
+	for _, b := range [][]byte{compressed, legacy} {
+		gov := load(b)
+		assert.Equal(t, uint64(100), gov.lastGovernanceStateBlock)
+		assert.Equal(t, uint64(19), gov.voteMap["istanbul.committeesize"].Value)
+	}
+
+	// Uncompressed state is written as before
+	writer.SetStateCompression(false)
+	assert.NoError(t, writer.WriteGovernanceState(101, true))
+	b, err := writer.db.ReadGovernanceState()
+	assert.NoError(t, err)
+	assert.Equal(t, byte('{'), b[0])
+
+	// Broken compressed state isn't loaded
+	_, err = decompressGovernanceState(append(append([]byte{}, compressedStateMagic...), 1, 2, 3))
+	assert.Error(t, err)
+	gov := load(compressed[:len(compressed)-8])
+	assert.Equal(t, uint64(0), gov.lastGovernanceStateBlock)
+}
+
+func TestGovernance_ReadGovernanceStateRecovery(t *testing.T) {
+	newDB := func() database.DBManager {
+		dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+		writer := NewGovernance(getTestConfig(), dbm)
+		epoch := writer.ChainConfig.Istanbul.Epoch
+
+		// Items written at the first epoch are applied after the second epoch
+		delta := NewGovernanceSet()
+		delta.SetValue(params.UnitPrice, uint64(50000000000))
+		delta.SetValue(params.CommitteeSize, uint64(19))
+		assert.NoError(t, writer.WriteGovernance(epoch, writer.currentSet, delta))
+		assert.NoError(t, writer.WriteGovernance(2*epoch, writer.currentSet, NewGovernanceSet()))
+		return dbm
+	}
+
+	corrupted := map[string][]byte{
+		"truncated":            []byte(`{"chainConfig":{"unitPrice":1`),
+		"null chain config":    []byte(`{"chainConfig":null,"blockNumber":100}`),
+		"broken compression":   append(append([]byte{}, compressedStateMagic...), 1, 2, 3),
+		"unknown version":      []byte(`{"version":100}`),
+		"non-object json":      []byte(`[1, 2, 3]`),
+		"mistyped current set": []byte(`{"chainConfig":{"unitPrice":1},"currentSet":[]}`),
+	}
+	for name, b := range corrupted {
+		dbm := newDB()
+		assert.NoError(t, dbm.WriteGovernanceState(b))
+
+		// Governance is reconstructed from the governance items instead of being half-initialized
+		gov := NewGovernance(getTestConfig(), dbm)
+		assert.Equal(t, uint64(50000000000), gov.ChainConfig.UnitPrice, name)
+		assert.Equal(t, uint64(19), gov.ChainConfig.Istanbul.SubGroupSize, name)
+		assert.NotNil(t, gov.ChainConfig.Governance.Reward, name)
+		assert.Equal(t, uint64(19), gov.currentSet.GetUint64(params.CommitteeSize, 0), name)
+		assert.Equal(t, uint64(0), gov.lastGovernanceStateBlock, name)
+		assert.Empty(t, gov.voteMap, name)
+	}
+
+	gov := getGovernance()
+	assert.Equal(t, ErrIncompleteGovernanceState, gov.UnmarshalJSON([]byte(`{"blockNumber":100}`)))
+	assert.NotNil(t, gov.ChainConfig)
+	assert.Equal(t, uint64(0), gov.lastGovernanceStateBlock)
+}
+
+// stateCountingDBManager counts how many times governance state is written at each block
+type stateCountingDBManager struct {
+	database.DBManager
+	mu     sync.Mutex
+	writes map[uint64]int
+}
+
+func (dbm *stateCountingDBManager) WriteGovernanceState(b []byte) error {
+	var j governanceJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	dbm.mu.Lock()
+	dbm.writes[j.BlockNumber]++
+	dbm.mu.Unlock()
+	return dbm.DBManager.WriteGovernanceState(b)
+}
+
+func TestGovernance_RemoveVote_Concurrent(t *testing.T) {
+	gov := getGovernance()
+	dbm := &stateCountingDBManager{DBManager: gov.db, writes: make(map[uint64]int)}
+	gov.db = dbm
+
+	keys := []string{"governance.unitprice", "istanbul.epoch", "istanbul.committeesize", "governance.blockgaslimit"}
+	for i := uint64(1); i <= 10; i++ {
+		var wg sync.WaitGroup
+		for _, key := range keys {
+			wg.Add(1)
+			go func(key string) {
+				defer wg.Done()
+				gov.AddVote(key, uint64(10000))
+				gov.RemoveVote(key, uint64(10000), i)
+			}(key)
+		}
+		// A checkpoint can be written concurrently
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gov.WriteGovernanceStateIfNewer(i, true)
+		}()
+		wg.Wait()
+	}
+
+	for i := uint64(1); i <= 10; i++ {
+		// At most one state by RemoveVote and one checkpoint are written per block
+		assert.True(t, dbm.writes[i] >= 1 && dbm.writes[i] <= 2, "block: %v, writes: %v", i, dbm.writes[i])
+	}
+
+	// Nothing is written at a block before the last checkpoint
+	before := dbm.writes[5]
+	written, err := gov.WriteGovernanceStateIfNewer(5, true)
+	assert.NoError(t, err)
+	assert.False(t, written)
+	gov.RemoveVote("governance.unitprice", uint64(10000), 5)
+	assert.Equal(t, before, dbm.writes[5])
+}
+
+func TestNewGovernanceReadOnly(t *testing.T) {
+	// Nothing is written on an empty database
+	{
+		dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+		gov, err := NewGovernanceReadOnly(getTestConfig(), dbm)
+		assert.Nil(t, gov)
+		assert.Equal(t, ErrNotInitialized, err)
+
+		_, err = dbm.ReadRecentGovernanceIdx(0)
+		assert.Error(t, err)
+	}
+
+	// Governance on a populated database is read
+	{
+		dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+		config := getTestConfig()
+		epoch := config.Istanbul.Epoch
+		writer := NewGovernance(config, dbm)
+		delta := NewGovernanceSet()
+		delta.SetValue(params.UnitPrice, uint64(50000000000))
+		if err := writer.WriteGovernance(epoch, writer.currentSet, delta); err != nil {
+			t.Fatalf("Failed to write governance: %v", err)
+		}
+		writer.AddVote("istanbul.committeesize", uint64(7))
+		writer.WriteGovernanceState(epoch+1, true)
+		indices, _ := dbm.ReadRecentGovernanceIdx(0)
+
+		gov, err := NewGovernanceReadOnly(getTestConfig(), dbm)
+		if err != nil {
+			t.Fatalf("Failed to read governance: %v", err)
+		}
+
+		// It reads the same governance with the one made by NewGovernance
+		expected := NewGovernance(getTestConfig(), dbm)
+		assert.Equal(t, expected.currentSet.Items(), gov.currentSet.Items())
+		assert.Equal(t, expected.idxCache, gov.idxCache)
+		assert.Equal(t, expected.actualGovernanceBlock, gov.actualGovernanceBlock)
+		assert.Equal(t, expected.voteMap, gov.voteMap)
+		assert.Equal(t, epoch+1, gov.lastGovernanceStateBlock)
+		assert.Equal(t, 1, len(gov.voteMap))
+
+		// Governance changed at the epoch is read
+		_, items, err := gov.ReadGovernance(2*epoch + 1)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(50000000000), items["governance.unitprice"])
+
+		// Writing is not allowed
+		assert.Equal(t, ErrReadOnly, gov.WriteGovernance(2*epoch, gov.currentSet, delta))
+		assert.Equal(t, ErrReadOnly, gov.WriteGovernanceState(2*epoch, true))
+		after, _ := dbm.ReadRecentGovernanceIdx(0)
+		assert.Equal(t, indices, after)
+	}
+
+	// A compressed governance state is read
+	{
+		dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+		writer := NewGovernance(getTestConfig(), dbm)
+		writer.SetStateCompression(true)
+		writer.AddVote("istanbul.committeesize", uint64(7))
+		assert.NoError(t, writer.WriteGovernanceState(1, true))
+
+		gov, err := NewGovernanceReadOnly(getTestConfig(), dbm)
+		if err != nil {
+			t.Fatalf("Failed to read governance: %v", err)
+		}
+		assert.Equal(t, writer.voteMap, gov.voteMap)
+		assert.Equal(t, uint64(1), gov.lastGovernanceStateBlock)
+	}
+
+	_, err := NewGovernanceReadOnly(getTestConfig(), nil)
+	assert.Equal(t, ErrNotInitialized, err)
+}
+
+func TestNewGovernanceInMemory(t *testing.T) {
+	gov := NewGovernanceInMemory(getTestConfig())
+	epoch := gov.ChainConfig.Istanbul.Epoch
+
+	// The genesis governance is written
+	num, items, err := gov.ReadGovernance(1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), num)
+	assert.Equal(t, gov.ChainConfig.UnitPrice, items["governance.unitprice"])
+
+	// Governance items are written and read
+	delta := NewGovernanceSet()
+	delta.SetValue(params.UnitPrice, uint64(51000000000))
+	assert.NoError(t, gov.WriteGovernance(epoch, gov.currentSet, delta))
+	num, items, err = gov.ReadGovernance(2*epoch + 1)
+	assert.NoError(t, err)
+	assert.Equal(t, epoch, num)
+	assert.Equal(t, uint64(51000000000), items["governance.unitprice"])
+
+	gov.UpdateCurrentGovernance(2*epoch + 1)
+	assert.Equal(t, uint64(51000000000), gov.GetGovernanceValue(params.UnitPrice))
+
+	// Governance state is written and read
+	assert.True(t, gov.AddVote("istanbul.committeesize", uint64(7)))
+	assert.NoError(t, gov.WriteGovernanceState(2*epoch+1, true))
+	gov.voteMap = make(map[string]VoteStatus)
+	gov.ReadGovernanceState()
+	assert.Equal(t, uint64(7), gov.voteMap["istanbul.committeesize"].Value)
+	assert.Equal(t, 2*epoch+1, gov.lastGovernanceStateBlock)
+}
+
+func TestNewGovernanceWithCacheConfig(t *testing.T) {
+	// Cache sizes should be positive
+	invalids := []CacheConfig{
+		{ItemCacheSize: 0, IdxCacheLimit: 10},
+		{ItemCacheSize: 3, IdxCacheLimit: 0},
+		{ItemCacheSize: -1, IdxCacheLimit: -1},
+	}
+	for _, c := range invalids {
+		gov, err := NewGovernanceWithCacheConfig(getTestConfig(), nil, c)
+		assert.Nil(t, gov)
+		assert.Equal(t, ErrInvalidCacheConfig, err)
+	}
+
+	for _, limit := range []int{1, 3, 10} {
+		dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+		gov, err := NewGovernanceWithCacheConfig(getTestConfig(), dbm, CacheConfig{ItemCacheSize: 3, IdxCacheLimit: limit})
+		if err != nil {
+			t.Fatalf("Failed to make governance: %v", err)
+		}
+		epoch := gov.ChainConfig.Istanbul.Epoch
+
+		// Genesis governance is at block 0, and 5 more changes are written
+		for i := uint64(1); i <= 5; i++ {
+			delta := NewGovernanceSet()
+			delta.SetValue(params.UnitPrice, i)
+			if err := gov.WriteGovernance(i*epoch, gov.currentSet, delta); err != nil {
+				t.Fatalf("Failed to write governance: %v", err)
+			}
+		}
+
+		expected := []uint64{0, epoch, 2 * epoch, 3 * epoch, 4 * epoch, 5 * epoch}
+		if limit < len(expected) {
+			expected = expected[len(expected)-limit:]
+		}
+		assert.Equal(t, expected, gov.idxCache, "limit: %d", limit)
+	}
+}
+
+func TestNewGovernanceCache_OnEvicted(t *testing.T) {
+	// The cache size isn't scaled by the memory size of the test machine
+	defer func(scale, level, mem int) {
+		common.CacheScale, common.ScaleByCacheUsageLevel, common.TotalPhysicalMemGB = scale, level, mem
+	}(common.CacheScale, common.ScaleByCacheUsageLevel, common.TotalPhysicalMemGB)
+	common.CacheScale, common.ScaleByCacheUsageLevel, common.TotalPhysicalMemGB = 100, 100, 16
+
+	var evicted []uint64
+	cache := newGovernanceCache(3, func(num uint64) { evicted = append(evicted, num) })
+
+	for i := uint64(0); i < 3; i++ {
+		cache.Add(getGovernanceCacheKey(i*100), map[string]interface{}{})
+	}
+	assert.Empty(t, evicted)
+
+	// The least recently used entries are evicted beyond the limit
+	cache.Get(getGovernanceCacheKey(0))
+	cache.Add(getGovernanceCacheKey(300), map[string]interface{}{})
+	cache.Add(getGovernanceCacheKey(400), map[string]interface{}{})
+	assert.Equal(t, []uint64{100, 200}, evicted)
+	assert.True(t, cache.Contains(getGovernanceCacheKey(0)))
+}
+
+func TestGovernanceVote_JSONRoundTrip(t *testing.T) {
+	validator := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	testCases := []struct {
+		vote GovernanceVote
+		tag  string
+	}{
+		{GovernanceVote{validator, "governance.governingnode", common.HexToAddress("0x0000000000000000000000000000000000000001"), 0, 0}, voteTypeAddress},
+		{GovernanceVote{validator, "governance.unitprice", uint64(25000000000), 101, 0}, voteTypeUint64},
+		{GovernanceVote{validator, "governance.blockgaslimit", uint64(math.MaxUint64), 0, 0}, voteTypeUint64},
+		{GovernanceVote{validator, "reward.ratio", "30/40/30", 103, 40}, voteTypeString},
+		{GovernanceVote{validator, "reward.useginicoeff", true, 0, 0}, voteTypeBool},
+		{GovernanceVote{validator, "reward.deferredtxfee", false, 105, 0}, voteTypeBool},
+		{GovernanceVote{validator, "istanbul.epoch", []byte{0x4e, 0x20}, 0, 0}, voteTypeBytes},
+		{GovernanceVote{validator, "unknown.key", nil, 107, 0}, ""},
+	}
+
+	for _, tc := range testCases {
+		b, err := json.Marshal(tc.vote)
+		assert.NoError(t, err)
+
+		var tagged map[string]interface{}
+		assert.NoError(t, json.Unmarshal(b, &tagged))
+		if tc.tag == "" {
+			assert.NotContains(t, tagged, "type")
+		} else {
+			assert.Equal(t, tc.tag, tagged["type"])
+		}
+
+		var decoded GovernanceVote
+		assert.NoError(t, json.Unmarshal(b, &decoded))
+		assert.Equal(t, tc.vote, decoded, "key: %s", tc.vote.Key)
+	}
+
+	// A vote before and after parsing is decoded into the same state respectively
+	gov := getGovernance()
+	raw := &GovernanceVote{Key: "istanbul.epoch", Value: uint64(20000)}
+	encoded, _ := rlp.EncodeToBytes(raw)
+	before := new(GovernanceVote)
+	rlp.DecodeBytes(encoded, before)
+
+	b, err := json.Marshal(before)
+	assert.NoError(t, err)
+	decoded := new(GovernanceVote)
+	assert.NoError(t, json.Unmarshal(b, decoded))
+	assert.Equal(t, before, decoded)
+
+	after, err := gov.ParseVoteValue(decoded)
+	assert.NoError(t, err)
+	b, err = json.Marshal(after)
+	assert.NoError(t, err)
+	decoded = new(GovernanceVote)
+	assert.NoError(t, json.Unmarshal(b, decoded))
+	assert.Equal(t, uint64(20000), decoded.Value)
+}
+
+func TestGovernanceVote_JSONCompatibility(t *testing.T) {
+	// A vote written without a type tag is decoded as before
+	var vote GovernanceVote
+	assert.NoError(t, json.Unmarshal([]byte(`{"validator":"0x1234567890123456789012345678901234567890","key":"istanbul.epoch","value":30000}`), &vote))
+	assert.Equal(t, "istanbul.epoch", vote.Key)
+	assert.Equal(t, float64(30000), vote.Value)
+
+	// An unknown type tag is rejected
+	assert.Equal(t, ErrUnknownVoteType, json.Unmarshal([]byte(`{"key":"istanbul.epoch","type":"float","value":1}`), &vote))
+
+	// A vote loaded from an older state is written again with the type of its key
+	var legacy GovernanceVote
+	assert.NoError(t, json.Unmarshal([]byte(`{"key":"governance.unitprice","value":25000000000}`), &legacy))
+	b, err := json.Marshal(legacy)
+	assert.NoError(t, err)
+	var decoded GovernanceVote
+	assert.NoError(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, uint64(25000000000), decoded.Value)
+
+	// A value which can't be normalized is written without a type tag
+	b, err = json.Marshal(GovernanceVote{Key: "governance.unitprice", Value: []interface{}{float64(1)}})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), `"type"`)
+	assert.NoError(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, []interface{}{float64(1)}, decoded.Value)
+}
+
+// readCountingDBManager counts how many times governance items are read from the database
+type readCountingDBManager struct {
+	database.DBManager
+	reads int
+}
+
+func (dbm *readCountingDBManager) ReadGovernanceAtNumber(num uint64, epoch uint64) (uint64, map[string]interface{}, error) {
+	dbm.reads++
+	return dbm.DBManager.ReadGovernanceAtNumber(num, epoch)
+}
+
+func TestGovernance_PreloadAround(t *testing.T) {
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	writer := NewGovernance(getTestConfig(), dbm)
+	epoch := writer.ChainConfig.Istanbul.Epoch
+
+	// Genesis governance is at block 0, and unitprice is changed to i at every epoch
+	for i := uint64(1); i <= 9; i++ {
+		delta := NewGovernanceSet()
+		delta.SetValue(params.UnitPrice, i)
+		if err := writer.WriteGovernance(i*epoch, writer.currentSet, delta); err != nil {
+			t.Fatalf("Failed to write governance: %v", err)
+		}
+	}
+
+	gov, err := NewGovernanceWithCacheConfig(getTestConfig(), dbm, CacheConfig{ItemCacheSize: 4, IdxCacheLimit: 4})
+	if err != nil {
+		t.Fatalf("Failed to make governance: %v", err)
+	}
+	counter := &readCountingDBManager{DBManager: dbm}
+	gov.db = counter
+
+	// Governance of block 3*epoch is used for the block 4*epoch+1, which is not cached yet
+	_, ok := gov.getGovernanceCache(3 * epoch)
+	assert.False(t, ok)
+	num, data, err := gov.ReadGovernance(4*epoch + 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3*epoch, num)
+	assert.Equal(t, uint64(3), data["governance.unitprice"])
+	assert.Equal(t, 1, counter.reads)
+
+	assert.NoError(t, gov.PreloadAround(4*epoch+1, 1))
+	for _, idx := range []uint64{2 * epoch, 3 * epoch, 4 * epoch} {
+		_, ok := gov.getGovernanceCache(idx)
+		assert.True(t, ok, "idx: %d", idx)
+	}
+
+	// Reads in the preloaded range hit the cache
+	for i := uint64(2); i <= 4; i++ {
+		num, data, err := gov.ReadGovernance((i+1)*epoch + 1)
+		assert.NoError(t, err)
+		assert.Equal(t, i*epoch, num)
+		assert.Equal(t, i, data["governance.unitprice"])
+	}
+	assert.Equal(t, 1, counter.reads)
+
+	// A read out of the range goes to the database
+	num, data, err = gov.ReadGovernance(6*epoch + 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 5*epoch, num)
+	assert.Equal(t, uint64(5), data["governance.unitprice"])
+	assert.Equal(t, 2, counter.reads)
+
+	assert.Equal(t, ErrNegativeRadius, gov.PreloadAround(0, -1))
+	assert.Equal(t, ErrNotInitialized, NewGovernance(getTestConfig(), nil).PreloadAround(0, 1))
+}
+
+func TestGovernance_ReconcileGovernanceStateBlock(t *testing.T) {
+	testCases := []struct {
+		stateBlock uint64
+		head       uint64
+		mismatch   bool
+		expected   uint64
+	}{
+		{0, 0, false, 0},
+		{100, 100, false, 100},
+		{100, 100 + governanceStateCheckpointInterval, false, 100},
+		// behind the head: only warned
+		{100, 101 + governanceStateCheckpointInterval, true, 100},
+		// ahead of the head: clamped to the head
+		{5000, 100, true, 100},
+		{1, 0, true, 0},
+	}
+	for _, tc := range testCases {
+		gov := getGovernance()
+		gov.lastGovernanceStateBlock = tc.stateBlock
+		gov.lastVoteStateBlock = tc.stateBlock
+
+		assert.Equal(t, tc.mismatch, gov.reconcileGovernanceStateBlock(tc.head), "stateBlock: %d, head: %d", tc.stateBlock, tc.head)
+		assert.Equal(t, tc.expected, gov.lastGovernanceStateBlock)
+		assert.Equal(t, tc.expected, gov.lastVoteStateBlock)
+	}
+}
+
+func TestGovernance_SetBlockchain_StateAheadOfHead(t *testing.T) {
+	config := getTestConfig()
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	bc := newTestBlockChain(t, dbm, config, 10)
+	defer bc.Stop()
+
+	// Governance state restored from a backup of a longer chain
+	gov := NewGovernance(config, dbm)
+	assert.NoError(t, gov.WriteGovernanceState(1000, true))
+	gov = NewGovernance(config, dbm)
+	assert.Equal(t, uint64(1000), gov.lastGovernanceStateBlock)
+	assert.False(t, gov.CanWriteGovernanceState(11))
+
+	gov.SetBlockchain(bc)
+	assert.Equal(t, uint64(10), gov.lastGovernanceStateBlock)
+	assert.True(t, gov.CanWriteGovernanceState(11))
+}
+
+func TestGetGovernanceItemsFromChainConfig_Partial(t *testing.T) {
+	config := &params.ChainConfig{
+		UnitPrice: 25000000000,
+		Governance: &params.GovernanceConfig{
+			GovernanceMode: "single",
+			GoverningNode:  common.HexToAddress("0x1234567890123456789012345678901234567890"),
+		},
+	}
+
+	// Reward and Istanbul are nil
+	items := getGovernanceItemsFromChainConfig(config)
+	assert.Equal(t, "single", items.GetString(params.GovernanceMode, ""))
+	assert.Equal(t, uint64(25000000000), items.GetUint64(params.UnitPrice, 0))
+	for _, key := range []int{params.MintingAmount, params.Ratio, params.UseGiniCoeff, params.DeferredTxFee, params.MinimumStake,
+		params.StakeUpdateInterval, params.ProposerRefreshInterval, params.Epoch, params.Policy, params.CommitteeSize} {
+		_, ok := items.GetValue(key)
+		assert.False(t, ok, "key: %s", GovernanceKeyMapReverse[key])
+	}
+
+	// Reward without big numbers
+	config.Governance.Reward = &params.RewardConfig{Ratio: "34/54/12", StakingUpdateInterval: 86400}
+	items = getGovernanceItemsFromChainConfig(config)
+	assert.Equal(t, "34/54/12", items.GetString(params.Ratio, ""))
+	assert.Equal(t, uint64(86400), items.GetUint64(params.StakeUpdateInterval, 0))
+	_, ok := items.GetValue(params.MintingAmount)
+	assert.False(t, ok)
+	_, ok = items.GetValue(params.MinimumStake)
+	assert.False(t, ok)
+
+	// Istanbul only
+	items = getGovernanceItemsFromChainConfig(&params.ChainConfig{Istanbul: &params.IstanbulConfig{Epoch: 30, SubGroupSize: 7}})
+	assert.Equal(t, uint64(30), items.GetUint64(params.Epoch, 0))
+	_, ok = items.GetValue(params.GovernanceMode)
+	assert.False(t, ok)
+}
+
+func TestMissingGovernanceItems(t *testing.T) {
+	// A complete config doesn't miss any item
+	assert.Empty(t, MissingGovernanceItems(getTestConfig()))
+
+	config := &params.ChainConfig{
+		UnitPrice: 25000000000,
+		Governance: &params.GovernanceConfig{
+			GovernanceMode: "single",
+			GoverningNode:  common.HexToAddress("0x1234567890123456789012345678901234567890"),
+			Reward:         &params.RewardConfig{Ratio: "34/54/12", StakingUpdateInterval: 86400},
+		},
+	}
+
+	// Big numbers of the reward and istanbul items are missing
+	assert.Equal(t, []string{
+		"istanbul.committeesize",
+		"istanbul.epoch",
+		"istanbul.policy",
+		"reward.minimumstake",
+		"reward.mintingamount",
+	}, MissingGovernanceItems(config))
+
+	// Istanbul items are not required for a clique network
+	config.Clique = &params.CliqueConfig{Period: 1, Epoch: 30000}
+	assert.Equal(t, []string{"reward.minimumstake", "reward.mintingamount"}, MissingGovernanceItems(config))
+
+	// All governance items are missing without a governance config
+	config = &params.ChainConfig{Istanbul: &params.IstanbulConfig{Epoch: 30, SubGroupSize: 7}}
+	missing := MissingGovernanceItems(config)
+	assert.Equal(t, len(requiredGovernanceItems), len(missing))
+	assert.Contains(t, missing, "governance.governancemode")
+	assert.NotContains(t, missing, "istanbul.epoch")
+
+	// A governance is still constructed with missing items
+	gov := NewGovernanceInMemory(config)
+	assert.Nil(t, gov.GetGovernanceValue(params.MinimumStake))
+}
+
+// flakyDBManager fails to read governance items for the given number of times
+type flakyDBManager struct {
+	database.DBManager
+	failures int
+	attempts int
+}
+
+func (dbm *flakyDBManager) ReadGovernanceAtNumber(num uint64, epoch uint64) (uint64, map[string]interface{}, error) {
+	dbm.attempts++
+	if dbm.attempts <= dbm.failures {
+		return 0, nil, errors.New("i/o timeout")
+	}
+	return dbm.DBManager.ReadGovernanceAtNumber(num, epoch)
+}
+
+func TestGovernance_ReadGovernance_Retry(t *testing.T) {
+	gov := getGovernance()
+	dbm := gov.db
+
+	// Retrying is disabled in tests by default
+	flaky := &flakyDBManager{DBManager: dbm, failures: 1}
+	gov.db = flaky
+	gov.idxCache = nil // not to hit the cache
+	_, _, err := gov.ReadGovernance(1)
+	assert.Error(t, err)
+	assert.Equal(t, 1, flaky.attempts)
+
+	// Succeeds on the second attempt
+	gov.SetDBReadRetry(3, time.Millisecond)
+	flaky = &flakyDBManager{DBManager: dbm, failures: 1}
+	gov.db = flaky
+	num, data, err := gov.ReadGovernance(1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), num)
+	assert.Equal(t, gov.ChainConfig.UnitPrice, data["governance.unitprice"])
+	assert.Equal(t, 2, flaky.attempts)
+
+	// Gives up after the retries
+	flaky = &flakyDBManager{DBManager: dbm, failures: 10}
+	gov.db = flaky
+	_, _, err = gov.ReadGovernance(1)
+	assert.Error(t, err)
+	assert.Equal(t, 4, flaky.attempts)
+
+	// Gives up before the total wait exceeds the limit
+	gov.SetDBReadRetry(10, maxDBReadWait/2+time.Millisecond)
+	flaky = &flakyDBManager{DBManager: dbm, failures: 10}
+	gov.db = flaky
+	_, _, err = gov.ReadGovernance(1)
+	assert.Error(t, err)
+	assert.Equal(t, 2, flaky.attempts)
+
+	// Not found is not retried
+	counter := &readCountingDBManager{DBManager: database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})}
+	gov.db = counter
+	_, _, err = gov.ReadGovernance(1)
+	assert.Error(t, err)
+	assert.Equal(t, 1, counter.reads)
+}
+
+func TestGovernance_OnInitialized(t *testing.T) {
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	writer := NewGovernance(getTestConfig(), dbm)
+	epoch := writer.ChainConfig.Istanbul.Epoch
+	delta := NewGovernanceSet()
+	delta.SetValue(params.UnitPrice, uint64(50000000000))
+	if err := writer.WriteGovernance(epoch, writer.currentSet, delta); err != nil {
+		t.Fatalf("Failed to write governance: %v", err)
+	}
+
+	gov := NewGovernance(getTestConfig(), dbm)
+	loaded := gov.currentSet.Items()
+
+	// Already initialized, so the callback is invoked immediately
+	count := 0
+	gov.OnInitialized(func(items map[string]interface{}) {
+		count++
+		assert.Equal(t, loaded, items)
+	})
+	assert.Equal(t, 1, count)
+
+	// Governance applied after boot doesn't invoke it again, and the loaded set is kept for late callbacks
+	delta = NewGovernanceSet()
+	delta.SetValue(params.UnitPrice, uint64(123))
+	if err := gov.WriteGovernance(2*epoch, gov.currentSet, delta); err != nil {
+		t.Fatalf("Failed to write governance: %v", err)
+	}
+	gov.UpdateCurrentGovernance(3 * epoch)
+	assert.Equal(t, 1, count)
+
+	assert.NotEqual(t, loaded, gov.currentSet.Items())
+	gov.OnInitialized(func(items map[string]interface{}) {
+		count++
+		assert.Equal(t, loaded, items)
+	})
+	assert.Equal(t, 2, count)
+
+	// Callbacks registered before loading are invoked once when it's loaded
+	pending := newGovernance(getTestConfig(), dbm)
+	fired := 0
+	pending.OnInitialized(func(items map[string]interface{}) { fired++ })
+	assert.Equal(t, 0, fired)
+	assert.NoError(t, pending.loadCache())
+	pending.markInitialized()
+	assert.Equal(t, 1, fired)
+}
+
+func TestGovernanceVotes_ByValidator(t *testing.T) {
+	a, b, c := common.HexToAddress("0xa"), common.HexToAddress("0xb"), common.HexToAddress("0xc")
+	votes := NewGovernanceVotes()
+	votes.Import([]GovernanceVote{
+		{Validator: a, Key: "governance.unitprice", Value: uint64(50000000000)},
+		{Validator: b, Key: "governance.unitprice", Value: uint64(75000000000)},
+		{Validator: a, Key: "istanbul.epoch", Value: uint64(30000)},
+		{Validator: b, Key: "reward.ratio", Value: "30/40/30"},
+		{Validator: a, Key: "reward.useginicoeff", Value: true},
+	})
+
+	assert.Equal(t, []GovernanceVote{
+		{Validator: a, Key: "governance.unitprice", Value: uint64(50000000000)},
+		{Validator: a, Key: "istanbul.epoch", Value: uint64(30000)},
+		{Validator: a, Key: "reward.useginicoeff", Value: true},
+	}, votes.ByValidator(a))
+	assert.Equal(t, []GovernanceVote{
+		{Validator: b, Key: "governance.unitprice", Value: uint64(75000000000)},
+		{Validator: b, Key: "reward.ratio", Value: "30/40/30"},
+	}, votes.ByValidator(b))
+	assert.Empty(t, votes.ByValidator(c))
+
+	// The returned votes are a copy
+	votes.ByValidator(a)[0].Value = uint64(1)
+	assert.Equal(t, uint64(50000000000), votes.ByValidator(a)[0].Value)
+}
+
+func TestGovernanceSet_UnknownKeysAndLimit(t *testing.T) {
+	gs := NewGovernanceSet()
+
+	// Unknown keys are not stored
+	assert.Equal(t, ErrUnknownKey, gs.SetValue(9999, uint64(1)))
+	assert.Equal(t, ErrUnknownKey, gs.SetValue(params.CliqueEpoch, uint64(1)))
+	gs.Merge(map[string]interface{}{
+		"governance.unitprice": uint64(25000000000),
+		"bogus.key":            uint64(1),
+	})
+	assert.Equal(t, map[string]interface{}{"governance.unitprice": uint64(25000000000)}, gs.Items())
+
+	gs.Import(map[string]interface{}{
+		"istanbul.epoch": uint64(30000),
+		"":               "empty",
+		"bogus.key":      uint64(1),
+	})
+	assert.Equal(t, map[string]interface{}{"istanbul.epoch": uint64(30000)}, gs.Items())
+
+	// The number of items is limited
+	defer func(size int) { maxGovernanceSetSize = size }(maxGovernanceSetSize)
+	maxGovernanceSetSize = 2
+
+	assert.NoError(t, gs.SetValue(params.UnitPrice, uint64(25000000000)))
+	assert.Equal(t, ErrGovernanceSetFull, gs.SetValue(params.CommitteeSize, uint64(7)))
+	// Existing items can still be changed
+	assert.NoError(t, gs.SetValue(params.Epoch, uint64(60000)))
+
+	gs.Merge(map[string]interface{}{
+		"istanbul.epoch":         uint64(90000),
+		"istanbul.committeesize": uint64(7),
+	})
+	assert.Equal(t, map[string]interface{}{
+		"istanbul.epoch":       uint64(90000),
+		"governance.unitprice": uint64(25000000000),
+	}, gs.Items())
+}
+
+func TestGovernance_ItemsFingerprint(t *testing.T) {
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	gov1 := NewGovernance(getTestConfig(), dbm)
+
+	// The same state loaded from the database has the same fingerprint though values are decoded from JSON
+	gov2 := NewGovernance(getTestConfig(), dbm)
+	b, err := json.Marshal(gov1.currentSet.Items())
+	assert.NoError(t, err)
+	decoded := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(b, &decoded))
+	gov2.currentSet.Import(decoded)
+	assert.NotEqual(t, gov1.currentSet.Items(), gov2.currentSet.Items())
+	assert.Equal(t, gov1.ItemsFingerprint(), gov2.ItemsFingerprint())
+
+	fingerprint := gov1.ItemsFingerprint()
+	assert.Equal(t, len(gov1.currentSet.Items()), len(fingerprint))
+	assert.Equal(t, strconv.FormatUint(gov1.ChainConfig.Istanbul.Epoch, 10), fingerprint["istanbul.epoch"])
+	assert.Equal(t, "false", fingerprint["reward.useginicoeff"])
+	assert.Equal(t, "0x0000000000000000000000000000000000000000", fingerprint["governance.governingnode"])
+
+	// A different state makes a different fingerprint
+	gov2.currentSet.SetValue(params.UnitPrice, uint64(1))
+	assert.NotEqual(t, gov1.ItemsFingerprint(), gov2.ItemsFingerprint())
+
+	assert.Equal(t, "30000", FormatGovernanceValue("istanbul.epoch", float64(30000)))
+	assert.Equal(t, "30000", FormatGovernanceValue("istanbul.epoch", json.Number("30000")))
+	assert.Equal(t, "0x00000000000000000000000000000000000000ab", FormatGovernanceValue("governance.governingnode", "0x00000000000000000000000000000000000000AB"))
+}
+
+func TestGovernance_TriggerChange_Idempotent(t *testing.T) {
+	gov := getGovernance()
+
+	triggered := make(map[string]int)
+	for _, key := range []int{params.UnitPrice, params.Epoch} {
+		item := GovernanceItems[key]
+		defer func(key int, item check) { GovernanceItems[key] = item }(key, item)
+
+		trigger := item.trigger
+		item.trigger = func(g *Governance, k string, v interface{}) bool {
+			triggered[k]++
+			return trigger(g, k, v)
+		}
+		GovernanceItems[key] = item
+	}
+
+	set := map[string]interface{}{
+		"governance.unitprice": uint64(50000000000),
+		"istanbul.epoch":       uint64(30000),
+	}
+	gov.triggerChange(set)
+	assert.Equal(t, map[string]int{"governance.unitprice": 1, "istanbul.epoch": 1}, triggered)
+	assert.Equal(t, uint64(50000000000), gov.ChainConfig.UnitPrice)
+
+	// The same set doesn't trigger anything
+	gov.triggerChange(set)
+	assert.Equal(t, map[string]int{"governance.unitprice": 1, "istanbul.epoch": 1}, triggered)
+
+	// Only the changed item is triggered
+	set["istanbul.epoch"] = uint64(60000)
+	gov.triggerChange(set)
+	assert.Equal(t, map[string]int{"governance.unitprice": 1, "istanbul.epoch": 2}, triggered)
+	assert.Equal(t, uint64(60000), gov.ChainConfig.Istanbul.Epoch)
+
+	// Applying the same governance block again, e.g., after actualGovernanceBlock is reset, doesn't trigger again
+	epoch := gov.ChainConfig.Istanbul.Epoch
+	delta := NewGovernanceSet()
+	delta.SetValue(params.UnitPrice, uint64(75000000000))
+	if err := gov.WriteGovernance(epoch, gov.currentSet, delta); err != nil {
+		t.Fatalf("Failed to write governance: %v", err)
+	}
+	gov.UpdateCurrentGovernance(2 * epoch)
+	assert.Equal(t, 2, triggered["governance.unitprice"])
+	gov.actualGovernanceBlock = 0
+	gov.UpdateCurrentGovernance(2 * epoch)
+	assert.Equal(t, 2, triggered["governance.unitprice"])
+}
+
+func TestGovernance_SubscribeIstanbulChange(t *testing.T) {
+	gov := getGovernance()
+	ch1, ch2 := gov.SubscribeIstanbulChange(), gov.SubscribeIstanbulChange()
+
+	// Only istanbul.* items are delivered to every subscriber
+	gov.triggerChange(map[string]interface{}{
+		"governance.unitprice":   uint64(51000000000),
+		"istanbul.epoch":         uint64(40000),
+		"istanbul.committeesize": uint64(17),
+	})
+	expected := map[string]interface{}{
+		"istanbul.epoch":         uint64(40000),
+		"istanbul.committeesize": uint64(17),
+	}
+	for _, ch := range []<-chan map[string]interface{}{ch1, ch2} {
+		select {
+		case changed := <-ch:
+			assert.Equal(t, expected, changed)
+		default:
+			t.Fatal("No istanbul change is delivered")
+		}
+	}
+
+	// Nothing is delivered if no istanbul item changed
+	gov.triggerChange(map[string]interface{}{"governance.unitprice": uint64(52000000000), "istanbul.epoch": uint64(40000)})
+	assert.Equal(t, 0, len(ch1))
+
+	// A subscriber falling behind doesn't block triggering changes
+	for i := uint64(1); i <= istanbulChangeBufferSize+1; i++ {
+		gov.triggerChange(map[string]interface{}{"istanbul.committeesize": i})
+	}
+	assert.Equal(t, istanbulChangeBufferSize, len(ch1))
+	assert.Equal(t, map[string]interface{}{"istanbul.committeesize": uint64(1)}, <-ch1)
+}
+
+func TestGovernance_OnBatchChange(t *testing.T) {
+	gov := getGovernance()
+
+	var events []string
+	for _, key := range []int{params.UnitPrice, params.Epoch, params.CommitteeSize} {
+		item := GovernanceItems[key]
+		defer func(key int, item check) { GovernanceItems[key] = item }(key, item)
+
+		trigger := item.trigger
+		item.trigger = func(g *Governance, k string, v interface{}) bool {
+			events = append(events, k)
+			return trigger(g, k, v)
+		}
+		GovernanceItems[key] = item
+	}
+
+	var batches []map[string]interface{}
+	gov.OnBatchChange(func(changes map[string]interface{}) {
+		events = append(events, "batch")
+		batches = append(batches, changes)
+	})
+
+	set := map[string]interface{}{
+		"governance.unitprice":   uint64(51000000000),
+		"istanbul.epoch":         uint64(40000),
+		"istanbul.committeesize": uint64(17),
+	}
+	gov.triggerChange(set)
+
+	// The batch callback is invoked once with the full change set after all per-key triggers
+	if assert.Equal(t, 1, len(batches)) {
+		assert.Equal(t, set, batches[0])
+	}
+	assert.Equal(t, 4, len(events))
+	assert.Equal(t, "batch", events[len(events)-1])
+
+	// Nothing is invoked if nothing changed
+	gov.triggerChange(set)
+	assert.Equal(t, 1, len(batches))
+
+	// Only the changed items are delivered
+	set["istanbul.epoch"] = uint64(50000)
+	gov.triggerChange(set)
+	if assert.Equal(t, 2, len(batches)) {
+		assert.Equal(t, map[string]interface{}{"istanbul.epoch": uint64(50000)}, batches[1])
+	}
+
+	// Modifying the delivered set doesn't affect the governance
+	batches[1]["istanbul.epoch"] = uint64(1)
+	gov.triggerChange(set)
+	assert.Equal(t, 2, len(batches))
+}
+
+func TestGovernance_MaxTxGas(t *testing.T) {
+	defer func(v uint64) { params.MaxTxGas = v }(params.MaxTxGas)
+	gov := getGovernance()
+	assert.Equal(t, params.DefaultMaxTxGas, gov.GetGovernanceValue(params.ConstMaxTxGas))
+
+	v := &GovernanceVote{Key: "param.maxtxgas", Value: uint64(100000000)}
+	b, _ := rlp.EncodeToBytes(v)
+	d := new(GovernanceVote)
+	rlp.DecodeBytes(b, d)
+	d, err := gov.ParseVoteValue(d)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100000000), d.Value)
+
+	gov.ReflectVotes(*d)
+	changed, ok := gov.changeSet.GetValue(params.ConstMaxTxGas)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(100000000), changed)
+
+	// The value is applied to params like param.txgashumanreadable
+	gov.triggerChange(map[string]interface{}{"param.maxtxgas": uint64(100000000)})
+	assert.Equal(t, uint64(100000000), params.MaxTxGas)
+
+	// Bounds
+	assert.False(t, gov.AddVote("param.maxtxgas", params.MinMaxTxGas-1))
+	assert.True(t, gov.AddVote("param.maxtxgas", params.MinMaxTxGas))
+	assert.True(t, gov.AddVote("param.maxtxgas", params.UpperGasLimit))
+	assert.False(t, gov.AddVote("param.maxtxgas", params.UpperGasLimit+1))
+}
+
+func TestGovernance_HumanReadableAddress(t *testing.T) {
+	defer func(v bool) { params.HumanReadableAddress = v }(params.HumanReadableAddress)
+	gov := getGovernance()
+	assert.Equal(t, params.DefaultHumanReadableAddress, gov.GetGovernanceValue(params.ConstHumanReadableAddress))
+
+	// Only 0 and 1 are accepted as a vote value
+	for _, tc := range []struct {
+		value    []byte
+		expected interface{}
+		err      error
+	}{
+		{[]byte{}, false, nil},
+		{[]byte{0}, false, nil},
+		{[]byte{1}, true, nil},
+		{[]byte{0, 1}, true, nil},
+		{[]byte{2}, nil, ErrValueTypeMismatch},
+		{[]byte{1, 0}, nil, ErrValueTypeMismatch},
+		{[]byte{0, 0, 0, 0, 0, 0, 0, 0, 1}, nil, ErrValueTypeMismatch},
+	} {
+		v := &GovernanceVote{Key: "param.humanreadableaddress", Value: tc.value}
+		b, _ := rlp.EncodeToBytes(v)
+		d := new(GovernanceVote)
+		rlp.DecodeBytes(b, d)
+		d, err := gov.ParseVoteValue(d)
+		assert.Equal(t, tc.err, err, "value: %v", tc.value)
+		if err == nil {
+			assert.Equal(t, tc.expected, d.Value, "value: %v", tc.value)
+		}
+	}
+
+	// A vote of true is encoded as 1
+	v := &GovernanceVote{Key: "param.humanreadableaddress", Value: true}
+	b, _ := rlp.EncodeToBytes(v)
+	d := new(GovernanceVote)
+	rlp.DecodeBytes(b, d)
+	d, err := gov.ParseVoteValue(d)
+	assert.NoError(t, err)
+	assert.Equal(t, true, d.Value)
+
+	gov.ReflectVotes(*d)
+	changed, ok := gov.changeSet.GetValue(params.ConstHumanReadableAddress)
+	assert.True(t, ok)
+	assert.Equal(t, true, changed)
+
+	// The value is applied to params
+	gov.triggerChange(map[string]interface{}{"param.humanreadableaddress": true})
+	assert.True(t, params.HumanReadableAddress)
+	gov.triggerChange(map[string]interface{}{"param.humanreadableaddress": false})
+	assert.False(t, params.HumanReadableAddress)
+}
+
+func TestGovernance_SetForbiddenKeysAllowed(t *testing.T) {
+	defer func(staking, proposer uint64) {
+		params.SetStakingUpdateInterval(staking)
+		params.SetProposerUpdateInterval(proposer)
+	}(params.StakingUpdateInterval(), params.ProposerUpdateInterval())
+
+	gov := getGovernance()
+	encode := func(key string, value interface{}) []byte {
+		b, _ := rlp.EncodeToBytes(&GovernanceVote{Key: key, Value: value})
+		return b
+	}
+
+	// Forbidden keys can't be voted by default
+	assert.False(t, gov.AddVote("reward.stakingupdateinterval", uint64(10)))
+	_, err := gov.DecodeAndValidateVote(encode("reward.proposerupdateinterval", uint64(20)))
+	assert.Equal(t, ErrInvalidVote, err)
+
+	gov.SetForbiddenKeysAllowed(true)
+	assert.True(t, gov.AddVote("reward.stakingupdateinterval", uint64(10)))
+	assert.True(t, gov.AddVote("reward.proposerupdateinterval", uint64(20)))
+	_, err = gov.DecodeAndValidateVote(encode("reward.proposerupdateinterval", uint64(20)))
+	assert.NoError(t, err)
+
+	// The params globals are updated when the changes are applied
+	gov.triggerChange(map[string]interface{}{
+		"reward.stakingupdateinterval":  uint64(10),
+		"reward.proposerupdateinterval": uint64(20),
+	})
+	assert.Equal(t, uint64(10), params.StakingUpdateInterval())
+	assert.Equal(t, uint64(20), params.ProposerUpdateInterval())
+	assert.Equal(t, uint64(10), gov.ChainConfig.Governance.Reward.StakingUpdateInterval)
+	assert.Equal(t, uint64(20), gov.ChainConfig.Governance.Reward.ProposerUpdateInterval)
+
+	gov.SetForbiddenKeysAllowed(false)
+	assert.False(t, gov.AddVote("reward.stakingupdateinterval", uint64(30)))
+}
+
+func TestGovernance_ResetToGenesis(t *testing.T) {
+	config := getTestConfig()
+	defer func(price, epoch uint64) { config.UnitPrice, config.Istanbul.Epoch = price, epoch }(config.UnitPrice, config.Istanbul.Epoch)
+	config.Istanbul.Epoch = 10
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	gov := NewGovernance(config, dbm)
+	gov.SetBlockchain(newTestBlockChain(t, dbm, config, 45))
+	genesis := gov.currentSet.Items()
+	genesisPrice := config.UnitPrice
+
+	// unitprice is changed at 10, 20 and 30
+	for i := uint64(1); i <= 3; i++ {
+		delta := NewGovernanceSet()
+		delta.SetValue(params.UnitPrice, i)
+		if err := gov.WriteGovernance(i*10, gov.currentSet, delta); err != nil {
+			t.Fatalf("Failed to write governance: %v", err)
+		}
+	}
+	gov.UpdateCurrentGovernance(40)
+	assert.Equal(t, uint64(3), gov.ChainConfig.UnitPrice)
+	gov.AddVote("istanbul.committeesize", uint64(7))
+	gov.GovernanceVotes.Import([]GovernanceVote{{Key: "governance.unitprice", Value: uint64(4)}})
+	gov.GovernanceTallies.Import([]GovernanceTallyItem{{Key: "governance.unitprice", Value: uint64(4), Votes: 1}})
+	gov.changeSet.SetValue(params.UnitPrice, uint64(4))
+
+	// Not allowed by default
+	assert.Equal(t, ErrResetNotAllowed, gov.ResetToGenesis())
+	assert.Equal(t, uint64(3), gov.ChainConfig.UnitPrice)
+
+	gov.SetResetToGenesisAllowed(true)
+	assert.NoError(t, gov.ResetToGenesis())
+
+	assert.Equal(t, genesis, gov.currentSet.Items())
+	assert.Equal(t, genesisPrice, gov.ChainConfig.UnitPrice)
+	assert.Empty(t, gov.voteMap)
+	assert.Empty(t, gov.GovernanceVotes.Copy())
+	assert.Empty(t, gov.GovernanceTallies.Copy())
+	assert.Equal(t, 0, gov.changeSet.Size())
+
+	// The genesis governance is written at the current epoch boundary
+	indices, err := dbm.ReadRecentGovernanceIdx(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{0, 10, 20, 30, 40}, indices)
+
+	// The past blocks keep their governance and the following blocks use the genesis governance
+	check := func(g *Governance) {
+		for _, tc := range []struct {
+			num, gNum uint64
+			price     uint64
+		}{
+			{5, 0, genesisPrice},
+			{25, 10, 1},
+			{35, 20, 2},
+			{45, 30, 3},
+			{55, 40, genesisPrice},
+			{1000, 40, genesisPrice},
+		} {
+			gNum, items, err := g.ReadGovernance(tc.num)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.gNum, gNum, "num: %d", tc.num)
+			assert.Equal(t, tc.price, items["governance.unitprice"], "num: %d", tc.num)
+		}
+	}
+	check(gov)
+
+	// The applied governance isn't changed again at the next epoch boundary
+	gov.UpdateCurrentGovernance(50)
+	assert.Equal(t, genesis, gov.currentSet.Items())
+
+	// A node booting from the database reads the same governance
+	rebooted := NewGovernance(config, dbm)
+	check(rebooted)
+	rebooted.UpdateCurrentGovernance(50)
+	assert.Equal(t, genesis, rebooted.currentSet.Items())
+
+	// A change committed at the current epoch boundary isn't overwritten
+	dbm = database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	gov = NewGovernance(config, dbm)
+	gov.SetBlockchain(newTestBlockChain(t, dbm, config, 45))
+	gov.SetResetToGenesisAllowed(true)
+	delta := NewGovernanceSet()
+	delta.SetValue(params.UnitPrice, uint64(4))
+	if err := gov.WriteGovernance(40, gov.currentSet, delta); err != nil {
+		t.Fatalf("Failed to write governance: %v", err)
+	}
+	assert.Equal(t, ErrResetBehindGovernance, gov.ResetToGenesis())
+	_, items, err := gov.ReadGovernance(55)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), items["governance.unitprice"])
+}
+
+func TestGovernance_ReadGovernanceTraced(t *testing.T) {
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	writer := NewGovernance(getTestConfig(), dbm)
+	epoch := writer.ChainConfig.Istanbul.Epoch
+	for i := uint64(1); i <= 5; i++ {
+		delta := NewGovernanceSet()
+		delta.SetValue(params.UnitPrice, i)
+		if err := writer.WriteGovernance(i*epoch, writer.currentSet, delta); err != nil {
+			t.Fatalf("Failed to write governance: %v", err)
+		}
+	}
+
+	// Only the last 2 governance blocks are cached
+	gov, err := NewGovernanceWithCacheConfig(getTestConfig(), dbm, CacheConfig{ItemCacheSize: 2, IdxCacheLimit: 2})
+	if err != nil {
+		t.Fatalf("Failed to make governance: %v", err)
+	}
+
+	// cache hit
+	num, data, trace, err := gov.ReadGovernanceTraced(5*epoch + 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 4*epoch, num)
+	assert.Equal(t, uint64(4), data["governance.unitprice"])
+	assert.Equal(t, ReadTrace{Num: 5*epoch + 1, InfoBlock: 4 * epoch, Source: ReadSourceCache, GovernanceBlock: 4 * epoch}, trace)
+
+	// db fallback
+	num, data, trace, err = gov.ReadGovernanceTraced(2*epoch + 1)
+	assert.NoError(t, err)
+	assert.Equal(t, epoch, num)
+	assert.Equal(t, uint64(1), data["governance.unitprice"])
+	assert.Equal(t, ReadTrace{Num: 2*epoch + 1, InfoBlock: epoch, Source: ReadSourceDB, GovernanceBlock: epoch}, trace)
+
+	// preloaded
+	assert.NoError(t, gov.PreloadAround(2*epoch+1, 0))
+	_, _, trace, err = gov.ReadGovernanceTraced(2*epoch + 1)
+	assert.NoError(t, err)
+	assert.Equal(t, ReadSourcePreloaded, trace.Source)
+	assert.Equal(t, epoch, trace.GovernanceBlock)
+
+	// no database
+	_, _, trace, _ = NewGovernance(getTestConfig(), nil).ReadGovernanceTraced(1)
+	assert.Equal(t, ReadSourceNone, trace.Source)
+}
+
+func TestGovernance_MinimumStakeLimit(t *testing.T) {
+	gov := getGovernance()
+	limit := new(big.Int).Mul(new(big.Int).SetUint64(params.MaxStakingLimit), new(big.Int).SetUint64(params.KLAY))
+
+	testCases := []struct {
+		minimumStake *big.Int
+		valid        bool
+	}{
+		{big.NewInt(0), true},
+		{big.NewInt(5000000), true},
+		{new(big.Int).Sub(limit, big.NewInt(1)), true},
+		{limit, true},
+		{new(big.Int).Add(limit, big.NewInt(1)), false},
+		{new(big.Int).Mul(limit, big.NewInt(10)), false},
+	}
+	for _, tc := range testCases {
+		_, ok := gov.ValidateVote(&GovernanceVote{Key: "reward.minimumstake", Value: tc.minimumStake.String()})
+		assert.Equal(t, tc.valid, ok, "minimumStake: %v", tc.minimumStake)
+	}
+
+	// A genesis with a minimum stake above the limit is rejected too
+	config := getTestConfig()
+	assert.NoError(t, CheckGenesisValues(config))
+	config.Governance.Reward.MinimumStake = new(big.Int).Add(limit, big.NewInt(1))
+	assert.Error(t, CheckGenesisValues(config))
+}
+
+func TestGovernance_ForkSchedule(t *testing.T) {
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	config := getTestConfig()
+	config.Istanbul.Epoch = 10
+	gov := NewGovernance(config, dbm)
+	gov.SetBlockchain(newTestBlockChain(t, gov.db, gov.ChainConfig, 35))
+
+	submit := func(v string) bool {
+		gov.voteMap = make(map[string]VoteStatus)
+		return gov.AddVote("governance.forkschedule", v)
+	}
+
+	// Forks should be scheduled after the current head
+	assert.True(t, submit("fork1:36"))
+	assert.True(t, submit("fork1:1000,fork2:2000"))
+	assert.False(t, submit("fork1:35"))
+	assert.False(t, submit("fork1:10"))
+	assert.False(t, submit("fork1:1000,fork2:20"))
+
+	// A vote in a header doesn't depend on the current head
+	_, ok := gov.ValidateVote(&GovernanceVote{Key: "governance.forkschedule", Value: "fork1:10"})
+	assert.True(t, ok)
+
+	// fork1 is already activated and fork2 is scheduled by the governance changed at block 10
+	delta := NewGovernanceSet()
+	delta.SetValue(params.ForkSchedule, "fork1:30,fork2:100")
+	assert.NoError(t, gov.WriteGovernance(10, gov.currentSet, delta))
+
+	// The activated fork is kept while the others are scheduled after the current head
+	assert.True(t, submit("fork1:30,fork2:200"))
+	assert.True(t, submit("fork1:30,fork2:100,fork3:50"))
+	assert.False(t, submit("fork1:40,fork2:100"))
+	assert.False(t, submit("fork2:100"))
+	assert.False(t, submit("fork1:30,fork2:10"))
+
+	// The schedule is resolved by the governance used for each block
+	num, ok := gov.ForkBlock("fork2", 100)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(100), num)
+	_, ok = gov.ForkBlock("fork3", 100)
+	assert.False(t, ok)
+	_, ok = gov.ForkBlock("fork1", 10)
+	assert.False(t, ok)
+
+	assert.True(t, gov.IsForkEnabled("fork1", 30))
+	assert.False(t, gov.IsForkEnabled("fork2", 99))
+	assert.True(t, gov.IsForkEnabled("fork2", 100))
+	assert.False(t, gov.IsForkEnabled("fork3", 1000))
+
+	// A vote in a header is parsed as a string
+	vote, err := gov.ParseVoteValue(&GovernanceVote{Key: "governance.forkschedule", Value: []byte("fork1:30,fork2:200")})
+	assert.NoError(t, err)
+	assert.Equal(t, "fork1:30,fork2:200", vote.Value)
+}
+
+func TestGovernance_ChangeStream(t *testing.T) {
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	gov := NewGovernance(getTestConfig(), dbm)
+	epoch := gov.ChainConfig.Istanbul.Epoch
+	genesis := gov.currentSet.Items()
+
+	// unitprice is changed at epoch, committeesize is changed at 2*epoch,
+	// both are changed at 4*epoch and nothing is changed at 3*epoch
+	changes := []struct {
+		num   uint64
+		items map[int]interface{}
+	}{
+		{epoch, map[int]interface{}{params.UnitPrice: uint64(10)}},
+		{2 * epoch, map[int]interface{}{params.CommitteeSize: uint64(7)}},
+		{3 * epoch, map[int]interface{}{}},
+		{4 * epoch, map[int]interface{}{params.UnitPrice: uint64(20), params.CommitteeSize: uint64(9)}},
+	}
+	for _, c := range changes {
+		delta := NewGovernanceSet()
+		for k, v := range c.items {
+			delta.SetValue(k, v)
+		}
+		if err := gov.WriteGovernance(c.num, gov.currentSet, delta); err != nil {
+			t.Fatalf("Failed to write governance: %v", err)
+		}
+		gov.currentSet.Merge(delta.Items())
+	}
+
+	all := []ChangeEvent{
+		{EpochBlock: epoch, Key: "governance.unitprice", Old: genesis["governance.unitprice"], New: uint64(10)},
+		{EpochBlock: 2 * epoch, Key: "istanbul.committeesize", Old: genesis["istanbul.committeesize"], New: uint64(7)},
+		{EpochBlock: 4 * epoch, Key: "governance.unitprice", Old: uint64(10), New: uint64(20)},
+		{EpochBlock: 4 * epoch, Key: "istanbul.committeesize", Old: uint64(7), New: uint64(9)},
+	}
+
+	testCases := []struct {
+		from, to uint64
+		expected []ChangeEvent
+	}{
+		{0, 5 * epoch, all},
+		{epoch, 4 * epoch, all},
+		{epoch + 1, 4*epoch - 1, all[1:2]},
+		{2 * epoch, 2 * epoch, all[1:2]},
+		{4 * epoch, 100 * epoch, all[2:]},
+		// no changes
+		{0, epoch - 1, []ChangeEvent{}},
+		{3 * epoch, 3 * epoch, []ChangeEvent{}},
+		{5 * epoch, 10 * epoch, []ChangeEvent{}},
+	}
+	for _, tc := range testCases {
+		events, err := gov.ChangeStream(tc.from, tc.to)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, events, "from: %d, to: %d", tc.from, tc.to)
+	}
+
+	_, err := gov.ChangeStream(2*epoch, epoch)
+	assert.Equal(t, ErrInvalidBlockRange, err)
+
+	_, err = NewGovernance(getTestConfig(), nil).ChangeStream(0, epoch)
+	assert.Equal(t, ErrNotInitialized, err)
+}
+
+func TestGovernance_DefaultItemsOfOldGovernance(t *testing.T) {
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	config := getTestConfig()
+	config.Istanbul.Epoch = 10
+
+	// Governance stored by an older node doesn't have the items added later
+	genesis := getGovernanceItemsFromChainConfig(config)
+	old := genesis.Items()
+	for key := range defaultGovernanceItems {
+		delete(old, GovernanceKeyMapReverse[key])
+	}
+	assert.NoError(t, dbm.WriteGovernance(old, 0))
+	gov := NewGovernance(config, dbm)
+
+	// The default values are used for the missing items
+	_, items, err := gov.ReadGovernance(5)
+	assert.NoError(t, err)
+	for key, v := range defaultGovernanceItems {
+		assert.Equal(t, v, items[GovernanceKeyMapReverse[key]], "key: %s", GovernanceKeyMapReverse[key])
+	}
+	gov.currentSet.Clear()
+	gov.currentSet.Import(old)
+	assert.Equal(t, params.DefaultMaxTxGas, gov.GetGovernanceValue(params.ConstMaxTxGas))
+	assert.Equal(t, common.HexToAddress(params.DefaultKIRAddress), gov.GetGovernanceValue(params.KIRAddress))
+
+	// The stored items aren't changed
+	stored, err := dbm.ReadGovernance(0)
+	assert.NoError(t, err)
+	assert.NotContains(t, stored, "governance.forkschedule")
+
+	// A fork scheduled by a vote after the upgrade is resolved along with the defaults
+	delta := NewGovernanceSet()
+	delta.SetValue(params.ForkSchedule, "fork1:30")
+	assert.NoError(t, gov.WriteGovernance(10, gov.currentSet, delta))
+	assert.True(t, gov.IsForkEnabled("fork1", 30))
+	assert.False(t, gov.IsForkEnabled("fork1", 5))
+}
+
+func TestGovernance_ImmutableFields(t *testing.T) {
+	gov := getGovernance()
+	epoch := gov.ChainConfig.Istanbul.Epoch
+
+	// Votes on the chain identity are rejected whatever their values and categories are
+	immutableVotes := []voteValue{
+		{k: "chainid", v: uint64(1001)},
+		{k: "governance.chainid", v: uint64(1001)},
+		{k: "Istanbul.ChainId", v: uint64(1001)},
+		{k: "param.networkid", v: uint64(1001)},
+		{k: "governance.engine", v: "single"},
+		{k: "governance.consensus", v: "single"},
+		{k: "istanbul.useistanbul", v: false},
+		{k: "clique.useclique", v: true},
+	}
+	for _, val := range immutableVotes {
+		_, ok := gov.ValidateVote(&GovernanceVote{Key: val.k, Value: val.v})
+		assert.False(t, ok, "key: %s", val.k)
+		assert.False(t, gov.AddVote(val.k, val.v), "key: %s", val.k)
+	}
+
+	// A key out of the registry isn't handled as another item
+	_, ok := gov.ValidateVote(&GovernanceVote{Key: "governance.unknown", Value: "single"})
+	assert.False(t, ok)
+
+	// Legitimate votes still pass
+	for _, val := range goodVotes {
+		_, ok := gov.ValidateVote(&GovernanceVote{Key: val.k, Value: val.v})
+		assert.True(t, ok, "key: %s", val.k)
+	}
+
+	// Governance data in a header can carry the chain identity regardless of votes
+	items, _ := json.Marshal(map[string]interface{}{
+		"governance.unitprice": uint64(50000000000),
+		"governance.chainid":   uint64(1001),
+	})
+	received, _ := rlp.EncodeToBytes(items)
+	gov.changeSet.SetValue(params.UnitPrice, uint64(50000000000))
+	assert.Equal(t, ErrImmutableGovChange, gov.VerifyGovernance(received))
+	gov.changeSet.Clear()
+
+	// If it is committed anyway, only the mutable items are stored
+	gov.UpdateGovernance(epoch, received)
+	stored, err := gov.db.ReadGovernance(epoch)
+	assert.NoError(t, err)
+	stored = adjustDecodedSet(stored)
+	assert.Equal(t, uint64(50000000000), stored["governance.unitprice"])
+	assert.NotContains(t, stored, "governance.chainid")
+}
+
+func TestGovernance_MigrateAddressEncoding(t *testing.T) {
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	gov, err := NewGovernanceWithCacheConfig(getTestConfig(), dbm, CacheConfig{ItemCacheSize: 10, IdxCacheLimit: 10})
+	if err != nil {
+		t.Fatalf("Failed to make governance: %v", err)
+	}
+	epoch := gov.ChainConfig.Istanbul.Epoch
+
+	// Older nodes stored addresses as checksummed strings
+	legacy := "0x52d41Ca72Af615A1aC3301B0a93efa222ecc7541"
+	canonical := "0x52d41ca72af615a1ac3301b0a93efa222ecc7541"
+	if err := dbm.WriteGovernance(map[string]interface{}{
+		"governance.governingnode": legacy,
+		"reward.kiraddress":        canonical,
+		"reward.pocaddress":        "not an address",
+		"governance.unitprice":     uint64(25000000000),
+	}, epoch); err != nil {
+		t.Fatalf("Failed to write governance: %v", err)
+	}
+	gov.currentSet.Import(map[string]interface{}{"governance.governingnode": legacy})
+	if err := gov.WriteGovernanceState(epoch, true); err != nil {
+		t.Fatalf("Failed to write governance state: %v", err)
+	}
+	indices, _ := dbm.ReadRecentGovernanceIdx(0)
+
+	assert.NoError(t, gov.MigrateAddressEncoding())
+
+	// The stored items are in the canonical encoding and the others are kept
+	stored, err := dbm.ReadGovernance(epoch)
+	assert.NoError(t, err)
+	assert.Equal(t, canonical, stored["governance.governingnode"])
+	assert.Equal(t, canonical, stored["reward.kiraddress"])
+	assert.Equal(t, "not an address", stored["reward.pocaddress"])
+	assert.Equal(t, json.Number("25000000000"), stored["governance.unitprice"])
+
+	// The governance history isn't changed
+	migrated, _ := dbm.ReadRecentGovernanceIdx(0)
+	assert.Equal(t, indices, migrated)
+
+	// The governance state is checkpointed again in the canonical encoding
+	state, err := dbm.ReadGovernanceState()
+	assert.NoError(t, err)
+	assert.NotContains(t, string(state), legacy)
+	assert.Contains(t, string(state), canonical)
+	assert.Equal(t, common.HexToAddress(legacy), gov.currentSet.GetAddress(params.GoverningNode, common.Address{}))
+
+	// Migrating again changes nothing
+	assert.NoError(t, gov.MigrateAddressEncoding())
+	again, _ := dbm.ReadGovernance(epoch)
+	assert.Equal(t, stored, again)
+
+	// A read-only governance can't migrate
+	readOnly := getGovernance()
+	readOnly.readOnly = true
+	assert.Equal(t, ErrReadOnly, readOnly.MigrateAddressEncoding())
+}
+
+func TestGovernance_ReadGovernanceBatch(t *testing.T) {
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	gov, err := NewGovernanceWithCacheConfig(getTestConfig(), dbm, CacheConfig{ItemCacheSize: 10, IdxCacheLimit: 10})
+	if err != nil {
+		t.Fatalf("Failed to make governance: %v", err)
+	}
+	epoch := gov.ChainConfig.Istanbul.Epoch
+	for i := uint64(1); i <= 2; i++ {
+		delta := NewGovernanceSet()
+		delta.SetValue(params.UnitPrice, i)
+		if err := gov.WriteGovernance(i*epoch, gov.currentSet, delta); err != nil {
+			t.Fatalf("Failed to write governance: %v", err)
+		}
+	}
+
+	// Blocks in the same and different epochs are resolved like ReadGovernance
+	nums := []uint64{1, epoch - 1, epoch, 2*epoch + 1, 3*epoch - 1, 3 * epoch, 5 * epoch, 1}
+	batch, err := gov.ReadGovernanceBatch(nums)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, len(batch))
+	for _, num := range nums {
+		_, expected, err := gov.ReadGovernance(num)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, batch[num], "num: %d", num)
+	}
+	assert.Equal(t, uint64(1), batch[3*epoch-1]["governance.unitprice"])
+	assert.Equal(t, uint64(2), batch[5*epoch]["governance.unitprice"])
+
+	// The items of the same governance block are read once
+	same := func(a, b uint64) bool {
+		return reflect.ValueOf(batch[a]).Pointer() == reflect.ValueOf(batch[b]).Pointer()
+	}
+	assert.True(t, same(1, epoch))
+	assert.True(t, same(2*epoch+1, 3*epoch-1))
+	assert.False(t, same(epoch, 2*epoch+1))
+
+	// Nothing is read for no blocks
+	batch, err = gov.ReadGovernanceBatch(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, batch)
+}
+
+func TestGovernance_InvalidateCacheAbove(t *testing.T) {
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	gov, err := NewGovernanceWithCacheConfig(getTestConfig(), dbm, CacheConfig{ItemCacheSize: 10, IdxCacheLimit: 10})
+	if err != nil {
+		t.Fatalf("Failed to make governance: %v", err)
+	}
+	epoch := gov.ChainConfig.Istanbul.Epoch
+	writeUnitPrice := func(num, price uint64) {
+		delta := NewGovernanceSet()
+		delta.SetValue(params.UnitPrice, price)
+		if err := gov.WriteGovernance(num, gov.currentSet, delta); err != nil {
+			t.Fatalf("Failed to write governance: %v", err)
+		}
+	}
+	for i := uint64(1); i <= 4; i++ {
+		writeUnitPrice(i*epoch, i)
+	}
+	assert.Equal(t, []uint64{0, epoch, 2 * epoch, 3 * epoch, 4 * epoch}, gov.idxCache)
+	assert.NoError(t, gov.PreloadAround(2*epoch+1, 2))
+
+	// The blocks above 2*epoch are orphaned
+	gov.InvalidateCacheAbove(2 * epoch)
+
+	// Entries above the threshold are evicted while the others are retained
+	assert.Equal(t, []uint64{0, epoch, 2 * epoch}, gov.idxCache)
+	for _, num := range []uint64{0, epoch, 2 * epoch} {
+		_, ok := gov.getGovernanceCache(num)
+		assert.True(t, ok, "num: %d", num)
+	}
+	for _, num := range []uint64{3 * epoch, 4 * epoch} {
+		_, ok := gov.getGovernanceCache(num)
+		assert.False(t, ok, "num: %d", num)
+	}
+	_, ok := gov.searchPreloaded(4*epoch + 1)
+	assert.False(t, ok)
+	idx, ok := gov.searchPreloaded(2 * epoch)
+	assert.True(t, ok)
+	assert.Equal(t, 2*epoch, idx)
+
+	// Governance of the orphaned governance blocks isn't used anymore
+	_, data, err := gov.ReadGovernance(5*epoch + 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), data["governance.unitprice"])
+
+	// Governance written on the new chain is cached again
+	writeUnitPrice(3*epoch, 30)
+	assert.Equal(t, []uint64{0, epoch, 2 * epoch, 3 * epoch}, gov.idxCache)
+	_, data, trace, err := gov.ReadGovernanceTraced(4*epoch + 1)
+	assert.NoError(t, err)
+	assert.Equal(t, ReadSourceCache, trace.Source)
+	assert.Equal(t, uint64(30), data["governance.unitprice"])
+}
+
+func TestGovernance_RegisterMetrics(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	gov := getGovernance()
+	epoch := gov.ChainConfig.Istanbul.Epoch
+	r := metrics.NewRegistry()
+	gov.RegisterMetrics(r)
+
+	gauge := func(name string) int64 {
+		g, ok := r.Get(governanceMetricPrefix + name).(metrics.Gauge)
+		if !ok {
+			t.Fatalf("No gauge for %s", name)
+		}
+		return g.Value()
+	}
+
+	// The current values are published on registration
+	assert.Equal(t, int64(gov.GetGovernanceValue(params.Epoch).(uint64)), gauge("istanbul.epoch"))
+	mode := gov.GetGovernanceValue(params.GovernanceMode).(string)
+	assert.Equal(t, int64(1), gauge("governance.governancemode/"+mode))
+
+	delta := NewGovernanceSet()
+	delta.SetValue(params.UnitPrice, uint64(61000000000))
+	delta.SetValue(params.CommitteeSize, uint64(13))
+	delta.SetValue(params.GovernanceMode, "ballot")
+	delta.SetValue(params.UseGiniCoeff, true)
+	if err := gov.WriteGovernance(epoch, gov.currentSet, delta); err != nil {
+		t.Fatalf("Failed to write governance: %v", err)
+	}
+	gov.UpdateCurrentGovernance(2*epoch + 1)
+
+	// The gauges are updated after the governance change
+	assert.Equal(t, int64(61000000000), gauge("governance.unitprice"))
+	assert.Equal(t, int64(13), gauge("istanbul.committeesize"))
+	assert.Equal(t, int64(1), gauge("reward.useginicoeff"))
+
+	// Non-numeric items are published as info
+	assert.Equal(t, int64(1), gauge("governance.governancemode/ballot"))
+	if mode != "ballot" {
+		assert.Equal(t, int64(0), gauge("governance.governancemode/"+mode))
+	}
+	assert.Equal(t, "reward_ratio_34_54_12", metricNameSafe("reward.ratio/34/54/12"))
+}
+
+func TestGovernance_ApplyMetrics(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	defer func(c metrics.Counter, apply, trigger metrics.Timer) {
+		governanceAppliedCounter, governanceApplyTimer, governanceTriggerTimer = c, apply, trigger
+	}(governanceAppliedCounter, governanceApplyTimer, governanceTriggerTimer)
+	governanceAppliedCounter, governanceApplyTimer, governanceTriggerTimer = metrics.NewCounter(), metrics.NewTimer(), metrics.NewTimer()
+
+	gov := getGovernance()
+	epoch := gov.ChainConfig.Istanbul.Epoch
+	delta := NewGovernanceSet()
+	delta.SetValue(params.UnitPrice, uint64(61000000000))
+	if err := gov.WriteGovernance(epoch, gov.currentSet, delta); err != nil {
+		t.Fatalf("Failed to write governance: %v", err)
+	}
+
+	// Nothing is applied before the change
+	gov.UpdateCurrentGovernance(epoch + 1)
+	assert.Equal(t, int64(0), governanceAppliedCounter.Count())
+	assert.Equal(t, int64(0), governanceApplyTimer.Count())
+
+	// The change is applied and timed once
+	gov.UpdateCurrentGovernance(2*epoch + 1)
+	assert.Equal(t, uint64(61000000000), gov.GetGovernanceValue(params.UnitPrice))
+	assert.Equal(t, int64(1), governanceAppliedCounter.Count())
+	assert.Equal(t, int64(1), governanceApplyTimer.Count())
+	assert.Equal(t, int64(1), governanceTriggerTimer.Count())
+
+	gov.UpdateCurrentGovernance(2*epoch + 2)
+	assert.Equal(t, int64(1), governanceAppliedCounter.Count())
+}
+
+func TestGovernanceSet_SetValues(t *testing.T) {
+	gs := NewGovernanceSet()
+	errs := gs.SetValues(map[int]interface{}{
+		params.UnitPrice:      uint64(25000000000),
+		params.Epoch:          "30000",
+		params.GovernanceMode: "single",
+		params.UseGiniCoeff:   uint64(1),
+		params.KIRAddress:     common.HexToAddress("0x1"),
+		-1:                    uint64(1),
+	})
+
+	// The valid items are set
+	assert.Equal(t, map[string]interface{}{
+		"governance.unitprice":      uint64(25000000000),
+		"governance.governancemode": "single",
+		"reward.kiraddress":         common.HexToAddress("0x1"),
+	}, gs.Items())
+
+	// All errors are reported in the order of the item types
+	if assert.Equal(t, 3, len(errs)) {
+		assert.Equal(t, ErrUnknownKey, errors.Cause(errs[0]))
+		assert.Equal(t, ErrValueTypeMismatch, errors.Cause(errs[1]))
+		assert.Contains(t, errs[1].Error(), "istanbul.epoch")
+		assert.Equal(t, ErrValueTypeMismatch, errors.Cause(errs[2]))
+		assert.Contains(t, errs[2].Error(), "reward.useginicoeff")
+	}
+
+	assert.Nil(t, gs.SetValues(map[int]interface{}{params.Epoch: uint64(30000)}))
+	assert.Nil(t, gs.SetValues(nil))
+}
+
+func TestGovernanceSet_RemoveItems(t *testing.T) {
+	gs := NewGovernanceSet()
+	gs.Import(map[string]interface{}{
+		"governance.unitprice":   uint64(25000000000),
+		"istanbul.epoch":         uint64(30000),
+		"istanbul.committeesize": uint64(7),
+		"reward.ratio":           "34/33/33",
+	})
+
+	// Absent keys are ignored
+	gs.RemoveItems([]string{"istanbul.epoch", "istanbul.committeesize", "reward.mintingamount"})
+	assert.Equal(t, map[string]interface{}{
+		"governance.unitprice": uint64(25000000000),
+		"reward.ratio":         "34/33/33",
+	}, gs.Items())
+
+	gs.RemoveItems(nil)
+	assert.Equal(t, 2, gs.Size())
+}
+
+// Readers of a GovernanceSet always see a consistent set of items while writers update it.
+// Run with -race to check that readers don't race with writers.
+func TestGovernanceSet_ConcurrentReadWrite(t *testing.T) {
+	gs := NewGovernanceSet()
+	gs.Import(map[string]interface{}{
+		"istanbul.epoch":         uint64(0),
+		"istanbul.committeesize": uint64(0),
+	})
+
+	const writes = 1000
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				// Both items are always written together, so a consistent reader sees the same values
+				items := gs.Items()
+				if items["istanbul.epoch"] != items["istanbul.committeesize"] {
+					t.Errorf("inconsistent items: %v", items)
+					return
+				}
+				gs.GetValue(params.Epoch)
+				gs.Size()
+			}
+		}()
+	}
+
+	for i := uint64(1); i <= writes; i++ {
+		gs.Merge(map[string]interface{}{
+			"istanbul.epoch":         i,
+			"istanbul.committeesize": i,
+		})
+		if i%10 == 0 {
+			gs.SetValue(params.UnitPrice, i)
+			gs.RemoveItem("governance.unitprice")
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	epoch, _ := gs.GetValue(params.Epoch)
+	assert.Equal(t, uint64(writes), epoch)
+	assert.Equal(t, 2, gs.Size())
+}
+
+func TestGovernanceVote_Hash(t *testing.T) {
+	gov := getGovernance()
+	validator := common.HexToAddress("0x1")
+	parse := func(key string, value []byte) GovernanceVote {
+		vote, err := gov.ParseVoteValue(&GovernanceVote{Validator: validator, Key: key, Value: value})
+		if err != nil {
+			t.Fatalf("Failed to parse vote: %v", err)
+		}
+		return *vote
+	}
+	hash := func(key string, value interface{}) common.Hash {
+		return GovernanceVote{Validator: validator, Key: key, Value: value}.Hash()
+	}
+
+	// The same votes in different encodings hash the same after ParseVoteValue
+	assert.Equal(t, hash("governance.governancemode", "single"), parse("governance.governancemode", []byte("single")).Hash())
+	assert.Equal(t, hash("reward.mintingamount", "9600000000000000000"), parse("reward.mintingamount", []byte("9600000000000000000")).Hash())
+	assert.Equal(t, hash("istanbul.epoch", uint64(30000)), parse("istanbul.epoch", []byte{0, 0, 0, 0, 0, 0, 0x75, 0x30}).Hash())
+	assert.Equal(t, hash("istanbul.epoch", uint64(30000)), parse("istanbul.epoch", []byte{0x75, 0x30}).Hash())
+	assert.Equal(t, hash("istanbul.epoch", uint64(30000)), hash("istanbul.epoch", float64(30000)))
+	assert.Equal(t, hash("reward.useginicoeff", true), parse("reward.useginicoeff", []byte{1}).Hash())
+	assert.Equal(t, hash("reward.kiraddress", common.HexToAddress("0xAbCd")), hash("reward.kiraddress", "0x000000000000000000000000000000000000AbCd"))
+	assert.Equal(t, hash("reward.kiraddress", common.HexToAddress("0xAbCd")), parse("reward.kiraddress", common.HexToAddress("0xabcd").Bytes()).Hash())
+
+	// Votes different in the validator, the key or the value hash differently
+	base := hash("istanbul.epoch", uint64(30000))
+	assert.NotEqual(t, base, GovernanceVote{Validator: common.HexToAddress("0x2"), Key: "istanbul.epoch", Value: uint64(30000)}.Hash())
+	assert.NotEqual(t, base, hash("istanbul.committeesize", uint64(30000)))
+	assert.NotEqual(t, base, hash("istanbul.epoch", uint64(30001)))
+	assert.NotEqual(t, hash("reward.useginicoeff", true), hash("reward.useginicoeff", false))
+}
+
+func TestGovernanceVote_Equals(t *testing.T) {
+	validator := common.HexToAddress("0x1")
+	vote := func(key string, value interface{}) GovernanceVote {
+		return GovernanceVote{Validator: validator, Key: key, Value: value}
+	}
+
+	equal := []struct {
+		a, b GovernanceVote
+	}{
+		{vote("istanbul.epoch", uint64(30000)), vote("istanbul.epoch", uint64(30000))},
+		{vote("istanbul.epoch", uint64(30000)), vote("istanbul.epoch", float64(30000))},
+		{vote("istanbul.epoch", uint64(30000)), vote("istanbul.epoch", json.Number("30000"))},
+		{vote("istanbul.epoch", uint64(30000)), vote(" Istanbul.Epoch ", uint64(30000))},
+		{vote("governance.governancemode", "single"), vote("governance.governancemode", "single")},
+		{vote("reward.useginicoeff", true), vote("reward.useginicoeff", "true")},
+		{vote("reward.kiraddress", common.HexToAddress("0xAbCd")), vote("reward.kiraddress", "0x000000000000000000000000000000000000abcd")},
+		{vote("reward.kiraddress", "0x000000000000000000000000000000000000ABCD"), vote("reward.kiraddress", "0x000000000000000000000000000000000000abcd")},
+		{vote("istanbul.epoch", []byte{0x75, 0x30}), vote("istanbul.epoch", []byte{0x75, 0x30})},
+		{vote("istanbul.epoch", nil), vote("istanbul.epoch", nil)},
+	}
+	for _, tc := range equal {
+		assert.True(t, tc.a.Equals(tc.b), "%v, %v", tc.a, tc.b)
+		assert.True(t, tc.b.Equals(tc.a), "%v, %v", tc.b, tc.a)
+	}
+
+	// Block numbers are not compared
+	a, b := vote("istanbul.epoch", uint64(30000)), vote("istanbul.epoch", uint64(30000))
+	a.BlockNumber, b.BlockNumber = 1, 2
+	assert.True(t, a.Equals(b))
+
+	unequal := []struct {
+		a, b GovernanceVote
+	}{
+		{vote("istanbul.epoch", uint64(30000)), vote("istanbul.epoch", uint64(30001))},
+		{vote("istanbul.epoch", uint64(30000)), vote("istanbul.committeesize", uint64(30000))},
+		{vote("istanbul.epoch", uint64(30000)), GovernanceVote{Validator: common.HexToAddress("0x2"), Key: "istanbul.epoch", Value: uint64(30000)}},
+		{vote("reward.useginicoeff", true), vote("reward.useginicoeff", false)},
+		{vote("reward.kiraddress", common.HexToAddress("0xAbCd")), vote("reward.kiraddress", common.HexToAddress("0xAbCe"))},
+		{vote("governance.governancemode", "single"), vote("governance.governancemode", "Single")},
+		{vote("istanbul.epoch", uint64(30000)), vote("istanbul.epoch", []byte{0x75, 0x30})},
+		{vote("istanbul.epoch", uint64(30000)), vote("istanbul.epoch", nil)},
+	}
+	for _, tc := range unequal {
+		assert.False(t, tc.a.Equals(tc.b), "%v, %v", tc.a, tc.b)
+		assert.False(t, tc.b.Equals(tc.a), "%v, %v", tc.b, tc.a)
+	}
+}
+
+func TestRewardDust(t *testing.T) {
+	testCases := []struct {
+		minting string
+		ratio   string
+		dust    int64
+	}{
+		// clean combinations
+		{"9600000000000000000", "34/54/12", 0},
+		{"6400000000000000000", "50/40/10", 0},
+		{"100", "34/54/12", 0},
+		{"0", "34/54/12", 0},
+		// unclean combinations
+		{"101", "34/54/12", 1},
+		{"9600000000000000001", "34/54/12", 1},
+		{"9600000000000000099", "34/54/12", 2},
+		{"7", "33/33/34", 1},
+	}
+	for _, tc := range testCases {
+		minting, _ := new(big.Int).SetString(tc.minting, 10)
+		dust := RewardDust(minting, tc.ratio)
+		if assert.NotNil(t, dust) {
+			assert.Equal(t, tc.dust, dust.Int64(), "minting: %s, ratio: %s", tc.minting, tc.ratio)
+		}
+	}
+
+	// The minting amount is not modified
+	minting := big.NewInt(101)
+	RewardDust(minting, "34/54/12")
+	assert.Equal(t, int64(101), minting.Int64())
+
+	// Invalid inputs
+	assert.Nil(t, RewardDust(nil, "34/54/12"))
+	assert.Nil(t, RewardDust(big.NewInt(100), "34/54"))
+	assert.Nil(t, RewardDust(big.NewInt(100), "34/54/13"))
+
+	// Unclean combinations are warned but still valid
+	gov := getGovernance()
+	_, ok := gov.ValidateVote(&GovernanceVote{Key: "reward.mintingamount", Value: "9600000000000000001"})
+	assert.True(t, ok)
+	_, ok = gov.ValidateVote(&GovernanceVote{Key: "reward.ratio", Value: "33/33/34"})
+	assert.True(t, ok)
+}
+
+func TestGovernance_GovernanceAtBlock(t *testing.T) {
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	gov := NewGovernance(getTestConfig(), dbm)
+	gov.ChainConfig.Istanbul.Epoch = 10
+	defer func() { gov.ChainConfig.Istanbul.Epoch = params.DefaultEpoch }()
+
+	// A change written exactly at the epoch boundary block 20 and a change written in the middle of the epoch at 35
+	for _, c := range []struct{ num, price uint64 }{{20, 1}, {35, 2}} {
+		delta := NewGovernanceSet()
+		delta.SetValue(params.UnitPrice, c.price)
+		if err := gov.WriteGovernance(c.num, gov.currentSet, delta); err != nil {
+			t.Fatalf("Failed to write governance: %v", err)
+		}
+	}
+	genesisPrice := gov.currentSet.Items()["governance.unitprice"]
+
+	assert.Equal(t, uint64(30), GovernanceEffectiveBlock(20, 10))
+	assert.Equal(t, uint64(50), GovernanceEffectiveBlock(35, 10))
+	assert.Equal(t, uint64(50), GovernanceEffectiveBlock(31, 10))
+	assert.Equal(t, uint64(50), GovernanceEffectiveBlock(39, 10))
+	assert.Equal(t, uint64(10), GovernanceEffectiveBlock(0, 10))
+
+	testCases := []struct {
+		num   uint64
+		price interface{}
+	}{
+		{0, genesisPrice},
+		{19, genesisPrice},
+		{20, genesisPrice}, // the change at 20 doesn't affect 20
+		{29, genesisPrice},
+		{30, uint64(1)}, // the change at 20 is in effect from the next epoch boundary
+		{39, uint64(1)},
+		{40, uint64(1)}, // the change at 35 is not in effect at the next epoch boundary
+		{49, uint64(1)},
+		{50, uint64(2)}, // but from the one after the next
+		{1000, uint64(2)},
+	}
+	for _, tc := range testCases {
+		data, err := gov.GovernanceAtBlock(tc.num)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.price, data["governance.unitprice"], "num: %d", tc.num)
+	}
+
+	_, err := NewGovernance(getTestConfig(), nil).GovernanceAtBlock(1)
+	assert.Equal(t, ErrNotInitialized, err)
+}
+
+func TestGovernance_GovernanceModeChangeClearsVotes(t *testing.T) {
+	gov := getGovernance()
+	epoch := gov.ChainConfig.Istanbul.Epoch
+	mode := gov.ChainConfig.Governance.GovernanceMode
+	defer func() { gov.ChainConfig.Governance.GovernanceMode = mode }()
+
+	newMode := "ballot"
+	if mode == newMode {
+		newMode = "single"
+	}
+	fill := func() {
+		gov.GovernanceVotes.Import([]GovernanceVote{{Validator: common.HexToAddress("0x1"), Key: "governance.unitprice", Value: uint64(75000000000)}})
+		gov.GovernanceTallies.Import([]GovernanceTallyItem{{Key: "governance.unitprice", Value: uint64(75000000000), Votes: 1}})
+		assert.True(t, gov.AddVote("istanbul.committeesize", uint64(11)))
+	}
+	writeMode := func(num uint64, m string) {
+		delta := NewGovernanceSet()
+		delta.SetValue(params.GovernanceMode, m)
+		if err := gov.WriteGovernance(num, gov.currentSet, delta); err != nil {
+			t.Fatalf("Failed to write governance: %v", err)
+		}
+	}
+
+	// Applying a change which keeps the mode keeps the votes and tallies
+	fill()
+	delta := NewGovernanceSet()
+	delta.SetValue(params.UnitPrice, uint64(50000000000))
+	assert.NoError(t, gov.WriteGovernance(epoch, gov.currentSet, delta))
+	gov.UpdateCurrentGovernance(2 * epoch)
+	assert.Equal(t, 1, len(gov.GovernanceTallies.Copy()))
+	assert.Equal(t, 1, len(gov.GovernanceVotes.Copy()))
+
+	// Applying a change of the mode clears them
+	writeMode(2*epoch, newMode)
+	gov.UpdateCurrentGovernance(3 * epoch)
+	assert.Equal(t, newMode, gov.ChainConfig.Governance.GovernanceMode)
+	assert.Equal(t, 0, len(gov.GovernanceTallies.Copy()))
+	assert.Equal(t, 0, len(gov.GovernanceVotes.Copy()))
+	assert.Equal(t, 0, len(gov.voteMap))
+
+	// Triggering the items on startup or recovery doesn't clear the votes loaded from the governance state
+	fill()
+	gov.triggerChange(map[string]interface{}{"governance.governancemode": mode})
+	assert.Equal(t, mode, gov.ChainConfig.Governance.GovernanceMode)
+	assert.Equal(t, 1, len(gov.GovernanceTallies.Copy()))
+	assert.Equal(t, 1, len(gov.GovernanceVotes.Copy()))
+}
+
+func BenchmarkGovernanceSet_GetValueWhileWriting(b *testing.B) {
+	gs := NewGovernanceSet()
+	gs.SetValue(params.Epoch, uint64(30000))
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for i := uint64(0); ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				gs.SetValue(params.UnitPrice, i)
+			}
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			gs.GetValue(params.Epoch)
+		}
+	})
+}
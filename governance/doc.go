@@ -28,18 +28,35 @@ In the console of the node, "governance.vote(key, value)" API can be used to cas
 Keys for the voting API
 
 Following keys can be handled as of 7/20/2019.
-  - "governance.governancemode"   : To change the governance mode
-  - "governance.governingnode"    : To change the governing node if the governance mode is "single"
-  - "governance.unitprice"        : To change the unitprice of Klaytn (Unit price is same as gasprice in Ethereum)
-  - "governance.addvalidator"     : To add new node as a council node
-  - "governance.removevalidator"  : To remove a node from the governance council
-  - "istanbul.epoch"              : To change Epoch, the period to gather votes
-  - "istanbul.committeesize"      : To change the size of the committee
-  - "reward.mintingamount"        : To change the amount of block generation reward
-  - "reward.ratio"                : To change the ratio used to distribute the reward between block proposer node, PoC and KIR
-  - "reward.useginicoeff"         : To change the application of gini coefficient to reduce gap between CCOs
-  - "reward.deferredtxfee"        : To change the way of distributing tx fee
-  - "reward.minimumstake"         : To change the minimum amount of stake to participate in the governance council
+  - "governance.governancemode"      : To change the governance mode
+  - "governance.governingnode"       : To change the governing node if the governance mode is "single"
+  - "governance.unitprice"           : To change the unitprice of Klaytn (Unit price is same as gasprice in Ethereum)
+  - "governance.addvalidator"        : To add new node as a council node
+  - "governance.removevalidator"     : To remove a node from the governance council
+  - "governance.blockgaslimit"       : To change the maximum amount of gas which can be used in a block
+  - "governance.targetgasperblock"   : To change the amount of gas per block that the base fee targets
+  - "governance.basefeedenominator"  : To change the max change rate of the base fee per block (1/denominator)
+  - "istanbul.epoch"                 : To change Epoch, the period to gather votes
+  - "istanbul.committeesize"         : To change the size of the committee
+  - "reward.mintingamount"           : To change the amount of block generation reward
+  - "reward.ratio"                   : To change the ratio used to distribute the reward between block proposer node, PoC and KIR
+  - "reward.useginicoeff"            : To change the application of gini coefficient to reduce gap between CCOs
+  - "reward.deferredtxfee"           : To change the way of distributing tx fee
+  - "reward.minimumstake"            : To change the minimum amount of stake to participate in the governance council
+  - "reward.kiraddress"              : To change the address of KIR contract which receives the KIR reward
+  - "reward.pocaddress"              : To change the address of PoC contract which receives the PoC reward
+  - "reward.addressbook"             : To change the address of AddressBook contract which has the staking information of council nodes
+  - "param.maxtxgas"                 : To change the maximum amount of gas a transaction can use
+  - "governance.forkschedule"        : To schedule the blocks where forks are activated, e.g., "fork1:1000,fork2:2000"
+  - "param.humanreadableaddress"     : To enable or disable human-readable addresses
+
+"reward.kiraddress", "reward.pocaddress" and "reward.addressbook" are zero addresses by default, which means the contracts
+registered in the AddressBook and the AddressBook deployed at the genesis are used. A zero address can't be voted,
+so the address overridden by governance is used permanently. It can only be changed to another non-zero address.
+
+"governance.blockgaslimit", "governance.targetgasperblock" and "governance.basefeedenominator" are only stored in the
+governance and can be read by GetGovernanceValue. Nothing enforces them yet, so changing them doesn't change how blocks
+are made. The target gas per block can't exceed the block gas limit.
 
 
 How governance works
@@ -44,22 +44,64 @@ var (
 )
 
 var GovernanceItems = map[int]check{
-	params.GovernanceMode:          {stringT, checkGovernanceMode, updateGovernanceConfig},
-	params.GoverningNode:           {addressT, checkAddress, updateGovernanceConfig},
-	params.UnitPrice:               {uint64T, checkUint64andBool, updateGovernanceConfig},
-	params.AddValidator:            {addressT, checkAddress, updateGovernanceConfig},
-	params.RemoveValidator:         {addressT, checkAddress, updateGovernanceConfig},
-	params.MintingAmount:           {stringT, checkBigInt, updateGovernanceConfig},
-	params.Ratio:                   {stringT, checkRatio, updateGovernanceConfig},
-	params.UseGiniCoeff:            {boolT, checkUint64andBool, updateGovernanceConfig},
-	params.DeferredTxFee:           {boolT, checkUint64andBool, updateGovernanceConfig},
-	params.MinimumStake:            {stringT, checkBigInt, updateGovernanceConfig},
-	params.StakeUpdateInterval:     {uint64T, checkUint64andBool, updateGovernanceConfig},
-	params.ProposerRefreshInterval: {uint64T, checkUint64andBool, updateGovernanceConfig},
-	params.Epoch:                   {uint64T, checkUint64andBool, updateGovernanceConfig},
-	params.Policy:                  {uint64T, checkUint64andBool, updateGovernanceConfig},
-	params.CommitteeSize:           {uint64T, checkUint64andBool, updateGovernanceConfig},
-	params.ConstTxGasHumanReadable: {uint64T, checkUint64andBool, updateParams},
+	params.GovernanceMode:            {stringT, checkGovernanceMode, updateGovernanceConfig},
+	params.GoverningNode:             {addressT, checkAddress, updateGovernanceConfig},
+	params.UnitPrice:                 {uint64T, checkUint64andBool, updateGovernanceConfig},
+	params.AddValidator:              {addressT, checkAddress, updateGovernanceConfig},
+	params.RemoveValidator:           {addressT, checkAddress, updateGovernanceConfig},
+	params.MintingAmount:             {stringT, checkBigInt, updateGovernanceConfig},
+	params.Ratio:                     {stringT, checkRatio, updateGovernanceConfig},
+	params.UseGiniCoeff:              {boolT, checkUint64andBool, updateGovernanceConfig},
+	params.DeferredTxFee:             {boolT, checkUint64andBool, updateGovernanceConfig},
+	params.MinimumStake:              {stringT, checkMinimumStake, updateGovernanceConfig},
+	params.StakeUpdateInterval:       {uint64T, checkUint64andBool, updateGovernanceConfig},
+	params.ProposerRefreshInterval:   {uint64T, checkUint64andBool, updateGovernanceConfig},
+	params.Epoch:                     {uint64T, checkUint64andBool, updateGovernanceConfig},
+	params.Policy:                    {uint64T, checkUint64andBool, updateGovernanceConfig},
+	params.CommitteeSize:             {uint64T, checkUint64andBool, updateGovernanceConfig},
+	params.ConstTxGasHumanReadable:   {uint64T, checkUint64andBool, updateParams},
+	params.BlockGasLimit:             {uint64T, checkUint64andBool, updateGovernanceConfig},
+	params.TargetGasPerBlock:         {uint64T, checkUint64andBool, updateGovernanceConfig},
+	params.BaseFeeDenominator:        {uint64T, checkUint64andBool, updateGovernanceConfig},
+	params.KIRAddress:                {addressT, checkNonZeroAddress, updateGovernanceConfig},
+	params.PoCAddress:                {addressT, checkNonZeroAddress, updateGovernanceConfig},
+	params.ConstMaxTxGas:             {uint64T, checkUint64andBool, updateParams},
+	params.ForkSchedule:              {stringT, checkForkSchedule, updateForkSchedule},
+	params.ConstHumanReadableAddress: {boolT, checkUint64andBool, updateParams},
+	params.AddressBookAddress:        {addressT, checkNonZeroAddress, updateGovernanceConfig},
+}
+
+// GovernanceValueType returns the Go type of the value of the given governance item, e.g., common.Address for
+// params.GoverningNode. It returns nil if the key is unknown.
+func GovernanceValueType(key int) reflect.Type {
+	if item, ok := GovernanceItems[key]; ok {
+		return item.t
+	}
+	return nil
+}
+
+// constraint limits the value of a uint64 governance item into [min, max].
+// max 0 means there is no upper limit. validator is an optional additional check.
+type constraint struct {
+	min       uint64
+	max       uint64
+	validator func(k string, v uint64) bool
+}
+
+var GovernanceConstraints = map[int]constraint{
+	// Epoch and intervals are used as divisors of block numbers
+	params.Epoch:                   {min: 1},
+	params.StakeUpdateInterval:     {min: 1},
+	params.ProposerRefreshInterval: {min: 1},
+	// A committee can't be made without any member
+	params.CommitteeSize: {min: 1},
+	// A block gas limit smaller than the minimum can't include even a simple transaction
+	params.BlockGasLimit:     {min: params.MinGasLimit, max: params.UpperGasLimit},
+	params.TargetGasPerBlock: {min: params.MinGasLimit, max: params.UpperGasLimit},
+	// The base fee would change by more than 100% per block if the denominator is 0
+	params.BaseFeeDenominator: {min: 1, max: params.MaxBaseFeeDenominator},
+	// A transaction should be able to transfer value at least
+	params.ConstMaxTxGas: {min: params.MinMaxTxGas, max: params.UpperGasLimit},
 }
 
 func updateParams(g *Governance, k string, v interface{}) bool {
@@ -67,10 +109,21 @@ func updateParams(g *Governance, k string, v interface{}) bool {
 	case params.ConstTxGasHumanReadable:
 		params.TxGasHumanReadable = v.(uint64)
 		logger.Info("TxGasHumanReadable changed", "New value", params.TxGasHumanReadable)
+	case params.ConstMaxTxGas:
+		params.MaxTxGas = v.(uint64)
+		logger.Info("MaxTxGas changed", "New value", params.MaxTxGas)
+	case params.ConstHumanReadableAddress:
+		params.HumanReadableAddress = v.(bool)
+		logger.Info("HumanReadableAddress changed", "New value", params.HumanReadableAddress)
 	}
 	return true
 }
 
+func updateForkSchedule(g *Governance, k string, v interface{}) bool {
+	logger.Info("Fork schedule changed", "New value", v)
+	return true
+}
+
 func updateGovernanceConfig(g *Governance, k string, v interface{}) bool {
 	switch GovernanceKeyMap[k] {
 	case params.GovernanceMode:
@@ -79,7 +132,9 @@ func updateGovernanceConfig(g *Governance, k string, v interface{}) bool {
 		g.ChainConfig.Governance.GoverningNode = v.(common.Address)
 	case params.UnitPrice:
 		newPrice := v.(uint64)
-		g.TxPool.SetGasPrice(big.NewInt(0).SetUint64(newPrice))
+		if g.TxPool != nil {
+			g.TxPool.SetGasPrice(big.NewInt(0).SetUint64(newPrice))
+		}
 		g.ChainConfig.UnitPrice = newPrice
 	case params.MintingAmount:
 		g.ChainConfig.Governance.Reward.MintingAmount, _ = new(big.Int).SetString(v.(string), 10)
@@ -87,7 +142,9 @@ func updateGovernanceConfig(g *Governance, k string, v interface{}) bool {
 		g.ChainConfig.Governance.Reward.Ratio = v.(string)
 	case params.UseGiniCoeff:
 		g.ChainConfig.Governance.Reward.UseGiniCoeff = v.(bool)
-		g.blockChain.Config().Governance.Reward.UseGiniCoeff = g.ChainConfig.Governance.Reward.UseGiniCoeff
+		if g.blockChain != nil {
+			g.blockChain.Config().Governance.Reward.UseGiniCoeff = g.ChainConfig.Governance.Reward.UseGiniCoeff
+		}
 	case params.DeferredTxFee:
 		g.ChainConfig.Governance.Reward.DeferredTxFee = v.(bool)
 	case params.MinimumStake:
@@ -102,7 +159,9 @@ func updateGovernanceConfig(g *Governance, k string, v interface{}) bool {
 		g.ChainConfig.Istanbul.Epoch = v.(uint64)
 	case params.Policy:
 		g.ChainConfig.Istanbul.ProposerPolicy = uint64(v.(uint64))
-		g.blockChain.Config().Istanbul.ProposerPolicy = g.ChainConfig.Istanbul.ProposerPolicy
+		if g.blockChain != nil {
+			g.blockChain.Config().Istanbul.ProposerPolicy = g.ChainConfig.Istanbul.ProposerPolicy
+		}
 	case params.CommitteeSize:
 		g.ChainConfig.Istanbul.SubGroupSize = v.(uint64)
 	}
@@ -117,13 +176,33 @@ func (g *Governance) AddVote(key string, val interface{}) bool {
 	key = g.getKey(key)
 
 	// If the key is forbidden, stop processing it
-	if _, ok := GovernanceForbiddenKeyMap[key]; ok {
+	if g.isForbiddenKey(key) {
 		return false
 	}
 
 	vote := &GovernanceVote{Key: key, Value: val}
+	if g.blockChain != nil {
+		// The vote will be cast in the next block at the earliest
+		vote.BlockNumber = g.blockChain.CurrentHeader().Number.Uint64() + 1
+	}
 	var ok bool
 	if vote, ok = g.ValidateVote(vote); ok {
+		switch GovernanceKeyMap[key] {
+		case params.ForkSchedule:
+			// The schedule is checked against the local head only when a vote is submitted,
+			// since a vote in a header should be valid regardless of the head of the node
+			if !g.checkForkScheduleHead(vote.Value.(string)) {
+				return false
+			}
+		case params.AddValidator, params.RemoveValidator:
+			// A vote which changes nothing is rejected as HandleGovernanceVote does.
+			// It isn't checked until the validator set is known
+			valset := g.validatorSet()
+			if valset != nil && !g.checkVote(vote.Value.(common.Address), GovernanceKeyMap[key] == params.AddValidator, valset) {
+				logger.Warn("The vote doesn't change the validator set", "key", key, "value", vote.Value)
+				return false
+			}
+		}
 		g.voteMap[key] = VoteStatus{
 			Value:  vote.Value,
 			Casted: false,
@@ -170,22 +249,289 @@ func (gov *Governance) checkType(vote *GovernanceVote) bool {
 }
 
 func (gov *Governance) checkKey(k string) bool {
-	key := GovernanceKeyMap[k]
+	key, ok := GovernanceKeyMap[k]
+	if !ok {
+		return false
+	}
 	if _, ok := GovernanceItems[key]; ok {
 		return true
 	}
 	return false
 }
 
+// isImmutableKey returns true if the key refers to a chain config field identifying a chain
+func isImmutableKey(k string) bool {
+	field := k[strings.LastIndex(k, ".")+1:]
+	_, ok := GovernanceImmutableFields[field]
+	return ok
+}
+
+// ValidateVote validates the vote and returns it with the canonical key and the value of the registered type.
+// A value decoded from a header as raw bytes is parsed by ParseVoteValue, so the returned vote is
+// the single representation of a validated vote regardless of where it came from.
 func (gov *Governance) ValidateVote(vote *GovernanceVote) (*GovernanceVote, bool) {
+	vote, err := gov.validateVote(vote)
+	return vote, err == nil
+}
+
+// validateVote is the same as ValidateVote, but it returns ErrValueTypeMismatch if a raw value can't be parsed
+// and ErrInvalidVote if the vote is invalid.
+func (gov *Governance) validateVote(vote *GovernanceVote) (*GovernanceVote, error) {
 	vote.Key = gov.getKey(vote.Key)
 	key := GovernanceKeyMap[vote.Key]
+	if isRawVoteValue(vote.Value) && gov.checkKey(vote.Key) {
+		parsed, err := gov.ParseVoteValue(vote)
+		if err != nil {
+			return vote, err
+		}
+		vote = parsed
+	}
 	vote.Value = gov.adjustValueType(vote.Key, vote.Value)
 
 	if gov.checkKey(vote.Key) && gov.checkType(vote) {
-		return vote, GovernanceItems[key].validator(vote.Key, vote.Value)
+		if gov.isInChangeCooldown(vote.Key, vote.BlockNumber) {
+			logger.Warn("The item was changed recently and can't be changed yet", "key", vote.Key, "cooldownEpochs", gov.changeCooldownEpochs)
+			return vote, ErrInvalidVote
+		}
+		if key == params.CommitteeSize && !gov.checkCommitteeSizeRamp(vote.Value.(uint64), vote.BlockNumber) {
+			return vote, ErrInvalidVote
+		}
+		if (key == params.BlockGasLimit || key == params.TargetGasPerBlock) && !gov.checkGasTarget(key, vote.Value.(uint64), vote.BlockNumber) {
+			return vote, ErrInvalidVote
+		}
+		if !GovernanceItems[key].validator(vote.Key, vote.Value) || !checkConstraint(vote.Key, vote.Value) {
+			return vote, ErrInvalidVote
+		}
+		if key == params.MintingAmount || key == params.Ratio {
+			gov.warnRewardDust(key, vote.Value.(string))
+		}
+		return vote, nil
+	}
+	return vote, ErrInvalidVote
+}
+
+// isRawVoteValue returns true if the value is as decoded from the RLP-encoded vote of a header
+func isRawVoteValue(v interface{}) bool {
+	switch v.(type) {
+	case []byte, []interface{}:
+		return true
+	}
+	return false
+}
+
+// warnRewardDust warns if the minting amount and the ratio, one of which is voted, leave dust in each block.
+func (gov *Governance) warnRewardDust(key int, value string) {
+	minting := gov.currentSet.GetString(params.MintingAmount, "")
+	ratio := gov.currentSet.GetString(params.Ratio, "")
+	if key == params.MintingAmount {
+		minting = value
+	} else {
+		ratio = value
+	}
+
+	amount, ok := new(big.Int).SetString(minting, 10)
+	if !ok {
+		return
+	}
+	if dust := RewardDust(amount, ratio); dust != nil && dust.Sign() != 0 {
+		logger.Warn("The minting amount isn't divided by the ratio cleanly. Consider a round number",
+			"mintingAmount", minting, "ratio", ratio, "dustPerBlock", dust)
+	}
+}
+
+// RewardDust returns the remainder of the minting amount left in each block after splitting it
+// into the shares of the ratio "cn/poc/kir" which are rounded down. It returns nil if the ratio is invalid.
+func RewardDust(minting *big.Int, ratio string) *big.Int {
+	if minting == nil || !checkRatio(GovernanceKeyMapReverse[params.Ratio], ratio) {
+		return nil
+	}
+	totalRatio := big.NewInt(100)
+	dust := new(big.Int).Set(minting)
+	for _, item := range strings.Split(ratio, "/") {
+		r, _ := strconv.ParseUint(item, 10, 64)
+		share := new(big.Int).Mul(minting, new(big.Int).SetUint64(r))
+		dust.Sub(dust, share.Div(share, totalRatio))
+	}
+	return dust
+}
+
+// checkForkScheduleHead checks that the forks newly scheduled or rescheduled are activated after the current head,
+// and the forks already activated are kept as they are. If the current block is unknown, it returns true.
+// The current schedule is the one used for the current head.
+func (gov *Governance) checkForkScheduleHead(v string) bool {
+	schedule, err := parseForkSchedule(v)
+	if err != nil || gov.blockChain == nil {
+		return err == nil
+	}
+	head := gov.blockChain.CurrentHeader().Number.Uint64()
+
+	current := gov.forkSchedule(head)
+	for name, num := range current {
+		if num <= head && schedule[name] != num {
+			logger.Warn("A fork already activated can't be rescheduled", "fork", name, "block", num, "head", head)
+			return false
+		}
+	}
+	for name, num := range schedule {
+		if prev, ok := current[name]; ok && prev == num {
+			continue
+		}
+		if num <= head {
+			logger.Warn("A fork should be scheduled after the current head", "fork", name, "block", num, "head", head)
+			return false
+		}
+	}
+	return true
+}
+
+// parseForkSchedule parses a fork schedule which is a list of "name:block" separated by commas.
+// A fork name consists of lowercase letters, digits and underscores. An empty string is an empty schedule.
+func parseForkSchedule(s string) (map[string]uint64, error) {
+	schedule := make(map[string]uint64)
+	if s == "" {
+		return schedule, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		x := strings.Split(item, ":")
+		if len(x) != 2 || !isForkName(x[0]) {
+			return nil, ErrInvalidForkSchedule
+		}
+		if _, ok := schedule[x[0]]; ok {
+			return nil, ErrInvalidForkSchedule
+		}
+		num, err := strconv.ParseUint(x[1], 10, 64)
+		if err != nil {
+			return nil, ErrInvalidForkSchedule
+		}
+		schedule[x[0]] = num
+	}
+	return schedule, nil
+}
+
+func isForkName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func checkForkSchedule(k string, v interface{}) bool {
+	_, err := parseForkSchedule(v.(string))
+	return err == nil
+}
+
+// checkConstraint checks the value against the constraint of the item. Items without a constraint always pass.
+func checkConstraint(k string, v interface{}) bool {
+	c, ok := GovernanceConstraints[GovernanceKeyMap[k]]
+	if !ok {
+		return true
+	}
+	value, ok := v.(uint64)
+	if !ok {
+		return false
+	}
+	if value < c.min || (c.max != 0 && value > c.max) {
+		return false
+	}
+	if c.validator != nil {
+		return c.validator(k, value)
+	}
+	return true
+}
+
+// isInChangeCooldown returns true if the item of the given key was changed within the configured number of epochs
+// before the given block, which is the block of the vote. Changes are read from the governance database up to the
+// block, so it doesn't depend on the local head of the node. If the cooldown is disabled or the block is unknown,
+// it returns false.
+func (gov *Governance) isInChangeCooldown(key string, num uint64) bool {
+	if gov.changeCooldownEpochs == 0 || num == 0 || gov.ChainConfig.Istanbul == nil {
+		return false
+	}
+	var from uint64
+	if window := gov.changeCooldownEpochs * gov.ChainConfig.Istanbul.Epoch; num >= window {
+		from = num - window + 1
+	}
+	events, err := gov.ChangeStream(from, num)
+	if err != nil {
+		return false
+	}
+	for _, e := range events {
+		if e.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCommitteeSizeRamp returns false if the committee size differs by more than the ramp from the one used for
+// the given block, which is the block of the vote. It's read from the governance of the block, so it doesn't depend
+// on the local state of the node.
+func (gov *Governance) checkCommitteeSizeRamp(size uint64, num uint64) bool {
+	if gov.committeeSizeRamp == 0 {
+		return true
+	}
+	_, items, err := gov.ReadGovernance(num)
+	if err != nil {
+		logger.Warn("Failed to read the committee size for the ramp", "num", num, "err", err)
+		return true
+	}
+	latest, ok := items[GovernanceKeyMapReverse[params.CommitteeSize]]
+	if !ok {
+		// For CI tests which don't have a database
+		latest = gov.GetGovernanceValue(params.CommitteeSize)
+	}
+	current, ok := latest.(uint64)
+	if !ok {
+		return true
+	}
+
+	delta := size - current
+	if size < current {
+		delta = current - size
+	}
+	if delta > gov.committeeSizeRamp {
+		logger.Warn("The change of committee size exceeds the ramp", "num", num, "current", current, "voted", size, "ramp", gov.committeeSizeRamp)
+		return false
+	}
+	return true
+}
+
+// checkGasTarget returns false if the target gas per block would exceed the block gas limit.
+// The other one of the two is read from the governance of the given block, which is the block of the vote.
+func (gov *Governance) checkGasTarget(key int, value uint64, num uint64) bool {
+	other := params.TargetGasPerBlock
+	if key == params.TargetGasPerBlock {
+		other = params.BlockGasLimit
 	}
-	return vote, false
+	_, items, err := gov.ReadGovernance(num)
+	if err != nil {
+		logger.Warn("Failed to read the gas limit for the target gas", "num", num, "err", err)
+		return true
+	}
+	latest, ok := items[GovernanceKeyMapReverse[other]]
+	if !ok {
+		// For CI tests which don't have a database
+		latest = gov.GetGovernanceValue(other)
+	}
+	current, ok := latest.(uint64)
+	if !ok {
+		return true
+	}
+
+	target, limit := current, value
+	if key == params.TargetGasPerBlock {
+		target, limit = value, current
+	}
+	if target > limit {
+		logger.Warn("The target gas per block exceeds the block gas limit", "num", num, "target", target, "limit", limit)
+		return false
+	}
+	return true
 }
 
 func checkRatio(k string, v interface{}) bool {
@@ -238,52 +584,107 @@ func checkBigInt(k string, v interface{}) bool {
 	return false
 }
 
+// maxStakingLimitPeb is the maximum staking amount counted for the reward in peb
+var maxStakingLimitPeb = new(big.Int).Mul(new(big.Int).SetUint64(params.MaxStakingLimit), new(big.Int).SetUint64(params.KLAY))
+
+// checkMinimumStake rejects a minimum stake (in peb) larger than the maximum staking amount counted for the reward,
+// since no node could have the minimum stake.
+func checkMinimumStake(k string, v interface{}) bool {
+	x, ok := new(big.Int).SetString(v.(string), 10)
+	if !ok {
+		return false
+	}
+	if x.Cmp(maxStakingLimitPeb) > 0 {
+		logger.Warn("Minimum stake exceeds the maximum staking limit", "minimumStake", x, "maxStakingLimit", maxStakingLimitPeb)
+		return false
+	}
+	return true
+}
+
 func checkAddress(k string, v interface{}) bool {
 	return true
 }
 
+// checkNonZeroAddress rejects a zero address, since it can't be a contract which receives the reward.
+// The default zero address of reward.kiraddress, reward.pocaddress and reward.addressbook means the default contract
+// is used, so once one of them is changed by governance, it can't be changed back to the default contract.
+func checkNonZeroAddress(k string, v interface{}) bool {
+	return v.(common.Address) != common.Address{}
+}
+
+// DecodeAndValidateVote decodes a vote from the raw vote bytes of a header, parses its value and validates it.
+// It returns ErrDecodeVote if the bytes are not an RLP-encoded vote, ErrUnknownKey if the key is unknown,
+// ErrValueTypeMismatch if the value can't be parsed and ErrInvalidVote if the vote is forbidden or invalid.
+func (gov *Governance) DecodeAndValidateVote(raw []byte) (*GovernanceVote, error) {
+	gVote := new(GovernanceVote)
+	if err := rlp.DecodeBytes(raw, gVote); err != nil {
+		return nil, ErrDecodeVote
+	}
+	gVote.Key = gov.getKey(gVote.Key)
+	if _, ok := GovernanceKeyMap[gVote.Key]; !ok {
+		return nil, ErrUnknownKey
+	}
+	if gov.isForbiddenKey(gVote.Key) {
+		return nil, ErrInvalidVote
+	}
+	gVote, err := gov.validateVote(gVote)
+	if err != nil {
+		return nil, err
+	}
+	return gVote, nil
+}
+
 func (gov *Governance) HandleGovernanceVote(valset istanbul.ValidatorSet, votes []GovernanceVote, tally []GovernanceTallyItem, header *types.Header, proposer common.Address, self common.Address) (istanbul.ValidatorSet, []GovernanceVote, []GovernanceTallyItem) {
 	gVote := new(GovernanceVote)
 
 	if len(header.Vote) > 0 {
-		var err error
-
 		if err := rlp.DecodeBytes(header.Vote, gVote); err != nil {
 			logger.Error("Failed to decode a vote. This vote will be ignored", "number", header.Number, "key", gVote.Key, "value", gVote.Value, "validator", gVote.Validator)
 			return valset, votes, tally
 		}
-		if gVote, err = gov.ParseVoteValue(gVote); err != nil {
-			logger.Error("Failed to parse a vote value. This vote will be ignored", "number", header.Number, "key", gVote.Key, "value", gVote.Value, "validator", gVote.Validator)
-			return valset, votes, tally
-		}
+		gVote.Key = gov.getKey(gVote.Key)
 
 		// If the given key is forbidden, stop processing
-		if _, ok := GovernanceForbiddenKeyMap[gVote.Key]; ok {
+		if gov.isForbiddenKey(gVote.Key) {
 			logger.Warn("Forbidden vote key was received", "key", gVote.Key, "value", gVote.Value, "from", gVote.Validator)
 			return valset, votes, tally
 		}
 
-		key := GovernanceKeyMap[gVote.Key]
-		switch key {
-		case params.GoverningNode:
-			_, addr := valset.GetByAddress(gVote.Value.(common.Address))
-			if addr == nil {
-				logger.Warn("Invalid governing node address", "number", header.Number, "Validator", gVote.Validator, "key", gVote.Key, "value", gVote.Value)
-				return valset, votes, tally
-			}
-		case params.AddValidator:
-			if !gov.checkVote(gVote.Value.(common.Address), true, valset) {
-				return valset, votes, tally
-			}
-		case params.RemoveValidator:
-			if !gov.checkVote(gVote.Value.(common.Address), false, valset) {
-				return valset, votes, tally
-			}
+		number := header.Number.Uint64()
+		gVote.BlockNumber = number
+
+		// If the validator of the vote is not authorized at this block, stop processing
+		if !gov.isAuthorizedVoter(gVote.Validator, number) {
+			logger.Warn("Unauthorized vote was received. This vote will be ignored", "number", number, "key", gVote.Key, "value", gVote.Value, "validator", gVote.Validator)
+			return valset, votes, tally
 		}
 
-		number := header.Number.Uint64()
-		// Check vote's validity
-		if gVote, ok := gov.ValidateVote(gVote); ok {
+		// Check vote's validity. The value is parsed into its registered type
+		gVote, err := gov.validateVote(gVote)
+		switch {
+		case err == ErrValueTypeMismatch:
+			logger.Error("Failed to parse a vote value. This vote will be ignored", "number", header.Number, "key", gVote.Key, "value", gVote.Value, "validator", gVote.Validator)
+			return valset, votes, tally
+		case err != nil:
+			logger.Warn("Received Vote was invalid", "number", header.Number, "Validator", gVote.Validator, "key", gVote.Key, "value", gVote.Value)
+		default:
+			switch GovernanceKeyMap[gVote.Key] {
+			case params.GoverningNode:
+				_, addr := valset.GetByAddress(gVote.Value.(common.Address))
+				if addr == nil {
+					logger.Warn("Invalid governing node address", "number", header.Number, "Validator", gVote.Validator, "key", gVote.Key, "value", gVote.Value)
+					return valset, votes, tally
+				}
+			case params.AddValidator:
+				if !gov.checkVote(gVote.Value.(common.Address), true, valset) {
+					return valset, votes, tally
+				}
+			case params.RemoveValidator:
+				if !gov.checkVote(gVote.Value.(common.Address), false, valset) {
+					return valset, votes, tally
+				}
+			}
+
 			governanceMode := GovernanceModeMap[gov.ChainConfig.Governance.GovernanceMode]
 			governingNode := gov.ChainConfig.Governance.GoverningNode
 
@@ -301,8 +702,6 @@ func (gov *Governance) HandleGovernanceVote(valset istanbul.ValidatorSet, votes
 			if self == proposer {
 				gov.removeDuplicatedVote(gVote, header.Number.Uint64())
 			}
-		} else {
-			logger.Warn("Received Vote was invalid", "number", header.Number, "Validator", gVote.Validator, "key", gVote.Key, "value", gVote.Value)
 		}
 		if number > atomic.LoadUint64(&gov.lastGovernanceStateBlock) {
 			gov.GovernanceVotes.Import(votes)
@@ -312,6 +711,97 @@ func (gov *Governance) HandleGovernanceVote(valset istanbul.ValidatorSet, votes
 	return valset, votes, tally
 }
 
+// tallyKey identifies a tally item
+type tallyKey struct {
+	key   string
+	value interface{}
+}
+
+// TallyVotes ingests the votes of a block's committee at once and accumulates them into GovernanceTallies,
+// weighted by the given voting powers. Invalid votes and votes of validators without voting power are ignored.
+// A previous vote of the same validator on the same key is replaced by the new one.
+// It returns the tally items which exceeded the half of the total voting power by this batch.
+func (g *Governance) TallyVotes(votes []GovernanceVote, powers map[common.Address]uint64) []GovernanceTallyItem {
+	var total uint64
+	for _, vp := range powers {
+		total += vp
+	}
+
+	valid := make([]GovernanceVote, 0, len(votes))
+	for _, vote := range votes {
+		gVote := &GovernanceVote{Validator: vote.Validator, Key: vote.Key, Value: vote.Value, BlockNumber: vote.BlockNumber}
+		if powers[gVote.Validator] == 0 {
+			logger.Warn("Vote from a validator without voting power is ignored", "validator", gVote.Validator, "key", gVote.Key)
+			continue
+		}
+		if g.isForbiddenKey(g.getKey(gVote.Key)) {
+			logger.Warn("Forbidden vote key was received", "key", gVote.Key, "from", gVote.Validator)
+			continue
+		}
+		// The value decoded from a header is parsed by ValidateVote
+		gVote, ok := g.ValidateVote(gVote)
+		if !ok {
+			logger.Warn("Invalid vote is ignored", "validator", gVote.Validator, "key", gVote.Key, "value", gVote.Value)
+			continue
+		}
+		valid = append(valid, *gVote)
+	}
+
+	g.GovernanceVotes.mu.Lock()
+	defer g.GovernanceVotes.mu.Unlock()
+	g.GovernanceTallies.mu.Lock()
+	defer g.GovernanceTallies.mu.Unlock()
+
+	currentVotes := make([]GovernanceVote, len(g.GovernanceVotes.items))
+	copy(currentVotes, g.GovernanceVotes.items)
+	tally := make([]GovernanceTallyItem, len(g.GovernanceTallies.items))
+	copy(tally, g.GovernanceTallies.items)
+
+	before := make(map[tallyKey]uint64, len(tally))
+	for _, item := range tally {
+		before[tallyKey{item.Key, item.Value}] = item.Votes
+	}
+
+	for _, gVote := range valid {
+		vp := powers[gVote.Validator]
+		for idx, prev := range currentVotes {
+			if prev.Validator == gVote.Validator && prev.Key == gVote.Key {
+				// The previous vote is subtracted with the power it was tallied with, since the power may have changed.
+				// A vote tallied by HandleGovernanceVote doesn't have it, so the current power is used as the handler does
+				prevVp := prev.VotingPower
+				if prevVp == 0 {
+					prevVp = vp
+				}
+				_, tally = g.changeGovernanceTally(tally, prev.Key, prev.Value, prevVp, false)
+				currentVotes = append(currentVotes[:idx], currentVotes[idx+1:]...)
+				break
+			}
+		}
+		gVote.VotingPower = vp
+		currentVotes = append(currentVotes, gVote)
+		_, tally = g.changeGovernanceTally(tally, gVote.Key, gVote.Value, vp, true)
+	}
+
+	var passed []GovernanceTallyItem
+	for _, item := range tally {
+		if item.Votes > total/2 && before[tallyKey{item.Key, item.Value}] <= total/2 {
+			passed = append(passed, item)
+		}
+	}
+
+	g.GovernanceVotes.items = currentVotes
+	g.GovernanceTallies.items = tally
+	return passed
+}
+
+// isAuthorizedVoter returns true if no vote authorizer is set or the authorizer accepts the validator at the given block.
+func (gov *Governance) isAuthorizedVoter(validator common.Address, number uint64) bool {
+	if gov.voteAuthorizer == nil {
+		return true
+	}
+	return gov.voteAuthorizer(validator, number)
+}
+
 func (gov *Governance) checkVote(address common.Address, authorize bool, valset istanbul.ValidatorSet) bool {
 	_, validator := valset.GetByAddress(address)
 	return (validator != nil && !authorize) || (validator == nil && authorize)
@@ -330,15 +820,14 @@ func (gov *Governance) removePreviousVote(valset istanbul.ValidatorSet, votes []
 		// Check if previous vote from same validator exists
 		if vote.Validator == validator && vote.Key == gVote.Key {
 			// Reduce Tally
-			_, v := valset.GetByAddress(vote.Validator)
-			vp := v.VotingPower()
+			vp := gov.votingPowerOf(valset, vote.Validator)
 			var currentVotes uint64
 			currentVotes, tally = gov.changeGovernanceTally(tally, vote.Key, vote.Value, vp, false)
 
 			// Remove the old vote from GovernanceVotes
 			ret = append(votes[:idx], votes[idx+1:]...)
 			if gov.isGovernanceModeSingleOrNone(governanceMode, governingNode, gVote.Validator) ||
-				(governanceMode == params.GovernanceMode_Ballot && currentVotes <= valset.TotalVotingPower()/2) {
+				(governanceMode == params.GovernanceMode_Ballot && currentVotes <= gov.totalVotingPowerOf(valset)/2) {
 				if v, ok := gov.changeSet.GetValue(GovernanceKeyMap[vote.Key]); ok && v == vote.Value {
 					gov.changeSet.RemoveItem(vote.Key)
 				}
@@ -389,11 +878,11 @@ func (gov *Governance) changeGovernanceTally(tally []GovernanceTallyItem, key st
 func (gov *Governance) addNewVote(valset istanbul.ValidatorSet, votes []GovernanceVote, tally []GovernanceTallyItem, gVote *GovernanceVote, governanceMode int, governingNode common.Address, blockNum uint64) (istanbul.ValidatorSet, []GovernanceVote, []GovernanceTallyItem) {
 	_, v := valset.GetByAddress(gVote.Validator)
 	if v != nil {
-		vp := v.VotingPower()
+		vp := gov.votingPowerOf(valset, gVote.Validator)
 		var currentVotes uint64
 		currentVotes, tally = gov.changeGovernanceTally(tally, gVote.Key, gVote.Value, vp, true)
 		if gov.isGovernanceModeSingleOrNone(governanceMode, governingNode, gVote.Validator) ||
-			(governanceMode == params.GovernanceMode_Ballot && currentVotes > valset.TotalVotingPower()/2) {
+			(governanceMode == params.GovernanceMode_Ballot && currentVotes > gov.totalVotingPowerOf(valset)/2) {
 			switch GovernanceKeyMap[gVote.Key] {
 			case params.AddValidator:
 				valset.AddValidator(gVote.Value.(common.Address))
@@ -411,6 +900,36 @@ func (gov *Governance) addNewVote(valset istanbul.ValidatorSet, votes []Governan
 	return valset, votes, tally
 }
 
+// votingPowerOf returns the voting power of the validator used to weight its votes.
+// The voting powers set by SetVotingPowers have priority over the ones in the validator set.
+func (gov *Governance) votingPowerOf(valset istanbul.ValidatorSet, addr common.Address) uint64 {
+	gov.votingPowersLock.RLock()
+	defer gov.votingPowersLock.RUnlock()
+
+	if len(gov.votingPowers) > 0 {
+		return gov.votingPowers[addr]
+	}
+	if _, v := valset.GetByAddress(addr); v != nil {
+		return v.VotingPower()
+	}
+	return 0
+}
+
+// totalVotingPowerOf returns the total voting power which a tally is compared with.
+func (gov *Governance) totalVotingPowerOf(valset istanbul.ValidatorSet) uint64 {
+	gov.votingPowersLock.RLock()
+	defer gov.votingPowersLock.RUnlock()
+
+	if len(gov.votingPowers) > 0 {
+		total := uint64(0)
+		for _, power := range gov.votingPowers {
+			total += power
+		}
+		return total
+	}
+	return valset.TotalVotingPower()
+}
+
 func (gov *Governance) removeVotesFromRemovedNode(votes []GovernanceVote, addr common.Address) []GovernanceVote {
 	ret := make([]GovernanceVote, len(votes))
 	copy(ret, votes)
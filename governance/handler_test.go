@@ -0,0 +1,566 @@
+// Copyright 2019 The klaytn Authors
+// This file is part of the klaytn library.
+//
+// The klaytn library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The klaytn library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the klaytn library. If not, see <http://www.gnu.org/licenses/>.
+
+package governance
+
+import (
+	"encoding/json"
+	"github.com/klaytn/klaytn/blockchain/types"
+	"github.com/klaytn/klaytn/common"
+	"github.com/klaytn/klaytn/consensus/istanbul"
+	"github.com/klaytn/klaytn/params"
+	"github.com/klaytn/klaytn/ser/rlp"
+	"github.com/klaytn/klaytn/storage/database"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testValidator and testValidatorSet implement only the methods used by the vote handler
+type testValidator struct {
+	istanbul.Validator
+	address     common.Address
+	votingPower uint64
+}
+
+func (v *testValidator) Address() common.Address { return v.address }
+func (v *testValidator) VotingPower() uint64     { return v.votingPower }
+
+type testValidatorSet struct {
+	istanbul.ValidatorSet
+	validators []*testValidator
+}
+
+func newTestValidatorSet(addrs ...common.Address) *testValidatorSet {
+	valset := &testValidatorSet{}
+	for _, addr := range addrs {
+		valset.AddValidator(addr)
+	}
+	return valset
+}
+
+func (vs *testValidatorSet) GetByAddress(addr common.Address) (int, istanbul.Validator) {
+	for i, v := range vs.validators {
+		if v.address == addr {
+			return i, v
+		}
+	}
+	return -1, nil
+}
+
+func (vs *testValidatorSet) TotalVotingPower() uint64 {
+	sum := uint64(0)
+	for _, v := range vs.validators {
+		sum += v.votingPower
+	}
+	return sum
+}
+
+func (vs *testValidatorSet) Copy() istanbul.ValidatorSet {
+	copied := &testValidatorSet{}
+	for _, v := range vs.validators {
+		copied.validators = append(copied.validators, &testValidator{address: v.address, votingPower: v.votingPower})
+	}
+	return copied
+}
+
+func (vs *testValidatorSet) AddValidator(addr common.Address) bool {
+	if _, v := vs.GetByAddress(addr); v != nil {
+		return false
+	}
+	vs.validators = append(vs.validators, &testValidator{address: addr, votingPower: 1000})
+	return true
+}
+
+func (vs *testValidatorSet) RemoveValidator(addr common.Address) bool {
+	for i, v := range vs.validators {
+		if v.address == addr {
+			vs.validators = append(vs.validators[:i], vs.validators[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func makeVoteHeader(t *testing.T, num uint64, validator common.Address, key string, value interface{}) *types.Header {
+	vote, err := rlp.EncodeToBytes(&GovernanceVote{Validator: validator, Key: key, Value: value})
+	if err != nil {
+		t.Fatalf("Failed to encode a vote: %v", err)
+	}
+	return &types.Header{Number: big.NewInt(int64(num)), Vote: vote}
+}
+
+func TestGovernance_SetVoteAuthorizer(t *testing.T) {
+	validator := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	header := makeVoteHeader(t, 1, validator, "governance.unitprice", uint64(50000000000))
+
+	// The authorizer accepts the validator
+	{
+		gov := getGovernance()
+		gov.SetVoteAuthorizer(func(addr common.Address, num uint64) bool {
+			return addr == validator && num == 1
+		})
+		_, votes, tally := gov.HandleGovernanceVote(newTestValidatorSet(validator), []GovernanceVote{}, []GovernanceTallyItem{}, header, validator, common.Address{})
+		assert.Equal(t, 1, len(votes))
+		assert.Equal(t, 1, len(tally))
+	}
+
+	// The authorizer rejects the validator
+	{
+		gov := getGovernance()
+		gov.SetVoteAuthorizer(func(addr common.Address, num uint64) bool {
+			return false
+		})
+		_, votes, tally := gov.HandleGovernanceVote(newTestValidatorSet(validator), []GovernanceVote{}, []GovernanceTallyItem{}, header, validator, common.Address{})
+		assert.Equal(t, 0, len(votes))
+		assert.Equal(t, 0, len(tally))
+		assert.Equal(t, 0, gov.changeSet.Size())
+	}
+}
+
+func TestGovernance_Snapshot(t *testing.T) {
+	gov := getGovernance()
+	validator := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	gov.SetNodeAddress(validator)
+	gov.SetMyVotingPower(1000)
+	gov.SetTotalVotingPower(3000)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var valset istanbul.ValidatorSet = newTestValidatorSet(validator)
+		votes, tally := []GovernanceVote{}, []GovernanceTallyItem{}
+		for i := uint64(1); i <= 100; i++ {
+			gov.AddVote("governance.unitprice", 25000000000+i)
+			header := makeVoteHeader(t, i, validator, "governance.unitprice", 25000000000+i)
+			valset, votes, tally = gov.HandleGovernanceVote(valset, votes, tally, header, validator, validator)
+			gov.UpdateCurrentGovernance(i)
+		}
+	}()
+
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		snap := gov.Snapshot()
+		assert.Equal(t, validator, snap.NodeAddress)
+		assert.Equal(t, uint64(1000), snap.VotingPower)
+		assert.Equal(t, uint64(3000), snap.TotalVotingPower)
+	}
+
+	snap := gov.Snapshot()
+	assert.Equal(t, 1, len(snap.Votes))
+	assert.Equal(t, 1, len(snap.Tallies))
+	assert.Equal(t, uint64(25000000100), snap.ChangeSet["governance.unitprice"])
+	assert.Equal(t, gov.currentSet.Items(), snap.CurrentSet)
+}
+
+func TestGovernance_ValidateVote_Constraints(t *testing.T) {
+	// The bounds of the block gas limit aren't limited by the target gas per block
+	gov := NewGovernance(getTestConfig(), nil)
+	gov.currentSet.SetValue(params.TargetGasPerBlock, params.MinGasLimit)
+
+	testCases := []struct {
+		key   string
+		value uint64
+		valid bool
+	}{
+		{"istanbul.epoch", 0, false},
+		{"istanbul.epoch", 1, true},
+		{"istanbul.committeesize", 0, false},
+		{"istanbul.committeesize", 1, true},
+		{"reward.stakingupdateinterval", 0, false},
+		{"reward.stakingupdateinterval", 1, true},
+		{"reward.proposerupdateinterval", 0, false},
+		{"reward.proposerupdateinterval", 1, true},
+		{"governance.blockgaslimit", params.MinGasLimit - 1, false},
+		{"governance.blockgaslimit", params.MinGasLimit, true},
+		{"governance.blockgaslimit", params.UpperGasLimit, true},
+		{"governance.blockgaslimit", params.UpperGasLimit + 1, false},
+		{"governance.targetgasperblock", params.MinGasLimit - 1, false},
+		{"governance.targetgasperblock", params.MinGasLimit, true},
+		{"governance.targetgasperblock", params.UpperGasLimit, true},
+		{"governance.targetgasperblock", params.UpperGasLimit + 1, false},
+		{"governance.basefeedenominator", 0, false},
+		{"governance.basefeedenominator", 1, true},
+		{"governance.basefeedenominator", params.MaxBaseFeeDenominator, true},
+		{"governance.basefeedenominator", params.MaxBaseFeeDenominator + 1, false},
+		// unitprice has no constraint
+		{"governance.unitprice", 0, true},
+	}
+	for _, tc := range testCases {
+		_, ok := gov.ValidateVote(&GovernanceVote{Key: tc.key, Value: tc.value})
+		assert.Equal(t, tc.valid, ok, "key: %v, value: %v", tc.key, tc.value)
+	}
+}
+
+func TestGovernance_ValidateVote_GasTarget(t *testing.T) {
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	config := getTestConfig()
+	config.Istanbul.Epoch = 10
+	gov := NewGovernance(config, dbm)
+
+	// The block gas limit is lowered at block 10 and used from block 20
+	delta := NewGovernanceSet()
+	delta.SetValue(params.BlockGasLimit, uint64(40000000))
+	assert.NoError(t, gov.WriteGovernance(10, gov.currentSet, delta))
+
+	testCases := []struct {
+		key   string
+		value uint64
+		num   uint64
+		valid bool
+	}{
+		{"governance.targetgasperblock", 50000000, 5, true},
+		{"governance.targetgasperblock", 50000000, 25, false},
+		{"governance.targetgasperblock", 40000000, 25, true},
+		{"governance.blockgaslimit", params.DefaultTargetGasPerBlock, 25, true},
+		{"governance.blockgaslimit", params.DefaultTargetGasPerBlock - 1, 25, false},
+	}
+	for _, tc := range testCases {
+		_, ok := gov.ValidateVote(&GovernanceVote{Key: tc.key, Value: tc.value, BlockNumber: tc.num})
+		assert.Equal(t, tc.valid, ok, "key: %v, value: %v, num: %v", tc.key, tc.value, tc.num)
+	}
+}
+
+func TestCheckConstraint_Validator(t *testing.T) {
+	key := params.CommitteeSize
+	org := GovernanceConstraints[key]
+	defer func() { GovernanceConstraints[key] = org }()
+
+	// A custom validator is applied in addition to the range
+	GovernanceConstraints[key] = constraint{min: 1, max: 100, validator: func(k string, v uint64) bool { return v%2 == 1 }}
+	assert.True(t, checkConstraint("istanbul.committeesize", uint64(7)))
+	assert.False(t, checkConstraint("istanbul.committeesize", uint64(8)))
+	assert.False(t, checkConstraint("istanbul.committeesize", uint64(101)))
+	assert.False(t, checkConstraint("istanbul.committeesize", "7"))
+}
+
+func TestGovernance_SetVotingPowers(t *testing.T) {
+	validators := []common.Address{
+		common.HexToAddress("0x1"),
+		common.HexToAddress("0x2"),
+		common.HexToAddress("0x3"),
+	}
+	powers := map[common.Address]uint64{
+		validators[0]: 5000,
+		validators[1]: 1000,
+		validators[2]: 1000,
+	}
+
+	testCases := []struct {
+		voters       []common.Address
+		votingPowers map[common.Address]uint64
+		passed       bool
+		votes        uint64
+	}{
+		// Without voting powers, every validator has the same power in the test validator set
+		{[]common.Address{validators[0]}, nil, false, 1000},
+		{[]common.Address{validators[1], validators[2]}, nil, true, 2000},
+		// A validator having more than half of total voting power can pass a proposal alone
+		{[]common.Address{validators[0]}, powers, true, 5000},
+		// Validators having less than half of total voting power can't pass a proposal
+		{[]common.Address{validators[1], validators[2]}, powers, false, 2000},
+	}
+
+	for i, tc := range testCases {
+		gov := getGovernance()
+		gov.ChainConfig.Governance.GovernanceMode = "ballot"
+		gov.SetVotingPowers(tc.votingPowers)
+
+		var valset istanbul.ValidatorSet = newTestValidatorSet(validators...)
+		votes, tally := []GovernanceVote{}, []GovernanceTallyItem{}
+		for j, voter := range tc.voters {
+			header := makeVoteHeader(t, uint64(j+1), voter, "governance.unitprice", uint64(50000000000))
+			valset, votes, tally = gov.HandleGovernanceVote(valset, votes, tally, header, voter, common.Address{})
+		}
+
+		assert.Equal(t, 1, len(tally), "test case %d", i)
+		assert.Equal(t, tc.votes, tally[0].Votes, "test case %d", i)
+		_, ok := gov.changeSet.GetValue(params.UnitPrice)
+		assert.Equal(t, tc.passed, ok, "test case %d", i)
+	}
+}
+
+func TestGovernance_TallyVotes(t *testing.T) {
+	gov := getGovernance()
+	gov.ChainConfig.Governance.GovernanceMode = "ballot"
+
+	a, b, c := common.HexToAddress("0xa"), common.HexToAddress("0xb"), common.HexToAddress("0xc")
+	powers := map[common.Address]uint64{a: 40, b: 30, c: 30}
+
+	// unitprice gets 70 of 100 and passes, while epoch gets 30 and doesn't
+	passed := gov.TallyVotes([]GovernanceVote{
+		{Validator: a, Key: "governance.unitprice", Value: uint64(50000000000)},
+		{Validator: b, Key: "governance.unitprice", Value: uint64(50000000000)},
+		{Validator: c, Key: "istanbul.epoch", Value: uint64(30000)},
+		// ignored: no voting power, invalid value and forbidden key
+		{Validator: common.HexToAddress("0xd"), Key: "istanbul.epoch", Value: uint64(30000)},
+		{Validator: a, Key: "istanbul.committeesize", Value: uint64(0)},
+		{Validator: b, Key: "reward.stakingupdateinterval", Value: uint64(20)},
+	}, powers)
+	assert.Equal(t, []GovernanceTallyItem{{Key: "governance.unitprice", Value: uint64(50000000000), Votes: 70}}, passed)
+	assert.Equal(t, []GovernanceTallyItem{
+		{Key: "governance.unitprice", Value: uint64(50000000000), Votes: 70},
+		{Key: "istanbul.epoch", Value: uint64(30000), Votes: 30},
+	}, gov.GovernanceTallies.Copy())
+	assert.Equal(t, 3, len(gov.GovernanceVotes.Copy()))
+
+	// A raw vote from a header is parsed. An item already passed is not returned again
+	encoded, _ := rlp.EncodeToBytes(&GovernanceVote{Validator: c, Key: "governance.unitprice", Value: uint64(50000000000)})
+	raw := GovernanceVote{}
+	rlp.DecodeBytes(encoded, &raw)
+	passed = gov.TallyVotes([]GovernanceVote{raw}, powers)
+	assert.Empty(t, passed)
+
+	// A validator changing its vote replaces the previous one
+	passed = gov.TallyVotes([]GovernanceVote{
+		{Validator: a, Key: "istanbul.epoch", Value: uint64(30000)},
+		{Validator: b, Key: "governance.unitprice", Value: uint64(75000000000)},
+	}, powers)
+	assert.Equal(t, []GovernanceTallyItem{{Key: "istanbul.epoch", Value: uint64(30000), Votes: 70}}, passed)
+	assert.Equal(t, []GovernanceTallyItem{
+		{Key: "governance.unitprice", Value: uint64(50000000000), Votes: 70},
+		{Key: "istanbul.epoch", Value: uint64(30000), Votes: 70},
+		{Key: "governance.unitprice", Value: uint64(75000000000), Votes: 30},
+	}, gov.GovernanceTallies.Copy())
+
+	// The voting power of a changes. Its previous vote is subtracted with the power it was tallied with
+	powers[a] = 20
+	passed = gov.TallyVotes([]GovernanceVote{
+		{Validator: a, Key: "istanbul.epoch", Value: uint64(40000)},
+	}, powers)
+	assert.Empty(t, passed)
+	assert.Equal(t, []GovernanceTallyItem{
+		{Key: "governance.unitprice", Value: uint64(50000000000), Votes: 70},
+		{Key: "istanbul.epoch", Value: uint64(30000), Votes: 30},
+		{Key: "governance.unitprice", Value: uint64(75000000000), Votes: 30},
+		{Key: "istanbul.epoch", Value: uint64(40000), Votes: 20},
+	}, gov.GovernanceTallies.Copy())
+}
+
+func TestGovernance_DecodeAndValidateVote(t *testing.T) {
+	gov := getGovernance()
+	validator := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	encode := func(key string, value interface{}) []byte {
+		b, err := rlp.EncodeToBytes(&GovernanceVote{Validator: validator, Key: key, Value: value})
+		if err != nil {
+			t.Fatalf("Failed to encode a vote: %v", err)
+		}
+		return b
+	}
+
+	// malformed RLP
+	_, err := gov.DecodeAndValidateVote([]byte{0xff, 0x01})
+	assert.Equal(t, ErrDecodeVote, err)
+
+	// unknown key
+	_, err = gov.DecodeAndValidateVote(encode("governance.unknown", uint64(1)))
+	assert.Equal(t, ErrUnknownKey, err)
+
+	// value of an unparsable type
+	_, err = gov.DecodeAndValidateVote(encode("governance.unitprice", []interface{}{[]byte{1}}))
+	assert.Equal(t, ErrValueTypeMismatch, err)
+
+	// forbidden key and invalid value
+	_, err = gov.DecodeAndValidateVote(encode("istanbul.policy", uint64(params.RoundRobin)))
+	assert.Equal(t, ErrInvalidVote, err)
+	_, err = gov.DecodeAndValidateVote(encode("governance.governancemode", "unknown"))
+	assert.Equal(t, ErrInvalidVote, err)
+
+	// valid vote
+	vote, err := gov.DecodeAndValidateVote(encode("governance.unitprice", uint64(50000000000)))
+	assert.NoError(t, err)
+	assert.Equal(t, &GovernanceVote{Validator: validator, Key: "governance.unitprice", Value: uint64(50000000000)}, vote)
+}
+
+func TestGovernanceValueType(t *testing.T) {
+	testCases := map[int]interface{}{
+		params.GovernanceMode:            "",
+		params.GoverningNode:             common.Address{},
+		params.UnitPrice:                 uint64(0),
+		params.AddValidator:              common.Address{},
+		params.RemoveValidator:           common.Address{},
+		params.MintingAmount:             "",
+		params.Ratio:                     "",
+		params.UseGiniCoeff:              false,
+		params.DeferredTxFee:             false,
+		params.MinimumStake:              "",
+		params.StakeUpdateInterval:       uint64(0),
+		params.ProposerRefreshInterval:   uint64(0),
+		params.Epoch:                     uint64(0),
+		params.Policy:                    uint64(0),
+		params.CommitteeSize:             uint64(0),
+		params.ConstTxGasHumanReadable:   uint64(0),
+		params.BlockGasLimit:             uint64(0),
+		params.TargetGasPerBlock:         uint64(0),
+		params.BaseFeeDenominator:        uint64(0),
+		params.KIRAddress:                common.Address{},
+		params.PoCAddress:                common.Address{},
+		params.ConstMaxTxGas:             uint64(0),
+		params.ForkSchedule:              "",
+		params.ConstHumanReadableAddress: false,
+		params.AddressBookAddress:        common.Address{},
+	}
+	assert.Equal(t, len(GovernanceItems), len(testCases))
+	for key, v := range testCases {
+		assert.Equal(t, reflect.TypeOf(v), GovernanceValueType(key), GovernanceKeyMapReverse[key])
+	}
+
+	// Unknown keys
+	assert.Nil(t, GovernanceValueType(params.CliqueEpoch))
+	assert.Nil(t, GovernanceValueType(-1))
+}
+
+func TestGovernance_ValidateVote_Canonical(t *testing.T) {
+	gov := getGovernance()
+	decode := func(key string, value interface{}) *GovernanceVote {
+		b, _ := rlp.EncodeToBytes(&GovernanceVote{Key: key, Value: value})
+		v := new(GovernanceVote)
+		if err := rlp.DecodeBytes(b, v); err != nil {
+			t.Fatalf("Failed to decode a vote: %v", err)
+		}
+		return v
+	}
+
+	// A vote decoded from a header carries the typed value after validation
+	testCases := []struct {
+		key      string
+		value    interface{}
+		expected interface{}
+	}{
+		{"governance.unitprice", uint64(50000000000), uint64(50000000000)},
+		{"Istanbul.CommitteeSize", uint64(7), uint64(7)},
+		{"reward.useginicoeff", true, true},
+		{"governance.governancemode", "single", "single"},
+		{"reward.kiraddress", common.HexToAddress("0xabc"), common.HexToAddress("0xabc")},
+	}
+	for _, tc := range testCases {
+		vote, ok := gov.ValidateVote(decode(tc.key, tc.value))
+		assert.True(t, ok, tc.key)
+		assert.Equal(t, strings.ToLower(tc.key), vote.Key)
+		assert.Equal(t, tc.expected, vote.Value, tc.key)
+	}
+
+	// A typed vote is the same after validation
+	vote, ok := gov.ValidateVote(&GovernanceVote{Key: "governance.unitprice", Value: uint64(50000000000)})
+	assert.True(t, ok)
+	assert.Equal(t, uint64(50000000000), vote.Value)
+
+	// A raw value which can't be parsed is invalid
+	_, ok = gov.ValidateVote(decode("governance.unitprice", []interface{}{[]byte{1}}))
+	assert.False(t, ok)
+}
+
+func TestGovernance_SetCommitteeSizeRamp(t *testing.T) {
+	gov := getGovernance()
+	epoch := gov.ChainConfig.Istanbul.Epoch
+	current := gov.GetGovernanceValue(params.CommitteeSize).(uint64)
+	validate := func(size uint64, num uint64) bool {
+		_, ok := gov.ValidateVote(&GovernanceVote{Key: "istanbul.committeesize", Value: size, BlockNumber: num})
+		return ok
+	}
+
+	// No limitation by default
+	assert.True(t, validate(current+10, 1))
+
+	// A change within the ramp is allowed and a larger one is rejected
+	gov.SetCommitteeSizeRamp(2)
+	assert.True(t, validate(current+2, 1))
+	assert.True(t, validate(current-2, 1))
+	assert.False(t, validate(current+3, 1))
+	assert.False(t, validate(current-3, 1))
+
+	// The committee size used for the block of the vote is the base of the ramp,
+	// regardless of the local change set
+	delta := NewGovernanceSet()
+	delta.SetValue(params.CommitteeSize, current+2)
+	if err := gov.WriteGovernance(epoch, gov.currentSet, delta); err != nil {
+		t.Fatalf("Failed to write governance: %v", err)
+	}
+	gov.changeSet.SetValue(params.CommitteeSize, current+4)
+	assert.True(t, validate(current+2, epoch+1))
+	assert.False(t, validate(current+4, epoch+1))
+	assert.True(t, validate(current+4, 2*epoch+1))
+	assert.False(t, validate(current-1, 2*epoch+1))
+
+	// A node which restarted reads the same base
+	restarted := NewGovernance(gov.ChainConfig, gov.db)
+	restarted.SetCommitteeSizeRamp(2)
+	_, ok := restarted.ValidateVote(&GovernanceVote{Key: "istanbul.committeesize", Value: current + 4, BlockNumber: epoch + 1})
+	assert.False(t, ok)
+	_, ok = restarted.ValidateVote(&GovernanceVote{Key: "istanbul.committeesize", Value: current + 4, BlockNumber: 2*epoch + 1})
+	assert.True(t, ok)
+}
+
+func TestGovernance_SetValidatorSet(t *testing.T) {
+	member, nonMember := common.HexToAddress("0x1"), common.HexToAddress("0x2")
+	gov := getGovernance()
+	submit := func(key string, addr common.Address) bool {
+		gov.voteMap = make(map[string]VoteStatus)
+		return gov.AddVote(key, addr)
+	}
+
+	// Votes aren't checked without the validator set
+	assert.True(t, submit("governance.addvalidator", member))
+	assert.True(t, submit("governance.removevalidator", nonMember))
+
+	gov.SetValidatorSet(newTestValidatorSet(member))
+
+	// Redundant votes are rejected
+	assert.False(t, submit("governance.addvalidator", member))
+	assert.False(t, submit("governance.removevalidator", nonMember))
+
+	// Valid votes are accepted
+	assert.True(t, submit("governance.addvalidator", nonMember))
+	assert.True(t, submit("governance.removevalidator", member))
+
+	// A vote in a header is validated regardless of the validator set of the node
+	_, ok := gov.ValidateVote(&GovernanceVote{Key: "governance.addvalidator", Value: member})
+	assert.True(t, ok)
+}
+
+func TestGovernanceVotes_SortedByBlock(t *testing.T) {
+	validators := []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2"), common.HexToAddress("0x3")}
+
+	// The block number is recorded when a vote is handled
+	gov := getGovernance()
+	var valset istanbul.ValidatorSet = newTestValidatorSet(validators...)
+	votes, tally := []GovernanceVote{}, []GovernanceTallyItem{}
+	for i, num := range []uint64{30, 10, 20} {
+		header := makeVoteHeader(t, num, validators[i], "governance.unitprice", uint64(50000000000))
+		valset, votes, tally = gov.HandleGovernanceVote(valset, votes, tally, header, validators[i], common.Address{})
+	}
+
+	sorted := gov.GovernanceVotes.SortedByBlock()
+	assert.Equal(t, 3, len(sorted))
+	assert.Equal(t, []uint64{10, 20, 30}, []uint64{sorted[0].BlockNumber, sorted[1].BlockNumber, sorted[2].BlockNumber})
+	assert.Equal(t, []common.Address{validators[1], validators[2], validators[0]}, []common.Address{sorted[0].Validator, sorted[1].Validator, sorted[2].Validator})
+
+	// The block number isn't a part of the vote in a header
+	withNumber, _ := rlp.EncodeToBytes(&GovernanceVote{Validator: validators[0], Key: "governance.unitprice", Value: uint64(1), BlockNumber: 10})
+	withoutNumber, _ := rlp.EncodeToBytes(&GovernanceVote{Validator: validators[0], Key: "governance.unitprice", Value: uint64(1)})
+	assert.Equal(t, withoutNumber, withNumber)
+
+	// A vote written without the block number is decoded with block number 0
+	var vote GovernanceVote
+	assert.NoError(t, json.Unmarshal([]byte(`{"validator":"0x0000000000000000000000000000000000000001","key":"governance.unitprice","type":"uint64","value":1}`), &vote))
+	assert.Equal(t, GovernanceVote{Validator: validators[0], Key: "governance.unitprice", Value: uint64(1)}, vote)
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 The klaytn Authors
+// This file is part of the klaytn library.
+//
+// The klaytn library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The klaytn library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the klaytn library. If not, see <http://www.gnu.org/licenses/>.
+
+package governance
+
+import (
+	"github.com/klaytn/klaytn/metrics"
+	"strings"
+	"sync"
+)
+
+// governanceMetricPrefix is the prefix of the names of governance metrics
+const governanceMetricPrefix = "klay/governance/"
+
+// governanceCacheEvictedCounter counts the entries evicted from the governance item cache
+var governanceCacheEvictedCounter = metrics.NewRegisteredCounter(governanceMetricPrefix+"cache/evicted", nil)
+
+// Metrics of applying governance changes by UpdateCurrentGovernance, which runs in the block processing path
+var (
+	governanceAppliedCounter = metrics.NewRegisteredCounter(governanceMetricPrefix+"apply/count", nil)
+	governanceApplyTimer     = metrics.NewRegisteredTimer(governanceMetricPrefix+"apply/time", nil)
+	governanceTriggerTimer   = metrics.NewRegisteredTimer(governanceMetricPrefix+"apply/trigger", nil)
+)
+
+// governanceMetrics publishes the applied governance items as metrics
+type governanceMetrics struct {
+	registry metrics.Registry
+	gauges   map[string]metrics.Gauge // gauges of numeric items by key
+	infos    map[string]metrics.Gauge // info gauges of the current values of non-numeric items by key
+	mu       sync.Mutex
+}
+
+// RegisterMetrics publishes the applied governance items as metrics in the given registry. If the registry is nil,
+// metrics.DefaultRegistry is used. Numeric items are published as gauges named after their keys (bool items are 1 or 0).
+// Non-numeric items like the governance mode and addresses are published as info gauges named after their keys
+// and values, which are 1 for the current values and 0 for the previous ones.
+// The metrics are updated whenever UpdateCurrentGovernance applies a change.
+func (gov *Governance) RegisterMetrics(r metrics.Registry) {
+	if r == nil {
+		r = metrics.DefaultRegistry
+	}
+	m := &governanceMetrics{
+		registry: r,
+		gauges:   make(map[string]metrics.Gauge),
+		infos:    make(map[string]metrics.Gauge),
+	}
+	m.update(gov.currentSet.Items())
+
+	gov.metricsLock.Lock()
+	gov.metrics = m
+	gov.metricsLock.Unlock()
+}
+
+// updateMetrics updates the governance metrics if they are registered
+func (gov *Governance) updateMetrics(items map[string]interface{}) {
+	gov.metricsLock.Lock()
+	m := gov.metrics
+	gov.metricsLock.Unlock()
+
+	if m != nil {
+		m.update(items)
+	}
+}
+
+func (m *governanceMetrics) update(items map[string]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for k, v := range items {
+		switch x := normalizeGovernanceItem(k, v).(type) {
+		case uint64:
+			m.gauge(k).Update(int64(x))
+		case bool:
+			if x {
+				m.gauge(k).Update(1)
+			} else {
+				m.gauge(k).Update(0)
+			}
+		default:
+			name := governanceMetricPrefix + k + "/" + metricNameSafe(FormatGovernanceValue(k, v))
+			if prev, ok := m.infos[k]; ok {
+				prev.Update(0)
+			}
+			info := metrics.GetOrRegisterGauge(name, m.registry)
+			info.Update(1)
+			m.infos[k] = info
+		}
+	}
+}
+
+func (m *governanceMetrics) gauge(key string) metrics.Gauge {
+	g, ok := m.gauges[key]
+	if !ok {
+		g = metrics.GetOrRegisterGauge(governanceMetricPrefix+key, m.registry)
+		m.gauges[key] = g
+	}
+	return g
+}
+
+// metricNameSafe replaces the characters which can't be used in a metric name with underscores
+func metricNameSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -246,8 +246,12 @@ func New(ctx *node.ServiceContext, config *Config) (*CN, error) {
 	config.TxPool.NoAccountCreation = config.NoAccountCreation
 	cn.txPool = blockchain.NewTxPool(config.TxPool, cn.chainConfig, cn.blockchain)
 	governance.SetTxPool(cn.txPool)
-	// Synchronize unitprice
-	cn.txPool.SetGasPrice(big.NewInt(0).SetUint64(governance.ChainConfig.UnitPrice))
+	// Synchronize unitprice with the currently applied governance
+	unitPrice, ok := governance.CurrentUnitPrice()
+	if !ok {
+		unitPrice = governance.ChainConfig.UnitPrice
+	}
+	cn.txPool.SetGasPrice(big.NewInt(0).SetUint64(unitPrice))
 
 	if cn.protocolManager, err = NewProtocolManager(cn.chainConfig, config.SyncMode, config.NetworkId, cn.eventMux, cn.txPool, cn.engine, cn.blockchain, chainDB, ctx.NodeType(), config); err != nil {
 		return nil, err
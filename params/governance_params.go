@@ -87,6 +87,15 @@ const (
 	ProposerRefreshInterval
 	ConstTxGasHumanReadable
 	CliqueEpoch
+	BlockGasLimit
+	TargetGasPerBlock
+	BaseFeeDenominator
+	KIRAddress
+	PoCAddress
+	ConstMaxTxGas
+	ForkSchedule
+	ConstHumanReadableAddress
+	AddressBookAddress
 )
 
 const (
@@ -117,6 +126,35 @@ const (
 	DefaultDefferedTxFee  = false
 	DefaultUnitPrice      = uint64(250000000000)
 	DefaultPeriod         = 1
+	DefaultBlockGasLimit  = UpperGasLimit
+
+	// Default values of the base fee parameters. The base fee can change by 1/DefaultBaseFeeDenominator per block.
+	// Like the block gas limit, they are only stored in the governance and nothing enforces them yet.
+	DefaultTargetGasPerBlock  = uint64(30000000)
+	DefaultBaseFeeDenominator = uint64(20)
+	MaxBaseFeeDenominator     = uint64(1000)
+
+	// Default addresses of KIR and PoC. A zero address means the addresses registered in the AddressBook are used.
+	// Governance can't change them back to a zero address once they are changed.
+	DefaultKIRAddress = "0x0000000000000000000000000000000000000000"
+	DefaultPoCAddress = "0x0000000000000000000000000000000000000000"
+
+	// Default address of the AddressBook. A zero address means the AddressBook deployed at the genesis is used.
+	// Governance can't change it back to a zero address once it is changed.
+	DefaultAddressBookAddress = "0x0000000000000000000000000000000000000000"
+
+	// The maximum staking amount of a node in KLAY counted for the reward. A larger amount is capped to it.
+	MaxStakingLimit = uint64(100000000000)
+
+	// Default value of the maximum amount of gas a transaction can use. A transaction can't use more than a value transfer uses.
+	DefaultMaxTxGas = UpperGasLimit
+	MinMaxTxGas     = TxGasValueTransfer
+
+	// Default schedule of the forks activated by governance. It is a list of "name:block" separated by commas.
+	DefaultForkSchedule = ""
+
+	// Default value of the human-readable address feature. It is disabled until it is enabled by governance.
+	DefaultHumanReadableAddress = false
 )
 
 func IsStakingUpdateInterval(blockNum uint64) bool {
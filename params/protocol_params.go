@@ -127,12 +127,17 @@ const (
 
 var (
 	TxGasHumanReadable     uint64 = 4000000000
+	MaxTxGas               uint64 = DefaultMaxTxGas    // The maximum amount of gas a transaction can use. It can be changed by governance
 	BlockScoreBoundDivisor        = big.NewInt(2048)   // The bound divisor of the blockscore, used in the update calculations.
 	GenesisBlockScore             = big.NewInt(131072) // BlockScore of the Genesis block.
 	MinimumBlockScore             = big.NewInt(131072) // The minimum that the blockscore may ever be.
 	DurationLimit                 = big.NewInt(13)     // The decision boundary on the blocktime duration used to determine whether blockscore should go up or not.
 )
 
+// HumanReadableAddress tells whether the human-readable address feature is enabled by governance.
+// It is informational only. It follows the last applied governance, so it must not be used to validate blocks.
+var HumanReadableAddress = DefaultHumanReadableAddress
+
 // Parameters for execution time limit
 var (
 	// TODO-Klaytn Determine more practical values through actual running experience
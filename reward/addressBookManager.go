@@ -48,6 +48,8 @@ type addressBookManager struct {
 	governanceHelper           governanceHelper
 	addressBookABI             string
 	addressBookContractAddress common.Address
+	stakingInfoFlight          *stakingInfoFlight
+	stakingAmountSource        StakingAmountSource
 }
 
 // create and return addressBookManager
@@ -57,11 +59,31 @@ func newAddressBookManager(bc *blockchain.BlockChain, governanceHelper governanc
 		governanceHelper:           governanceHelper,
 		addressBookABI:             contract.AddressBookABI,
 		addressBookContractAddress: common.HexToAddress(contract.AddressBookContractAddress),
+		stakingInfoFlight:          newStakingInfoFlight(),
+		stakingAmountSource:        BalanceStakingAmountSource{},
 	}
 }
 
+// setStakingAmountSource replaces the source of staking amounts. A nil source restores the balance-based one.
+func (abm *addressBookManager) setStakingAmountSource(source StakingAmountSource) {
+	if source == nil {
+		source = BalanceStakingAmountSource{}
+	}
+	abm.stakingAmountSource = source
+}
+
+// addressBookAddress returns the address of the addressBook contract at the given block.
+// The address changed by governance takes priority over the default one, so that a network can redeploy the contract.
+func (abm *addressBookManager) addressBookAddress(blockNum uint64) common.Address {
+	if abm.governanceHelper == nil {
+		return abm.addressBookContractAddress
+	}
+	return governedAddress(abm.governanceHelper, blockNum, params.AddressBookAddress, abm.addressBookContractAddress)
+}
+
 // make a message to the addressBook contract for executing getAllAddress function of the addressBook contract
-func (abm *addressBookManager) makeMsgToAddressBook() (*types.Transaction, error) {
+// which is used at the given block
+func (abm *addressBookManager) makeMsgToAddressBook(blockNum uint64) (*types.Transaction, error) {
 	abiInstance, err := abi.JSON(strings.NewReader(abm.addressBookABI))
 	if err != nil {
 		return nil, err
@@ -79,7 +101,8 @@ func (abm *addressBookManager) makeMsgToAddressBook() (*types.Transaction, error
 
 	// Create new call message
 	// TODO-Klaytn-Issue1166 Decide who will be sender(i.e. from)
-	msg := types.NewMessage(common.Address{}, &abm.addressBookContractAddress, 0, big.NewInt(0), 10000000, big.NewInt(0), data, false, intrinsicGas)
+	addressBook := abm.addressBookAddress(blockNum)
+	msg := types.NewMessage(common.Address{}, &addressBook, 0, big.NewInt(0), 10000000, big.NewInt(0), data, false, intrinsicGas)
 
 	return msg, nil
 }
@@ -157,13 +180,20 @@ func (abm *addressBookManager) parseAllAddresses(result []byte) (nodeIds []commo
 // If addressBook is not activated, emptyStakingInfo is returned.
 // After addressBook is activated, it returns stakingInfo with addresses and stakingAmount.
 // Otherwise, it returns an error.
+// Concurrent calls for the same block number share a single construction of stakingInfo.
 func (abm *addressBookManager) getStakingInfoFromAddressBook(blockNum uint64) (*StakingInfo, error) {
+	return abm.stakingInfoFlight.do(blockNum, func() (*StakingInfo, error) {
+		return abm.makeStakingInfoFromAddressBook(blockNum)
+	})
+}
+
+func (abm *addressBookManager) makeStakingInfoFromAddressBook(blockNum uint64) (*StakingInfo, error) {
 	if !params.IsStakingUpdateInterval(blockNum) {
 		return nil, errors.New(fmt.Sprintf("not staking block number. blockNum: %d", blockNum))
 	}
 
 	// Prepare a message
-	msg, err := abm.makeMsgToAddressBook()
+	msg, err := abm.makeMsgToAddressBook(blockNum)
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("failed to make message for AddressBook. root err: %s", err))
 	}
@@ -199,5 +229,5 @@ func (abm *addressBookManager) getStakingInfoFromAddressBook(blockNum uint64) (*
 		return newEmptyStakingInfo(blockNum), nil
 	}
 
-	return newStakingInfo(abm.bc, abm.governanceHelper, blockNum, nodeIds, stakingAddrs, rewardAddrs, KIRAddr, PoCAddr)
+	return newStakingInfoWithSource(abm.bc, abm.governanceHelper, abm.stakingAmountSource, blockNum, nodeIds, stakingAddrs, rewardAddrs, KIRAddr, PoCAddr)
 }
@@ -18,6 +18,7 @@ package reward
 
 import (
 	"github.com/klaytn/klaytn/blockchain"
+	"github.com/klaytn/klaytn/common"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -29,10 +30,30 @@ func newTestBlockChain() *blockchain.BlockChain {
 func TestAddressBookManager_makeMsgToAddressBook(t *testing.T) {
 	targetAddress := "0x0000000000000000000000000000000000000400" // address of addressBook which the message has to be sent to
 	addressBookManager := newAddressBookManager(newTestBlockChain(), nil)
-	msg, err := addressBookManager.makeMsgToAddressBook()
+	msg, err := addressBookManager.makeMsgToAddressBook(0)
 	if err != nil {
 		t.Errorf("err has occurred. err : %v", err)
 	} else {
 		assert.Equal(t, targetAddress, msg.To().String())
 	}
 }
+
+func TestAddressBookManager_addressBookAddress(t *testing.T) {
+	defaultAddress := common.HexToAddress("0x0000000000000000000000000000000000000400")
+	gov := newDefaultTestGovernance()
+	addressBookManager := newAddressBookManager(newTestBlockChain(), gov)
+
+	// The default address is used if governance has a zero address
+	assert.Equal(t, defaultAddress, addressBookManager.addressBookAddress(0))
+
+	// The address changed by governance is used
+	gov.addressBook = common.HexToAddress("0x0000000000000000000000000000000000000401")
+	assert.Equal(t, gov.addressBook, addressBookManager.addressBookAddress(0))
+	msg, err := addressBookManager.makeMsgToAddressBook(0)
+	assert.NoError(t, err)
+	assert.Equal(t, gov.addressBook, *msg.To())
+
+	// The default address is used if governance is not available at the block
+	gov.initializedAt = 1
+	assert.Equal(t, defaultAddress, addressBookManager.addressBookAddress(0))
+}
@@ -18,6 +18,7 @@ package reward
 
 import (
 	"errors"
+	"github.com/klaytn/klaytn/common"
 	"github.com/klaytn/klaytn/params"
 	"github.com/stretchr/testify/assert"
 	"math/big"
@@ -31,6 +32,10 @@ type testGovernance struct {
 	unitPrice     uint64
 	useGiniCoeff  bool
 	deferredTxFee bool
+	addressBook   common.Address
+
+	// governance items are not available before this block
+	initializedAt uint64
 }
 
 func newDefaultTestGovernance() *testGovernance {
@@ -60,6 +65,9 @@ func (governance *testGovernance) Epoch() uint64 {
 }
 
 func (governance *testGovernance) GetItemAtNumberByIntKey(num uint64, key int) (interface{}, error) {
+	if num < governance.initializedAt {
+		return nil, errors.New("Governance is not initialized on testGovernance")
+	}
 	switch key {
 	case params.MintingAmount:
 		return governance.mintingAmount, nil
@@ -69,6 +77,10 @@ func (governance *testGovernance) GetItemAtNumberByIntKey(num uint64, key int) (
 		return governance.unitPrice, nil
 	case params.Epoch:
 		return governance.epoch, nil
+	case params.UseGiniCoeff:
+		return governance.useGiniCoeff, nil
+	case params.AddressBookAddress:
+		return governance.addressBook, nil
 	default:
 		return nil, errors.New("Unhandled key on testGovernance")
 	}
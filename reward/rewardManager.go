@@ -19,6 +19,7 @@ package reward
 import (
 	"github.com/klaytn/klaytn/common"
 	"github.com/klaytn/klaytn/log"
+	"reflect"
 )
 
 var logger = log.NewModuleLogger(log.Reward)
@@ -29,6 +30,16 @@ type governanceHelper interface {
 	DeferredTxFee() bool
 }
 
+// getItemAtNumberByIntKeyWithDefault returns the governance item of the key at num. It returns def if the item
+// isn't available at num (e.g., governance isn't initialized at early blocks) or its type is different from the type of def.
+func getItemAtNumberByIntKeyWithDefault(helper governanceHelper, num uint64, key int, def interface{}) interface{} {
+	res, err := helper.GetItemAtNumberByIntKey(num, key)
+	if err != nil || reflect.TypeOf(res) != reflect.TypeOf(def) {
+		return def
+	}
+	return res
+}
+
 func isEmptyAddress(addr common.Address) bool {
 	return addr == common.Address{}
 }
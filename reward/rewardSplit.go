@@ -0,0 +1,158 @@
+// Copyright 2019 The klaytn Authors
+// This file is part of the klaytn library.
+//
+// The klaytn library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The klaytn library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the klaytn library. If not, see <http://www.gnu.org/licenses/>.
+
+package reward
+
+import (
+	"errors"
+	"github.com/klaytn/klaytn/common"
+	"math"
+	"math/big"
+)
+
+var (
+	errInvalidRewardAmount = errors.New("reward amount should not be negative")
+	errInvalidRatio        = errors.New("ratio should not be negative and its sum should be positive")
+	errNoStakingInfo       = errors.New("staking info is not given")
+	errNoStakingWeight     = errors.New("no council node has a staking amount")
+	errNoKIRAddress        = errors.New("KIR share of the ratio is positive, but KIR address is empty")
+	errNoPoCAddress        = errors.New("PoC share of the ratio is positive, but PoC address is empty")
+)
+
+// SplitReward splits the total reward into the shares of CN, KIR and PoC according to the given ratio "cn/poc/kir".
+// Each share is rounded down and the remainder of the rounding is given to CN,
+// so the sum of the shares is always equal to the total.
+func SplitReward(total *big.Int, ratio string) (cn, kir, poc *big.Int, err error) {
+	if total == nil || total.Sign() < 0 {
+		return nil, nil, nil, errInvalidRewardAmount
+	}
+	cnRatio, pocRatio, kirRatio, err := (&rewardConfigCache{}).parseRewardRatio(ratio)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if cnRatio < 0 || pocRatio < 0 || kirRatio < 0 || cnRatio+pocRatio+kirRatio == 0 {
+		return nil, nil, nil, errInvalidRatio
+	}
+	totalRatio := big.NewInt(int64(cnRatio + pocRatio + kirRatio))
+
+	kir = new(big.Int).Mul(total, big.NewInt(int64(kirRatio)))
+	kir.Div(kir, totalRatio)
+	poc = new(big.Int).Mul(total, big.NewInt(int64(pocRatio)))
+	poc.Div(poc, totalRatio)
+
+	cn = new(big.Int).Sub(total, kir)
+	cn.Sub(cn, poc)
+
+	return cn, kir, poc, nil
+}
+
+// PreviewRewardDistribution returns the amounts of a block reward of minting which each reward address would get
+// under the given ratio "cn/poc/kir", without producing a block. The shares of KIR and PoC go to the KIR and PoC
+// addresses of the stakingInfo. Since the CN share of a block goes to its proposer, the CN share is divided into
+// the expected amounts of council nodes in proportion to their proposer weights: staking amounts, or staking amounts
+// to the power of 1/(1+gini) if useGini is true, rounded as the weighted council does. The remainder of the division
+// goes to the node having the largest weight, so the sum of the amounts is always equal to the minting.
+func PreviewRewardDistribution(stakingInfo *StakingInfo, minting *big.Int, ratio string, useGini bool) (map[common.Address]*big.Int, error) {
+	if stakingInfo == nil {
+		return nil, errNoStakingInfo
+	}
+	numNodes := len(stakingInfo.CouncilNodeAddrs)
+	if len(stakingInfo.CouncilRewardAddrs) != numNodes || len(stakingInfo.CouncilStakingAmounts) != numNodes {
+		return nil, errAddressBookIncomplete
+	}
+	cn, kir, poc, err := SplitReward(minting, ratio)
+	if err != nil {
+		return nil, err
+	}
+
+	gini := stakingInfo.Gini
+	if useGini && gini == DefaultGiniCoefficient {
+		amounts := make(uint64Slice, numNodes)
+		copy(amounts, stakingInfo.CouncilStakingAmounts)
+		gini = CalcGiniCoefficient(amounts)
+	}
+
+	weights := make([]*big.Int, numNodes)
+	totalWeight := new(big.Int)
+	largest := 0
+	for i, amount := range stakingInfo.CouncilStakingAmounts {
+		weight := float64(amount)
+		if useGini && gini != DefaultGiniCoefficient {
+			weight = math.Round(math.Pow(weight, 1.0/(1+gini)))
+		}
+		weights[i] = new(big.Int).SetUint64(uint64(weight))
+		totalWeight.Add(totalWeight, weights[i])
+		if weights[i].Cmp(weights[largest]) > 0 {
+			largest = i
+		}
+	}
+	if totalWeight.Sign() == 0 && cn.Sign() > 0 {
+		return nil, errNoStakingWeight
+	}
+
+	ret := make(map[common.Address]*big.Int)
+	add := func(addr common.Address, amount *big.Int) {
+		if amount.Sign() == 0 {
+			return
+		}
+		if _, ok := ret[addr]; !ok {
+			ret[addr] = new(big.Int)
+		}
+		ret[addr].Add(ret[addr], amount)
+	}
+	add(stakingInfo.KIRAddr, kir)
+	add(stakingInfo.PoCAddr, poc)
+
+	dust := new(big.Int).Set(cn)
+	shares := make([]*big.Int, numNodes)
+	for i, weight := range weights {
+		shares[i] = new(big.Int)
+		if totalWeight.Sign() > 0 {
+			shares[i].Mul(cn, weight)
+			shares[i].Div(shares[i], totalWeight)
+		}
+		dust.Sub(dust, shares[i])
+	}
+	if numNodes > 0 {
+		shares[largest].Add(shares[largest], dust)
+	}
+	for i, share := range shares {
+		add(stakingInfo.CouncilRewardAddrs[i], share)
+	}
+	return ret, nil
+}
+
+// CheckRewardDestinations cross-checks the ratio "cn/poc/kir" of governance with the KIR and PoC addresses
+// of the stakingInfo, which can come from either the AddressBook or governance. It returns an error for each
+// positive share of KIR or PoC whose address is empty, since the share would be burned.
+func CheckRewardDestinations(stakingInfo *StakingInfo, ratio string) []error {
+	if stakingInfo == nil {
+		return []error{errNoStakingInfo}
+	}
+	_, pocRatio, kirRatio, err := (&rewardConfigCache{}).parseRewardRatio(ratio)
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	if kirRatio > 0 && isEmptyAddress(stakingInfo.KIRAddr) {
+		errs = append(errs, errNoKIRAddress)
+	}
+	if pocRatio > 0 && isEmptyAddress(stakingInfo.PoCAddr) {
+		errs = append(errs, errNoPoCAddress)
+	}
+	return errs
+}
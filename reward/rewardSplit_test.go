@@ -0,0 +1,178 @@
+// Copyright 2019 The klaytn Authors
+// This file is part of the klaytn library.
+//
+// The klaytn library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The klaytn library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the klaytn library. If not, see <http://www.gnu.org/licenses/>.
+
+package reward
+
+import (
+	"github.com/klaytn/klaytn/common"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestSplitReward(t *testing.T) {
+	testCases := []struct {
+		total *big.Int
+		ratio string
+		cn    *big.Int
+		kir   *big.Int
+		poc   *big.Int
+	}{
+		{big.NewInt(100), "34/54/12", big.NewInt(34), big.NewInt(12), big.NewInt(54)},
+		{big.NewInt(100), "100/0/0", big.NewInt(100), big.NewInt(0), big.NewInt(0)},
+		{big.NewInt(0), "34/54/12", big.NewInt(0), big.NewInt(0), big.NewInt(0)},
+		// remainders go to CN
+		{big.NewInt(10), "1/1/1", big.NewInt(4), big.NewInt(3), big.NewInt(3)},
+		{big.NewInt(99), "34/54/12", big.NewInt(35), big.NewInt(11), big.NewInt(53)},
+		{big.NewInt(1), "0/50/50", big.NewInt(1), big.NewInt(0), big.NewInt(0)},
+	}
+
+	for _, tc := range testCases {
+		cn, kir, poc, err := SplitReward(tc.total, tc.ratio)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.cn.String(), cn.String(), "total: %v, ratio: %v", tc.total, tc.ratio)
+		assert.Equal(t, tc.kir.String(), kir.String(), "total: %v, ratio: %v", tc.total, tc.ratio)
+		assert.Equal(t, tc.poc.String(), poc.String(), "total: %v, ratio: %v", tc.total, tc.ratio)
+	}
+}
+
+// the sum of split rewards should be exactly same with the total
+func TestSplitReward_Sum(t *testing.T) {
+	ratios := []string{"34/54/12", "1/1/1", "33/33/34", "7/0/3", "0/1/2"}
+	totals := []*big.Int{
+		big.NewInt(1),
+		big.NewInt(7),
+		big.NewInt(1000000007),
+		new(big.Int).SetUint64(9600000000000000001),
+		new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil),
+	}
+
+	for _, ratio := range ratios {
+		for _, total := range totals {
+			cn, kir, poc, err := SplitReward(total, ratio)
+			assert.NoError(t, err)
+			sum := new(big.Int).Add(cn, kir)
+			sum.Add(sum, poc)
+			assert.Equal(t, 0, sum.Cmp(total), "total: %v, ratio: %v", total, ratio)
+			assert.True(t, cn.Sign() >= 0 && kir.Sign() >= 0 && poc.Sign() >= 0)
+		}
+	}
+}
+
+func TestSplitReward_Error(t *testing.T) {
+	testCases := []struct {
+		total *big.Int
+		ratio string
+		err   error
+	}{
+		{big.NewInt(-1), "34/54/12", errInvalidRewardAmount},
+		{nil, "34/54/12", errInvalidRewardAmount},
+		{big.NewInt(100), "34/54", errInvalidFormat},
+		{big.NewInt(100), "34/a/12", errParsingRatio},
+		{big.NewInt(100), "0/0/0", errInvalidRatio},
+		{big.NewInt(100), "-10/60/50", errInvalidRatio},
+	}
+
+	for _, tc := range testCases {
+		_, _, _, err := SplitReward(tc.total, tc.ratio)
+		assert.Equal(t, tc.err, err, "total: %v, ratio: %v", tc.total, tc.ratio)
+	}
+}
+
+func TestPreviewRewardDistribution(t *testing.T) {
+	rewardAddrs := []common.Address{common.HexToAddress("0x21"), common.HexToAddress("0x22")}
+	stakingInfo := newEmptyStakingInfo(0)
+	stakingInfo.CouncilNodeAddrs = []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2")}
+	stakingInfo.CouncilStakingAddrs = []common.Address{common.HexToAddress("0x11"), common.HexToAddress("0x12")}
+	stakingInfo.CouncilRewardAddrs = rewardAddrs
+	stakingInfo.CouncilStakingAmounts = []uint64{100, 400}
+	stakingInfo.KIRAddr = common.HexToAddress("0x31")
+	stakingInfo.PoCAddr = common.HexToAddress("0x32")
+
+	testCases := []struct {
+		ratio    string
+		useGini  bool
+		expected map[common.Address]int64
+	}{
+		// CN share in proportion to staking amounts
+		{"100/0/0", false, map[common.Address]int64{rewardAddrs[0]: 200, rewardAddrs[1]: 800}},
+		// gini is 0.3, so the weights are 35 and 100. The remainder goes to the largest weight
+		{"100/0/0", true, map[common.Address]int64{rewardAddrs[0]: 259, rewardAddrs[1]: 741}},
+		// KIR and PoC shares go to their addresses
+		{"34/54/12", false, map[common.Address]int64{rewardAddrs[0]: 68, rewardAddrs[1]: 272, stakingInfo.PoCAddr: 540, stakingInfo.KIRAddr: 120}},
+		{"34/54/12", true, map[common.Address]int64{rewardAddrs[0]: 88, rewardAddrs[1]: 252, stakingInfo.PoCAddr: 540, stakingInfo.KIRAddr: 120}},
+	}
+	for _, tc := range testCases {
+		distribution, err := PreviewRewardDistribution(stakingInfo, big.NewInt(1000), tc.ratio, tc.useGini)
+		assert.NoError(t, err)
+		assert.Equal(t, len(tc.expected), len(distribution), "ratio: %v, useGini: %v", tc.ratio, tc.useGini)
+		for addr, amount := range tc.expected {
+			assert.Equal(t, amount, distribution[addr].Int64(), "ratio: %v, useGini: %v, addr: %v", tc.ratio, tc.useGini, addr.String())
+		}
+	}
+
+	// gini makes the distribution more even than staking amounts
+	withoutGini, _ := PreviewRewardDistribution(stakingInfo, big.NewInt(1000000), "100/0/0", false)
+	withGini, _ := PreviewRewardDistribution(stakingInfo, big.NewInt(1000000), "100/0/0", true)
+	assert.True(t, withGini[rewardAddrs[0]].Cmp(withoutGini[rewardAddrs[0]]) > 0)
+	assert.True(t, withGini[rewardAddrs[1]].Cmp(withoutGini[rewardAddrs[1]]) < 0)
+}
+
+func TestPreviewRewardDistribution_Error(t *testing.T) {
+	_, err := PreviewRewardDistribution(nil, big.NewInt(1000), "34/54/12", false)
+	assert.Equal(t, errNoStakingInfo, err)
+
+	stakingInfo := newEmptyStakingInfo(0)
+	_, err = PreviewRewardDistribution(stakingInfo, big.NewInt(1000), "34/54/12", false)
+	assert.Equal(t, errNoStakingWeight, err)
+
+	stakingInfo.CouncilNodeAddrs = []common.Address{common.HexToAddress("0x1")}
+	_, err = PreviewRewardDistribution(stakingInfo, big.NewInt(1000), "34/54/12", false)
+	assert.Equal(t, errAddressBookIncomplete, err)
+
+	stakingInfo.CouncilRewardAddrs = []common.Address{common.HexToAddress("0x21")}
+	stakingInfo.CouncilStakingAmounts = []uint64{100}
+	_, err = PreviewRewardDistribution(stakingInfo, big.NewInt(-1), "34/54/12", false)
+	assert.Equal(t, errInvalidRewardAmount, err)
+}
+
+func TestCheckRewardDestinations(t *testing.T) {
+	kir, poc := common.HexToAddress("0x1"), common.HexToAddress("0x2")
+	testCases := []struct {
+		ratio    string
+		kirAddr  common.Address
+		pocAddr  common.Address
+		expected []error
+	}{
+		{"34/54/12", kir, poc, nil},
+		{"100/0/0", common.Address{}, common.Address{}, nil},
+		{"34/54/12", kir, common.Address{}, []error{errNoPoCAddress}},
+		{"34/54/12", common.Address{}, poc, []error{errNoKIRAddress}},
+		{"34/54/12", common.Address{}, common.Address{}, []error{errNoKIRAddress, errNoPoCAddress}},
+		// Empty addresses of zero shares are fine
+		{"50/50/0", common.Address{}, poc, nil},
+		{"50/0/50", kir, common.Address{}, nil},
+	}
+
+	for _, tc := range testCases {
+		stakingInfo := newEmptyStakingInfo(1)
+		stakingInfo.KIRAddr, stakingInfo.PoCAddr = tc.kirAddr, tc.pocAddr
+		assert.Equal(t, tc.expected, CheckRewardDestinations(stakingInfo, tc.ratio), "ratio: %v", tc.ratio)
+	}
+
+	assert.Equal(t, []error{errNoStakingInfo}, CheckRewardDestinations(nil, "34/54/12"))
+	assert.Len(t, CheckRewardDestinations(newEmptyStakingInfo(1), "34/54"), 1)
+}
@@ -17,26 +17,32 @@
 package reward
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"github.com/klaytn/klaytn/blockchain"
+	"github.com/klaytn/klaytn/blockchain/state"
+	"github.com/klaytn/klaytn/blockchain/types"
 	"github.com/klaytn/klaytn/common"
 	"github.com/klaytn/klaytn/params"
 	"math"
 	"math/big"
 	"sort"
+	"sync"
 )
 
 const (
 	AddrNotFoundInCouncilNodes = -1
-	maxStakingLimit            = uint64(100000000000)
+	maxStakingLimit            = params.MaxStakingLimit
 	DefaultGiniCoefficient     = -1.0
 )
 
 var (
 	maxStakingLimitBigInt = big.NewInt(0).SetUint64(maxStakingLimit)
 
-	ErrAddrNotInStakingInfo = errors.New("Address is not in stakingInfo")
+	ErrAddrNotInStakingInfo  = errors.New("Address is not in stakingInfo")
+	ErrPebAmountNotAvailable = errors.New("Staking amount in peb is not available")
+	ErrInvalidMinimumStake   = errors.New("Minimum stake should be a non-negative amount")
 )
 
 // StakingInfo contains staking information.
@@ -54,25 +60,127 @@ type StakingInfo struct {
 	Gini    float64 // gini coefficient
 
 	// Derived from CouncilStakingAddrs
-	CouncilStakingAmounts []uint64 // Staking amounts of Council
+	CouncilStakingAmounts    []uint64   // Staking amounts of Council
+	CouncilStakingAmountsPeb []*big.Int // Staking balances of Council in peb. Not rounded nor limited
 }
 
 func newEmptyStakingInfo(blockNum uint64) *StakingInfo {
 	stakingInfo := &StakingInfo{
-		BlockNum:              blockNum,
-		CouncilNodeAddrs:      make([]common.Address, 0, 0),
-		CouncilStakingAddrs:   make([]common.Address, 0, 0),
-		CouncilRewardAddrs:    make([]common.Address, 0, 0),
-		KIRAddr:               common.Address{},
-		PoCAddr:               common.Address{},
-		CouncilStakingAmounts: make([]uint64, 0, 0),
-		Gini:                  DefaultGiniCoefficient,
-		UseGini:               false,
+		BlockNum:                 blockNum,
+		CouncilNodeAddrs:         make([]common.Address, 0, 0),
+		CouncilStakingAddrs:      make([]common.Address, 0, 0),
+		CouncilRewardAddrs:       make([]common.Address, 0, 0),
+		KIRAddr:                  common.Address{},
+		PoCAddr:                  common.Address{},
+		CouncilStakingAmounts:    make([]uint64, 0, 0),
+		CouncilStakingAmountsPeb: make([]*big.Int, 0, 0),
+		Gini:                     DefaultGiniCoefficient,
+		UseGini:                  false,
 	}
 	return stakingInfo
 }
 
+// governedAddress returns the address of the given governance key at blockNum.
+// If the item is not available or is a zero address, defaultAddr is returned.
+func governedAddress(helper governanceHelper, blockNum uint64, key int, defaultAddr common.Address) common.Address {
+	res, err := helper.GetItemAtNumberByIntKey(blockNum, key)
+	if err != nil {
+		return defaultAddr
+	}
+	if addr, ok := res.(common.Address); ok && addr != (common.Address{}) {
+		return addr
+	}
+	return defaultAddr
+}
+
+// StakingAmountSource provides the staking amount of a staking address in peb at the block of the given header.
+// The balance of a staking address is used by default, but a source calling a view method of newer AddressBook
+// contracts can be used instead.
+type StakingAmountSource interface {
+	StakingAmount(header *types.Header, statedb *state.StateDB, stakingAddr common.Address) (*big.Int, error)
+}
+
+// BalanceStakingAmountSource uses the balance of a staking address as its staking amount.
+type BalanceStakingAmountSource struct{}
+
+func (BalanceStakingAmountSource) StakingAmount(header *types.Header, statedb *state.StateDB, stakingAddr common.Address) (*big.Int, error) {
+	return statedb.GetBalance(stakingAddr), nil
+}
+
+// stakingAmountWorkers is the maximum number of goroutines reading staking amounts of a stakingInfo concurrently
+const stakingAmountWorkers = 8
+
+// readStakingAmounts reads the staking amounts of stakingAddrs from the source with at most the given number of
+// workers, and returns them in the order of stakingAddrs. State is only read, but a StateDB caches state objects
+// without locks, so the first worker reads from statedb and the others read from their own StateDBs made by newState.
+// Reading is aborted at the first error, which is returned.
+func readStakingAmounts(header *types.Header, statedb *state.StateDB, newState func() (*state.StateDB, error), source StakingAmountSource, stakingAddrs []common.Address, workers int) ([]*big.Int, error) {
+	amounts := make([]*big.Int, len(stakingAddrs))
+	if workers > len(stakingAddrs) {
+		workers = len(stakingAddrs)
+	}
+
+	var (
+		jobs     = make(chan int)
+		abort    = make(chan struct{})
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		failOnce.Do(func() {
+			firstErr = err
+			close(abort)
+		})
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			s := statedb
+			if w > 0 {
+				var err error
+				if s, err = newState(); err != nil {
+					fail(err)
+					return
+				}
+			}
+			for i := range jobs {
+				amount, err := source.StakingAmount(header, s, stakingAddrs[i])
+				if err != nil {
+					logger.Trace("Failed to get the staking amount", "blockNum", header.Number, "stakingAddr", stakingAddrs[i], "err", err)
+					fail(err)
+					return
+				}
+				amounts[i] = amount
+			}
+		}(w)
+	}
+
+dispatch:
+	for i := range stakingAddrs {
+		select {
+		case jobs <- i:
+		case <-abort:
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return amounts, nil
+}
+
 func newStakingInfo(bc *blockchain.BlockChain, helper governanceHelper, blockNum uint64, nodeIds []common.Address, stakingAddrs []common.Address, rewardAddrs []common.Address, KIRAddr common.Address, PoCAddr common.Address) (*StakingInfo, error) {
+	return newStakingInfoWithSource(bc, helper, BalanceStakingAmountSource{}, blockNum, nodeIds, stakingAddrs, rewardAddrs, KIRAddr, PoCAddr)
+}
+
+// newStakingInfoWithSource is the same as newStakingInfo, but the staking amounts are given by the source.
+func newStakingInfoWithSource(bc *blockchain.BlockChain, helper governanceHelper, source StakingAmountSource, blockNum uint64, nodeIds []common.Address, stakingAddrs []common.Address, rewardAddrs []common.Address, KIRAddr common.Address, PoCAddr common.Address) (*StakingInfo, error) {
 	intervalBlock := bc.GetBlockByNumber(blockNum)
 	if intervalBlock == nil {
 		logger.Trace("Failed to get the block by the given number", "blockNum", blockNum)
@@ -84,35 +192,55 @@ func newStakingInfo(bc *blockchain.BlockChain, helper governanceHelper, blockNum
 		return nil, err
 	}
 
-	// Get balance of stakingAddrs
+	// Get staking amounts of stakingAddrs
+	newState := func() (*state.StateDB, error) { return bc.StateAt(intervalBlock.Root()) }
+	amounts, err := readStakingAmounts(intervalBlock.Header(), statedb, newState, source, stakingAddrs, stakingAmountWorkers)
+	if err != nil {
+		logger.Trace("Failed to get the staking amounts", "blockNum", blockNum, "err", err)
+		return nil, err
+	}
 	stakingAmounts := make([]uint64, len(stakingAddrs))
-	for i, stakingAddr := range stakingAddrs {
-		tempStakingAmount := big.NewInt(0).Div(statedb.GetBalance(stakingAddr), big.NewInt(0).SetUint64(params.KLAY))
+	stakingAmountsPeb := make([]*big.Int, len(stakingAddrs))
+	for i, amount := range amounts {
+		if amount == nil || amount.Sign() < 0 {
+			amount = big.NewInt(0)
+		}
+		stakingAmountsPeb[i] = amount
+		tempStakingAmount := big.NewInt(0).Div(stakingAmountsPeb[i], big.NewInt(0).SetUint64(params.KLAY))
 		if tempStakingAmount.Cmp(maxStakingLimitBigInt) > 0 {
 			tempStakingAmount.SetUint64(maxStakingLimit)
 		}
 		stakingAmounts[i] = tempStakingAmount.Uint64()
 	}
 
-	var useGini bool
-	if res, err := helper.GetItemAtNumberByIntKey(blockNum, params.UseGiniCoeff); err != nil {
-		logger.Trace("Failed to get useGiniCoeff from governance", "blockNum", blockNum, "err", err)
-		return nil, err
-	} else {
-		useGini = res.(bool)
-	}
+	useGini, _ := getItemAtNumberByIntKeyWithDefault(helper, blockNum, params.UseGiniCoeff, params.DefaultUseGiniCoeff).(bool)
 	gini := DefaultGiniCoefficient
+	if useGini {
+		// Council nodes without stakes (e.g., newly added but not funded yet) are excluded,
+		// since they would make the distribution of stakes look more unequal than it is.
+		gini = CalcGiniCoefficientExcludingZero(stakingAmounts)
+	}
+
+	// KIR and PoC addresses changed by governance take priority over the ones in the AddressBook
+	KIRAddr = governedAddress(helper, blockNum, params.KIRAddress, KIRAddr)
+	PoCAddr = governedAddress(helper, blockNum, params.PoCAddress, PoCAddr)
 
 	stakingInfo := &StakingInfo{
-		BlockNum:              blockNum,
-		CouncilNodeAddrs:      nodeIds,
-		CouncilStakingAddrs:   stakingAddrs,
-		CouncilRewardAddrs:    rewardAddrs,
-		KIRAddr:               KIRAddr,
-		PoCAddr:               PoCAddr,
-		CouncilStakingAmounts: stakingAmounts,
-		Gini:                  gini,
-		UseGini:               useGini,
+		BlockNum:                 blockNum,
+		CouncilNodeAddrs:         nodeIds,
+		CouncilStakingAddrs:      stakingAddrs,
+		CouncilRewardAddrs:       rewardAddrs,
+		KIRAddr:                  KIRAddr,
+		PoCAddr:                  PoCAddr,
+		CouncilStakingAmounts:    stakingAmounts,
+		CouncilStakingAmountsPeb: stakingAmountsPeb,
+		Gini:                     gini,
+		UseGini:                  useGini,
+	}
+
+	ratio, _ := getItemAtNumberByIntKeyWithDefault(helper, blockNum, params.Ratio, params.DefaultRatio).(string)
+	for _, err := range CheckRewardDestinations(stakingInfo, ratio) {
+		logger.Warn("Reward would be burned", "blockNum", blockNum, "ratio", ratio, "err", err)
 	}
 	return stakingInfo, nil
 }
@@ -126,6 +254,31 @@ func (s *StakingInfo) GetIndexByNodeId(nodeId common.Address) (int, error) {
 	return AddrNotFoundInCouncilNodes, ErrAddrNotInStakingInfo
 }
 
+// GetNodeByStakingAddr returns the council node id which has the given staking address.
+// If the staking address is registered by multiple nodes, the first one is returned. Use GetNodesByStakingAddr to get all of them.
+func (s *StakingInfo) GetNodeByStakingAddr(addr common.Address) (common.Address, error) {
+	for i, stakingAddr := range s.CouncilStakingAddrs {
+		if stakingAddr == addr && i < len(s.CouncilNodeAddrs) {
+			return s.CouncilNodeAddrs[i], nil
+		}
+	}
+	return common.Address{}, ErrAddrNotInStakingInfo
+}
+
+// GetNodesByStakingAddr returns all the council node ids which have the given staking address in the order of stakingInfo.
+func (s *StakingInfo) GetNodesByStakingAddr(addr common.Address) ([]common.Address, error) {
+	var nodes []common.Address
+	for i, stakingAddr := range s.CouncilStakingAddrs {
+		if stakingAddr == addr && i < len(s.CouncilNodeAddrs) {
+			nodes = append(nodes, s.CouncilNodeAddrs[i])
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, ErrAddrNotInStakingInfo
+	}
+	return nodes, nil
+}
+
 func (s *StakingInfo) GetStakingAmountByNodeId(nodeId common.Address) (uint64, error) {
 	i, err := s.GetIndexByNodeId(nodeId)
 	if err != nil {
@@ -134,6 +287,142 @@ func (s *StakingInfo) GetStakingAmountByNodeId(nodeId common.Address) (uint64, e
 	return s.CouncilStakingAmounts[i], nil
 }
 
+// StakingAmountPeb returns the exact staking balance of the given node in peb.
+// Unlike GetStakingAmountByNodeId, the amount is neither rounded to KLAY nor limited.
+func (s *StakingInfo) StakingAmountPeb(nodeId common.Address) (*big.Int, error) {
+	i, err := s.GetIndexByNodeId(nodeId)
+	if err != nil {
+		return nil, err
+	}
+	if i >= len(s.CouncilStakingAmountsPeb) || s.CouncilStakingAmountsPeb[i] == nil {
+		return nil, ErrPebAmountNotAvailable
+	}
+	return new(big.Int).Set(s.CouncilStakingAmountsPeb[i]), nil
+}
+
+// MeetsMinimumStake returns whether the staking balance of the given node is at least minStakePeb.
+// The exact balance in peb is compared, so a balance less than the minimum by less than 1 KLAY doesn't meet it.
+func (s *StakingInfo) MeetsMinimumStake(nodeId common.Address, minStakePeb *big.Int) (bool, error) {
+	if minStakePeb == nil || minStakePeb.Sign() < 0 {
+		return false, ErrInvalidMinimumStake
+	}
+	i, err := s.GetIndexByNodeId(nodeId)
+	if err != nil {
+		return false, err
+	}
+	if i >= len(s.CouncilStakingAmountsPeb) || s.CouncilStakingAmountsPeb[i] == nil {
+		return false, ErrPebAmountNotAvailable
+	}
+	return s.CouncilStakingAmountsPeb[i].Cmp(minStakePeb) >= 0, nil
+}
+
+// GetStakingInfoAge returns how many blocks the stakingInfo is old at the given block number
+// and whether the age exceeds the staking update interval.
+// If the given block number is smaller than the block number of the stakingInfo, the age is 0.
+func (s *StakingInfo) GetStakingInfoAge(currentBlockNum uint64) (uint64, bool) {
+	if currentBlockNum <= s.BlockNum {
+		return 0, false
+	}
+	age := currentBlockNum - s.BlockNum
+	return age, age > params.StakingUpdateInterval()
+}
+
+// SortedByNodeAddr returns a copy of the stakingInfo whose council information is ordered by node address.
+// Parallel slices (node, staking, reward addresses and staking amounts) are reordered together,
+// so that every node derives the same order regardless of the order in AddressBook.
+func (s *StakingInfo) SortedByNodeAddr() *StakingInfo {
+	indices := make([]int, len(s.CouncilNodeAddrs))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return bytes.Compare(s.CouncilNodeAddrs[indices[i]].Bytes(), s.CouncilNodeAddrs[indices[j]].Bytes()) < 0
+	})
+
+	sorted := *s
+	sorted.CouncilNodeAddrs = make([]common.Address, len(indices))
+	sorted.CouncilStakingAddrs = make([]common.Address, len(indices))
+	sorted.CouncilRewardAddrs = make([]common.Address, len(indices))
+	sorted.CouncilStakingAmounts = make([]uint64, len(indices))
+	// peb amounts are sorted only if they are available
+	hasPeb := len(s.CouncilStakingAmountsPeb) == len(indices)
+	if hasPeb {
+		sorted.CouncilStakingAmountsPeb = make([]*big.Int, len(indices))
+	}
+	for i, idx := range indices {
+		sorted.CouncilNodeAddrs[i] = s.CouncilNodeAddrs[idx]
+		sorted.CouncilStakingAddrs[i] = s.CouncilStakingAddrs[idx]
+		sorted.CouncilRewardAddrs[i] = s.CouncilRewardAddrs[idx]
+		sorted.CouncilStakingAmounts[i] = s.CouncilStakingAmounts[idx]
+		if hasPeb {
+			sorted.CouncilStakingAmountsPeb[i] = s.CouncilStakingAmountsPeb[idx]
+		}
+	}
+	return &sorted
+}
+
+// StakeChange is a change of the staking amount of a council node.
+type StakeChange struct {
+	Prev    uint64
+	Current uint64
+}
+
+// StakingDiff is the difference between two stakingInfos.
+type StakingDiff struct {
+	Added        []common.Address               // Council nodes only in the current stakingInfo
+	Removed      []common.Address               // Council nodes only in the previous stakingInfo
+	StakeChanges map[common.Address]StakeChange // Council nodes in both whose staking amounts are changed
+}
+
+// stakingAmountsByNode returns the staking amounts summed up by node and the node addresses in their first order.
+func (s *StakingInfo) stakingAmountsByNode() (map[common.Address]uint64, []common.Address) {
+	amounts := make(map[common.Address]uint64, len(s.CouncilNodeAddrs))
+	nodes := make([]common.Address, 0, len(s.CouncilNodeAddrs))
+	for i, node := range s.CouncilNodeAddrs {
+		if _, ok := amounts[node]; !ok {
+			nodes = append(nodes, node)
+		}
+		amount := uint64(0)
+		if i < len(s.CouncilStakingAmounts) {
+			amount = s.CouncilStakingAmounts[i]
+		}
+		amounts[node] += amount
+	}
+	return amounts, nodes
+}
+
+// Diff returns the difference from the previous stakingInfo to the stakingInfo.
+// If prev is nil, all council nodes are regarded as added.
+// Staking amounts of a node having multiple staking addresses are summed up.
+func (s *StakingInfo) Diff(prev *StakingInfo) StakingDiff {
+	diff := StakingDiff{
+		Added:        []common.Address{},
+		Removed:      []common.Address{},
+		StakeChanges: make(map[common.Address]StakeChange),
+	}
+	if prev == nil {
+		prev = newEmptyStakingInfo(0)
+	}
+
+	currAmounts, currNodes := s.stakingAmountsByNode()
+	prevAmounts, prevNodes := prev.stakingAmountsByNode()
+
+	for _, node := range currNodes {
+		prevAmount, ok := prevAmounts[node]
+		if !ok {
+			diff.Added = append(diff.Added, node)
+		} else if prevAmount != currAmounts[node] {
+			diff.StakeChanges[node] = StakeChange{Prev: prevAmount, Current: currAmounts[node]}
+		}
+	}
+	for _, node := range prevNodes {
+		if _, ok := currAmounts[node]; !ok {
+			diff.Removed = append(diff.Removed, node)
+		}
+	}
+	return diff
+}
+
 type uint64Slice []uint64
 
 func (p uint64Slice) Len() int           { return len(p) }
@@ -156,5 +445,56 @@ func CalcGiniCoefficient(stakingAmount uint64Slice) float64 {
 	result := float64(sumOfAbsoluteDifferences) / float64(subSum) / float64(len(stakingAmount))
 	result = math.Round(result*100) / 100
 
-	return result
+	return clampGiniCoefficient(result)
+}
+
+// clampGiniCoefficient limits a calculated gini coefficient into [0, 1], since rounding errors of nearly equal
+// distributions can make it slightly out of the range. NaN, which is calculated from empty or all-zero amounts,
+// is returned as DefaultGiniCoefficient.
+func clampGiniCoefficient(gini float64) float64 {
+	switch {
+	case math.IsNaN(gini):
+		return DefaultGiniCoefficient
+	case gini < 0:
+		return 0
+	case gini > 1:
+		return 1
+	}
+	return gini
+}
+
+// giniReference calculates the gini coefficient by its definition, the mean absolute difference of all pairs
+// divided by twice the mean. It is slower than CalcGiniCoefficient and is used to verify it. It isn't rounded.
+func giniReference(amounts []uint64) float64 {
+	n := float64(len(amounts))
+	sum := 0.0
+	sumOfAbsoluteDifferences := 0.0
+	for _, x := range amounts {
+		sum += float64(x)
+		for _, y := range amounts {
+			sumOfAbsoluteDifferences += math.Abs(float64(x) - float64(y))
+		}
+	}
+	if sum == 0 {
+		return DefaultGiniCoefficient
+	}
+	meanAbsoluteDifference := sumOfAbsoluteDifferences / (n * n)
+	mean := sum / n
+	return meanAbsoluteDifference / (2 * mean)
+}
+
+// CalcGiniCoefficientExcludingZero calculates the gini coefficient of the given staking amounts
+// ignoring zero amounts. The given slice is not modified.
+// It returns DefaultGiniCoefficient if there is no non-zero amount.
+func CalcGiniCoefficientExcludingZero(stakingAmount uint64Slice) float64 {
+	nonZero := make(uint64Slice, 0, len(stakingAmount))
+	for _, amount := range stakingAmount {
+		if amount != 0 {
+			nonZero = append(nonZero, amount)
+		}
+	}
+	if len(nonZero) == 0 {
+		return DefaultGiniCoefficient
+	}
+	return CalcGiniCoefficient(nonZero)
 }
@@ -0,0 +1,62 @@
+// Copyright 2019 The klaytn Authors
+// This file is part of the klaytn library.
+//
+// The klaytn library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The klaytn library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the klaytn library. If not, see <http://www.gnu.org/licenses/>.
+
+package reward
+
+import "sync"
+
+// stakingInfoCall is an in-flight or completed construction of a stakingInfo
+type stakingInfoCall struct {
+	wg          sync.WaitGroup
+	stakingInfo *StakingInfo
+	err         error
+	dups        int // the number of callers sharing the result
+}
+
+// stakingInfoFlight deduplicates concurrent constructions of stakingInfo for the same block number.
+type stakingInfoFlight struct {
+	calls map[uint64]*stakingInfoCall
+	lock  sync.Mutex
+}
+
+func newStakingInfoFlight() *stakingInfoFlight {
+	return &stakingInfoFlight{calls: make(map[uint64]*stakingInfoCall)}
+}
+
+// do executes fn for the given block number. If a construction for the same block number is already in flight,
+// it waits for that and returns its result instead of executing fn again.
+func (sf *stakingInfoFlight) do(blockNum uint64, fn func() (*StakingInfo, error)) (*StakingInfo, error) {
+	sf.lock.Lock()
+	if call, ok := sf.calls[blockNum]; ok {
+		call.dups++
+		sf.lock.Unlock()
+		call.wg.Wait()
+		return call.stakingInfo, call.err
+	}
+	call := new(stakingInfoCall)
+	call.wg.Add(1)
+	sf.calls[blockNum] = call
+	sf.lock.Unlock()
+
+	call.stakingInfo, call.err = fn()
+	call.wg.Done()
+
+	sf.lock.Lock()
+	delete(sf.calls, blockNum)
+	sf.lock.Unlock()
+
+	return call.stakingInfo, call.err
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 The klaytn Authors
+// This file is part of the klaytn library.
+//
+// The klaytn library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The klaytn library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the klaytn library. If not, see <http://www.gnu.org/licenses/>.
+
+package reward
+
+import (
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// concurrent requests for the same block number should construct stakingInfo only once
+func TestStakingInfoFlight_Do_SameNumber(t *testing.T) {
+	flight := newStakingInfoFlight()
+
+	var count int32
+	start := make(chan struct{})
+	fn := func() (*StakingInfo, error) {
+		atomic.AddInt32(&count, 1)
+		<-start // hold the construction until all callers are waiting
+		return newEmptyStakingInfo(100), nil
+	}
+
+	const numCallers = 100
+	results := make([]*StakingInfo, numCallers)
+	var wg sync.WaitGroup
+	wg.Add(numCallers)
+	for i := 0; i < numCallers; i++ {
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = flight.do(100, fn)
+		}(i)
+	}
+
+	// wait until all other callers are waiting for the in-flight construction, then let it finish
+	for {
+		flight.lock.Lock()
+		call, ok := flight.calls[100]
+		waiting := ok && call.dups == numCallers-1
+		flight.lock.Unlock()
+		if waiting {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	for i := 0; i < numCallers; i++ {
+		assert.Equal(t, results[0], results[i])
+	}
+	assert.Equal(t, 0, len(flight.calls))
+}
+
+// requests for different block numbers should not share the result
+func TestStakingInfoFlight_Do_DifferentNumber(t *testing.T) {
+	flight := newStakingInfoFlight()
+
+	var count int32
+	var wg sync.WaitGroup
+	wg.Add(4)
+	for i := uint64(1); i <= 4; i++ {
+		go func(num uint64) {
+			defer wg.Done()
+			s, err := flight.do(num, func() (*StakingInfo, error) {
+				atomic.AddInt32(&count, 1)
+				return newEmptyStakingInfo(num), nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, num, s.BlockNum)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(4), atomic.LoadInt32(&count))
+}
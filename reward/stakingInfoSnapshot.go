@@ -0,0 +1,116 @@
+// Copyright 2019 The klaytn Authors
+// This file is part of the klaytn library.
+//
+// The klaytn library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The klaytn library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the klaytn library. If not, see <http://www.gnu.org/licenses/>.
+
+package reward
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/klaytn/klaytn/common"
+	"io"
+	"math/big"
+)
+
+// councilStakingSnapshot is the staking information of a council node in a stakingInfoSnapshot
+type councilStakingSnapshot struct {
+	NodeId           common.Address `json:"nodeId"`
+	StakingAddr      common.Address `json:"stakingAddr"`
+	RewardAddr       common.Address `json:"rewardAddr"`
+	StakingAmount    uint64         `json:"stakingAmount"`              // in KLAY, rounded down and limited
+	StakingAmountPeb string         `json:"stakingAmountPeb,omitempty"` // in peb as a decimal string not to lose precision
+}
+
+// stakingInfoSnapshot is the JSON document of a stakingInfo for offline analysis.
+// Council nodes are written in the order of the stakingInfo, so the same stakingInfo is always written the same.
+type stakingInfoSnapshot struct {
+	BlockNum uint64                   `json:"blockNum"`
+	KIRAddr  common.Address           `json:"kirAddr"`
+	PoCAddr  common.Address           `json:"pocAddr"`
+	UseGini  bool                     `json:"useGini"`
+	Gini     float64                  `json:"gini"`
+	Council  []councilStakingSnapshot `json:"council"`
+}
+
+// WriteStakingInfoSnapshot writes the stakingInfo to w as an indented JSON document which has node ids,
+// staking and reward addresses, staking amounts both in KLAY and peb, the gini coefficient and the block number.
+// Staking amounts in peb are omitted if the stakingInfo doesn't have them.
+func WriteStakingInfoSnapshot(w io.Writer, s *StakingInfo) error {
+	if len(s.CouncilStakingAddrs) != len(s.CouncilNodeAddrs) || len(s.CouncilRewardAddrs) != len(s.CouncilNodeAddrs) ||
+		len(s.CouncilStakingAmounts) != len(s.CouncilNodeAddrs) {
+		return errAddressBookIncomplete
+	}
+	hasPeb := len(s.CouncilStakingAmountsPeb) == len(s.CouncilNodeAddrs)
+
+	snapshot := stakingInfoSnapshot{
+		BlockNum: s.BlockNum,
+		KIRAddr:  s.KIRAddr,
+		PoCAddr:  s.PoCAddr,
+		UseGini:  s.UseGini,
+		Gini:     s.Gini,
+		Council:  make([]councilStakingSnapshot, len(s.CouncilNodeAddrs)),
+	}
+	for i := range s.CouncilNodeAddrs {
+		snapshot.Council[i] = councilStakingSnapshot{
+			NodeId:        s.CouncilNodeAddrs[i],
+			StakingAddr:   s.CouncilStakingAddrs[i],
+			RewardAddr:    s.CouncilRewardAddrs[i],
+			StakingAmount: s.CouncilStakingAmounts[i],
+		}
+		if hasPeb && s.CouncilStakingAmountsPeb[i] != nil {
+			snapshot.Council[i].StakingAmountPeb = s.CouncilStakingAmountsPeb[i].String()
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(snapshot)
+}
+
+// ReadStakingInfoSnapshot reads a stakingInfo written by WriteStakingInfoSnapshot.
+// Staking amounts in peb are restored only if all council nodes have them.
+func ReadStakingInfoSnapshot(r io.Reader) (*StakingInfo, error) {
+	var snapshot stakingInfoSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, err
+	}
+
+	s := newEmptyStakingInfo(snapshot.BlockNum)
+	s.KIRAddr = snapshot.KIRAddr
+	s.PoCAddr = snapshot.PoCAddr
+	s.UseGini = snapshot.UseGini
+	s.Gini = snapshot.Gini
+
+	pebs := make([]*big.Int, 0, len(snapshot.Council))
+	for _, node := range snapshot.Council {
+		s.CouncilNodeAddrs = append(s.CouncilNodeAddrs, node.NodeId)
+		s.CouncilStakingAddrs = append(s.CouncilStakingAddrs, node.StakingAddr)
+		s.CouncilRewardAddrs = append(s.CouncilRewardAddrs, node.RewardAddr)
+		s.CouncilStakingAmounts = append(s.CouncilStakingAmounts, node.StakingAmount)
+
+		if node.StakingAmountPeb == "" {
+			continue
+		}
+		peb, ok := new(big.Int).SetString(node.StakingAmountPeb, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid staking amount in peb of node %s: %q", node.NodeId.String(), node.StakingAmountPeb)
+		}
+		pebs = append(pebs, peb)
+	}
+	if len(pebs) == len(snapshot.Council) {
+		s.CouncilStakingAmountsPeb = pebs
+	}
+	return s, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2019 The klaytn Authors
+// This file is part of the klaytn library.
+//
+// The klaytn library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The klaytn library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the klaytn library. If not, see <http://www.gnu.org/licenses/>.
+
+package reward
+
+import (
+	"bytes"
+	"github.com/klaytn/klaytn/common"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func newTestSnapshotStakingInfo() *StakingInfo {
+	peb, _ := new(big.Int).SetString("5000000123456789012345678", 10)
+	return &StakingInfo{
+		BlockNum:                 86400,
+		CouncilNodeAddrs:         []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2")},
+		CouncilStakingAddrs:      []common.Address{common.HexToAddress("0x11"), common.HexToAddress("0x12")},
+		CouncilRewardAddrs:       []common.Address{common.HexToAddress("0x21"), common.HexToAddress("0x22")},
+		KIRAddr:                  common.HexToAddress("0x31"),
+		PoCAddr:                  common.HexToAddress("0x32"),
+		UseGini:                  true,
+		Gini:                     0.25,
+		CouncilStakingAmounts:    []uint64{5000000, 6000000},
+		CouncilStakingAmountsPeb: []*big.Int{peb, new(big.Int).Mul(big.NewInt(6000000), big.NewInt(1e18))},
+	}
+}
+
+func TestStakingInfoSnapshot_RoundTrip(t *testing.T) {
+	stakingInfo := newTestSnapshotStakingInfo()
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteStakingInfoSnapshot(&buf, stakingInfo))
+	written := buf.String()
+	assert.True(t, strings.Contains(written, `"stakingAmountPeb": "5000000123456789012345678"`))
+
+	read, err := ReadStakingInfoSnapshot(strings.NewReader(written))
+	assert.NoError(t, err)
+	assert.Equal(t, stakingInfo, read)
+
+	// The same stakingInfo is always written the same
+	var again bytes.Buffer
+	assert.NoError(t, WriteStakingInfoSnapshot(&again, read))
+	assert.Equal(t, written, again.String())
+}
+
+func TestStakingInfoSnapshot_WithoutPeb(t *testing.T) {
+	stakingInfo := newTestSnapshotStakingInfo()
+	stakingInfo.CouncilStakingAmountsPeb = make([]*big.Int, 0, 0)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteStakingInfoSnapshot(&buf, stakingInfo))
+	assert.False(t, strings.Contains(buf.String(), "stakingAmountPeb"))
+
+	read, err := ReadStakingInfoSnapshot(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, stakingInfo, read)
+
+	// An empty stakingInfo is also written and read
+	buf.Reset()
+	assert.NoError(t, WriteStakingInfoSnapshot(&buf, newEmptyStakingInfo(0)))
+	read, err = ReadStakingInfoSnapshot(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, newEmptyStakingInfo(0), read)
+}
+
+func TestStakingInfoSnapshot_Invalid(t *testing.T) {
+	stakingInfo := newTestSnapshotStakingInfo()
+	stakingInfo.CouncilRewardAddrs = stakingInfo.CouncilRewardAddrs[:1]
+	assert.Equal(t, errAddressBookIncomplete, WriteStakingInfoSnapshot(&bytes.Buffer{}, stakingInfo))
+
+	_, err := ReadStakingInfoSnapshot(strings.NewReader(`{"council":[{"stakingAmountPeb":"12a"}]}`))
+	assert.Error(t, err)
+	_, err = ReadStakingInfoSnapshot(strings.NewReader(`{`))
+	assert.Error(t, err)
+}
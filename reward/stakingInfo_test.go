@@ -17,9 +17,20 @@
 package reward
 
 import (
+	"errors"
+	"fmt"
+	"github.com/klaytn/klaytn/blockchain"
+	"github.com/klaytn/klaytn/blockchain/state"
+	"github.com/klaytn/klaytn/blockchain/types"
+	"github.com/klaytn/klaytn/blockchain/vm"
 	"github.com/klaytn/klaytn/common"
+	"github.com/klaytn/klaytn/consensus/gxhash"
+	"github.com/klaytn/klaytn/params"
+	"github.com/klaytn/klaytn/storage/database"
 	"github.com/stretchr/testify/assert"
 	"math"
+	"math/big"
+	"math/rand"
 	"testing"
 )
 
@@ -90,6 +101,48 @@ func TestStakingInfo_GetStakingAmountByNodeId(t *testing.T) {
 	}
 }
 
+func TestStakingInfo_GetNodeByStakingAddr(t *testing.T) {
+	stakingInfo := newEmptyStakingInfo(0)
+	stakingInfo.CouncilNodeAddrs = []common.Address{
+		common.HexToAddress("0x1"),
+		common.HexToAddress("0x2"),
+		common.HexToAddress("0x3"),
+	}
+	// the staking address 0x12 is shared by the second and third nodes
+	stakingInfo.CouncilStakingAddrs = []common.Address{
+		common.HexToAddress("0x11"),
+		common.HexToAddress("0x12"),
+		common.HexToAddress("0x12"),
+	}
+
+	// unique staking address
+	node, err := stakingInfo.GetNodeByStakingAddr(common.HexToAddress("0x11"))
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x1"), node)
+
+	nodes, err := stakingInfo.GetNodesByStakingAddr(common.HexToAddress("0x11"))
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Address{common.HexToAddress("0x1")}, nodes)
+
+	// duplicate staking address
+	node, err = stakingInfo.GetNodeByStakingAddr(common.HexToAddress("0x12"))
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x2"), node)
+
+	nodes, err = stakingInfo.GetNodesByStakingAddr(common.HexToAddress("0x12"))
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Address{common.HexToAddress("0x2"), common.HexToAddress("0x3")}, nodes)
+
+	// unknown staking address
+	node, err = stakingInfo.GetNodeByStakingAddr(common.HexToAddress("0x13"))
+	assert.Equal(t, ErrAddrNotInStakingInfo, err)
+	assert.Equal(t, common.Address{}, node)
+
+	nodes, err = stakingInfo.GetNodesByStakingAddr(common.HexToAddress("0x13"))
+	assert.Equal(t, ErrAddrNotInStakingInfo, err)
+	assert.Nil(t, nodes)
+}
+
 func TestCalcGiniCoefficient(t *testing.T) {
 	testCase := []struct {
 		testdata []uint64
@@ -106,6 +159,91 @@ func TestCalcGiniCoefficient(t *testing.T) {
 	}
 }
 
+func TestCalcGiniCoefficient_Range(t *testing.T) {
+	// Nearly equal distributions
+	testCases := [][]uint64{
+		{maxStakingLimit, maxStakingLimit - 1, maxStakingLimit, maxStakingLimit - 1},
+		{5000000, 5000001, 5000000, 4999999, 5000000, 5000001, 5000000},
+		{100, 100, 100, 101},
+	}
+	for _, tc := range testCases {
+		result := CalcGiniCoefficient(tc)
+		assert.False(t, math.Signbit(result), "gini should not be negative: %v", tc)
+		assert.Equal(t, 0.0, result)
+	}
+
+	// Empty or all-zero amounts can't make a gini coefficient
+	assert.Equal(t, DefaultGiniCoefficient, CalcGiniCoefficient([]uint64{}))
+	assert.Equal(t, DefaultGiniCoefficient, CalcGiniCoefficient([]uint64{0, 0, 0}))
+
+	assert.Equal(t, 0.0, clampGiniCoefficient(-0.01))
+	assert.Equal(t, 0.0, clampGiniCoefficient(-1e-17))
+	assert.Equal(t, 1.0, clampGiniCoefficient(1.01))
+	assert.Equal(t, 0.27, clampGiniCoefficient(0.27))
+	assert.Equal(t, DefaultGiniCoefficient, clampGiniCoefficient(math.NaN()))
+}
+
+func TestCalcGiniCoefficient_Reference(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	// The fast path is rounded to 2 decimal places
+	tolerance := 0.005 + 1e-9
+
+	for i := 0; i < 1000; i++ {
+		amounts := make([]uint64, 1+rnd.Intn(100))
+		for j := range amounts {
+			switch rnd.Intn(3) {
+			case 0: // around the minimum stake
+				amounts[j] = 5000000 + uint64(rnd.Intn(1000000))
+			case 1: // up to the staking limit
+				amounts[j] = uint64(rnd.Int63n(int64(maxStakingLimit) + 1))
+			default: // small amounts
+				amounts[j] = uint64(rnd.Intn(100))
+			}
+		}
+		expected := giniReference(amounts)
+		if expected == DefaultGiniCoefficient {
+			continue
+		}
+
+		data := make([]uint64, len(amounts))
+		copy(data, amounts)
+		actual := CalcGiniCoefficient(data)
+		assert.InDelta(t, expected, actual, tolerance, "amounts: %v", amounts)
+	}
+
+	// The existing cases agree too
+	assert.InDelta(t, 0.8, giniReference([]uint64{0, 8, 0, 0, 0}), 1e-9)
+	assert.InDelta(t, 0.27, giniReference([]uint64{5, 4, 3, 2, 1}), 0.005)
+	assert.Equal(t, 0.0, giniReference([]uint64{1, 1, 1}))
+	assert.Equal(t, DefaultGiniCoefficient, giniReference([]uint64{0, 0}))
+}
+
+func TestCalcGiniCoefficientExcludingZero(t *testing.T) {
+	testCase := []struct {
+		testdata        []uint64
+		result          float64
+		resultWithZeros float64
+	}{
+		{[]uint64{1, 1, 1}, 0.0, 0.0},
+		{[]uint64{0, 1, 1, 1}, 0.0, 0.25},
+		{[]uint64{0, 8, 0, 0, 0}, 0.0, 0.8},
+		{[]uint64{5, 0, 4, 3, 0, 2, 1}, 0.27, 0.48},
+		{[]uint64{0, 0}, DefaultGiniCoefficient, DefaultGiniCoefficient},
+		{[]uint64{}, DefaultGiniCoefficient, DefaultGiniCoefficient},
+	}
+
+	for i := 0; i < len(testCase); i++ {
+		data := make([]uint64, len(testCase[i].testdata))
+		copy(data, testCase[i].testdata)
+
+		assert.Equal(t, testCase[i].result, CalcGiniCoefficientExcludingZero(data))
+		assert.Equal(t, testCase[i].testdata, data, "input should not be modified")
+		if len(data) > 0 && testCase[i].resultWithZeros != DefaultGiniCoefficient {
+			assert.Equal(t, testCase[i].resultWithZeros, CalcGiniCoefficient(data))
+		}
+	}
+}
+
 func TestGiniReflectToExpectedCCO(t *testing.T) {
 	testCase := []struct {
 		ccoToken        []uint64
@@ -178,3 +316,427 @@ func TestGiniReflectToExpectedCCO(t *testing.T) {
 		}
 	}
 }
+
+func TestStakingInfo_SortedByNodeAddr(t *testing.T) {
+	stakingInfo := newEmptyStakingInfo(0)
+	stakingInfo.CouncilNodeAddrs = []common.Address{
+		common.HexToAddress("0xD527822212Fded72c5fE89f46281d5355BD58235"),
+		common.HexToAddress("0x994daB8EB6f3FaE044cC0c9a0AB1A038e136b0B6"),
+		common.HexToAddress("0xB55e5986b972Be438b4A91d6e8726aA50AD55EDc"),
+		common.HexToAddress("0x027AbB8c9f952cfFf01B1707fF14E2CB5D439502"),
+	}
+	stakingInfo.CouncilStakingAddrs = []common.Address{
+		common.HexToAddress("0xd000000000000000000000000000000000000000"),
+		common.HexToAddress("0x9000000000000000000000000000000000000000"),
+		common.HexToAddress("0xb000000000000000000000000000000000000000"),
+		common.HexToAddress("0x0200000000000000000000000000000000000000"),
+	}
+	stakingInfo.CouncilRewardAddrs = []common.Address{
+		common.HexToAddress("0xd100000000000000000000000000000000000000"),
+		common.HexToAddress("0x9100000000000000000000000000000000000000"),
+		common.HexToAddress("0xb100000000000000000000000000000000000000"),
+		common.HexToAddress("0x0210000000000000000000000000000000000000"),
+	}
+	stakingInfo.CouncilStakingAmounts = []uint64{400, 300, 200, 100}
+
+	sorted := stakingInfo.SortedByNodeAddr()
+
+	expectedOrder := []int{3, 1, 2, 0}
+	for i, idx := range expectedOrder {
+		assert.Equal(t, stakingInfo.CouncilNodeAddrs[idx], sorted.CouncilNodeAddrs[i])
+		assert.Equal(t, stakingInfo.CouncilStakingAddrs[idx], sorted.CouncilStakingAddrs[i])
+		assert.Equal(t, stakingInfo.CouncilRewardAddrs[idx], sorted.CouncilRewardAddrs[i])
+		assert.Equal(t, stakingInfo.CouncilStakingAmounts[idx], sorted.CouncilStakingAmounts[i])
+	}
+
+	// the original stakingInfo should not be changed
+	assert.Equal(t, common.HexToAddress("0xD527822212Fded72c5fE89f46281d5355BD58235"), stakingInfo.CouncilNodeAddrs[0])
+	assert.Equal(t, uint64(400), stakingInfo.CouncilStakingAmounts[0])
+}
+
+func TestStakingInfo_GetStakingInfoAge(t *testing.T) {
+	interval := params.StakingUpdateInterval()
+	stakingInfo := newEmptyStakingInfo(interval)
+
+	testCases := []struct {
+		currentBlockNum uint64
+		age             uint64
+		stale           bool
+	}{
+		{0, 0, false},
+		{interval, 0, false},
+		{interval + 1, 1, false},
+		{2*interval - 1, interval - 1, false},
+		{2 * interval, interval, false},
+		{2*interval + 1, interval + 1, true},
+		{3 * interval, 2 * interval, true},
+	}
+
+	for _, tc := range testCases {
+		age, stale := stakingInfo.GetStakingInfoAge(tc.currentBlockNum)
+		assert.Equal(t, tc.age, age, "currentBlockNum: %d", tc.currentBlockNum)
+		assert.Equal(t, tc.stale, stale, "currentBlockNum: %d", tc.currentBlockNum)
+	}
+}
+
+func TestStakingInfo_Diff(t *testing.T) {
+	nodes := []common.Address{
+		common.HexToAddress("0x1"),
+		common.HexToAddress("0x2"),
+		common.HexToAddress("0x3"),
+		common.HexToAddress("0x4"),
+	}
+	makeStakingInfo := func(blockNum uint64, nodeIdx []int, amounts []uint64) *StakingInfo {
+		s := newEmptyStakingInfo(blockNum)
+		for _, idx := range nodeIdx {
+			s.CouncilNodeAddrs = append(s.CouncilNodeAddrs, nodes[idx])
+			s.CouncilStakingAddrs = append(s.CouncilStakingAddrs, nodes[idx])
+			s.CouncilRewardAddrs = append(s.CouncilRewardAddrs, nodes[idx])
+		}
+		s.CouncilStakingAmounts = amounts
+		return s
+	}
+
+	testCases := []struct {
+		prev    *StakingInfo
+		curr    *StakingInfo
+		added   []common.Address
+		removed []common.Address
+		changes map[common.Address]StakeChange
+	}{
+		// everything is added if there is no previous stakingInfo
+		{
+			nil,
+			makeStakingInfo(86400, []int{0, 1}, []uint64{100, 200}),
+			[]common.Address{nodes[0], nodes[1]},
+			[]common.Address{},
+			map[common.Address]StakeChange{},
+		},
+		// nothing changed
+		{
+			makeStakingInfo(86400, []int{0, 1}, []uint64{100, 200}),
+			makeStakingInfo(172800, []int{1, 0}, []uint64{200, 100}),
+			[]common.Address{},
+			[]common.Address{},
+			map[common.Address]StakeChange{},
+		},
+		// additions and removals
+		{
+			makeStakingInfo(86400, []int{0, 1, 2}, []uint64{100, 200, 300}),
+			makeStakingInfo(172800, []int{1, 3}, []uint64{200, 0}),
+			[]common.Address{nodes[3]},
+			[]common.Address{nodes[0], nodes[2]},
+			map[common.Address]StakeChange{},
+		},
+		// stake changes
+		{
+			makeStakingInfo(86400, []int{0, 1, 2}, []uint64{100, 200, 300}),
+			makeStakingInfo(172800, []int{0, 1, 2}, []uint64{150, 200, 0}),
+			[]common.Address{},
+			[]common.Address{},
+			map[common.Address]StakeChange{
+				nodes[0]: {Prev: 100, Current: 150},
+				nodes[2]: {Prev: 300, Current: 0},
+			},
+		},
+		// amounts of a node with multiple staking addresses are summed up
+		{
+			makeStakingInfo(86400, []int{0, 0, 1}, []uint64{100, 200, 300}),
+			makeStakingInfo(172800, []int{0, 2}, []uint64{300, 100}),
+			[]common.Address{nodes[2]},
+			[]common.Address{nodes[1]},
+			map[common.Address]StakeChange{},
+		},
+	}
+
+	for i, tc := range testCases {
+		diff := tc.curr.Diff(tc.prev)
+		assert.Equal(t, tc.added, diff.Added, "test case %d", i)
+		assert.Equal(t, tc.removed, diff.Removed, "test case %d", i)
+		assert.Equal(t, tc.changes, diff.StakeChanges, "test case %d", i)
+	}
+}
+
+func TestStakingInfo_StakingAmountPeb(t *testing.T) {
+	nodeIds := []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2"), common.HexToAddress("0x3")}
+	stakingAddrs := []common.Address{common.HexToAddress("0x11"), common.HexToAddress("0x12"), common.HexToAddress("0x13")}
+	klay := new(big.Int).SetUint64(params.KLAY)
+
+	// balances which are not multiples of KLAY
+	balances := []*big.Int{
+		new(big.Int).Add(new(big.Int).Mul(big.NewInt(5000000), klay), big.NewInt(1)),
+		new(big.Int).Sub(klay, big.NewInt(1)),
+		new(big.Int).Add(new(big.Int).Mul(big.NewInt(123), klay), big.NewInt(456789)),
+	}
+	alloc := blockchain.GenesisAlloc{}
+	for i, addr := range stakingAddrs {
+		alloc[addr] = blockchain.GenesisAccount{Balance: balances[i]}
+	}
+
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	(&blockchain.Genesis{Config: params.TestChainConfig, Alloc: alloc}).MustCommit(dbm)
+	bc, err := blockchain.NewBlockChain(dbm, nil, params.TestChainConfig, gxhash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create a blockchain: %v", err)
+	}
+	defer bc.Stop()
+
+	stakingInfo, err := newStakingInfo(bc, newDefaultTestGovernance(), 0, nodeIds, stakingAddrs, nodeIds, common.Address{}, common.Address{})
+	if err != nil {
+		t.Fatalf("Failed to make stakingInfo: %v", err)
+	}
+
+	for i, nodeId := range nodeIds {
+		peb, err := stakingInfo.StakingAmountPeb(nodeId)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, balances[i].Cmp(peb), "expected %v, got %v", balances[i], peb)
+
+		// the rounded amount is kept and equal to the peb amount divided by KLAY
+		rounded, err := stakingInfo.GetStakingAmountByNodeId(nodeId)
+		assert.NoError(t, err)
+		assert.Equal(t, new(big.Int).Div(peb, klay).Uint64(), rounded)
+	}
+
+	// the returned amount is a copy
+	peb, _ := stakingInfo.StakingAmountPeb(nodeIds[0])
+	peb.SetUint64(0)
+	peb, _ = stakingInfo.StakingAmountPeb(nodeIds[0])
+	assert.Equal(t, 0, balances[0].Cmp(peb))
+
+	// the peb amounts are reordered with the others
+	sorted := stakingInfo.SortedByNodeAddr()
+	for _, nodeId := range nodeIds {
+		expected, _ := stakingInfo.StakingAmountPeb(nodeId)
+		actual, err := sorted.StakingAmountPeb(nodeId)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, expected.Cmp(actual))
+	}
+
+	_, err = stakingInfo.StakingAmountPeb(common.HexToAddress("0x4"))
+	assert.Equal(t, ErrAddrNotInStakingInfo, err)
+
+	// stakingInfo without peb amounts
+	stakingInfo.CouncilStakingAmountsPeb = nil
+	_, err = stakingInfo.StakingAmountPeb(nodeIds[0])
+	assert.Equal(t, ErrPebAmountNotAvailable, err)
+}
+
+func TestStakingInfo_UseGiniDefault(t *testing.T) {
+	nodeIds := []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2")}
+	stakingAddrs := []common.Address{common.HexToAddress("0x11"), common.HexToAddress("0x12")}
+	alloc := blockchain.GenesisAlloc{
+		stakingAddrs[0]: {Balance: new(big.Int).Mul(big.NewInt(5000000), new(big.Int).SetUint64(params.KLAY))},
+		stakingAddrs[1]: {Balance: new(big.Int).Mul(big.NewInt(10000000), new(big.Int).SetUint64(params.KLAY))},
+	}
+
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	(&blockchain.Genesis{Config: params.TestChainConfig, Alloc: alloc}).MustCommit(dbm)
+	bc, err := blockchain.NewBlockChain(dbm, nil, params.TestChainConfig, gxhash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create a blockchain: %v", err)
+	}
+	defer bc.Stop()
+
+	// Governance is not initialized at the block
+	gov := newDefaultTestGovernance()
+	gov.initializedAt = 1
+	assert.Equal(t, true, getItemAtNumberByIntKeyWithDefault(gov, 1, params.UseGiniCoeff, false))
+	assert.Equal(t, false, getItemAtNumberByIntKeyWithDefault(gov, 0, params.UseGiniCoeff, false))
+	assert.Equal(t, uint64(7), getItemAtNumberByIntKeyWithDefault(gov, 1, params.UseGiniCoeff, uint64(7)))
+
+	stakingInfo, err := newStakingInfo(bc, gov, 0, nodeIds, stakingAddrs, nodeIds, common.Address{}, common.Address{})
+	assert.NoError(t, err)
+	assert.Equal(t, params.DefaultUseGiniCoeff, stakingInfo.UseGini)
+	assert.Equal(t, DefaultGiniCoefficient, stakingInfo.Gini)
+
+	// Governance is initialized at the block
+	gov.initializedAt = 0
+	stakingInfo, err = newStakingInfo(bc, gov, 0, nodeIds, stakingAddrs, nodeIds, common.Address{}, common.Address{})
+	assert.NoError(t, err)
+	assert.True(t, stakingInfo.UseGini)
+	assert.Equal(t, CalcGiniCoefficient([]uint64{5000000, 10000000}), stakingInfo.Gini)
+}
+
+// mockStakingAmountSource returns the overridden amounts of staking addresses and falls back to their balances
+type mockStakingAmountSource struct {
+	amounts map[common.Address]*big.Int
+	err     error
+}
+
+func (m *mockStakingAmountSource) StakingAmount(header *types.Header, statedb *state.StateDB, stakingAddr common.Address) (*big.Int, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if amount, ok := m.amounts[stakingAddr]; ok {
+		return amount, nil
+	}
+	return BalanceStakingAmountSource{}.StakingAmount(header, statedb, stakingAddr)
+}
+
+func TestStakingInfo_StakingAmountSource(t *testing.T) {
+	nodeIds := []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2")}
+	stakingAddrs := []common.Address{common.HexToAddress("0x11"), common.HexToAddress("0x12")}
+	klay := new(big.Int).SetUint64(params.KLAY)
+	alloc := blockchain.GenesisAlloc{
+		stakingAddrs[0]: {Balance: new(big.Int).Mul(big.NewInt(5000000), klay)},
+		stakingAddrs[1]: {Balance: new(big.Int).Mul(big.NewInt(6000000), klay)},
+	}
+
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	(&blockchain.Genesis{Config: params.TestChainConfig, Alloc: alloc}).MustCommit(dbm)
+	bc, err := blockchain.NewBlockChain(dbm, nil, params.TestChainConfig, gxhash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create a blockchain: %v", err)
+	}
+	defer bc.Stop()
+
+	// The balances are used by default
+	stakingInfo, err := newStakingInfo(bc, newDefaultTestGovernance(), 0, nodeIds, stakingAddrs, nodeIds, common.Address{}, common.Address{})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{5000000, 6000000}, stakingInfo.CouncilStakingAmounts)
+
+	// The amount of the first staking address is overridden by the source
+	source := &mockStakingAmountSource{amounts: map[common.Address]*big.Int{
+		stakingAddrs[0]: new(big.Int).Mul(big.NewInt(7000000), klay),
+	}}
+	stakingInfo, err = newStakingInfoWithSource(bc, newDefaultTestGovernance(), source, 0, nodeIds, stakingAddrs, nodeIds, common.Address{}, common.Address{})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{7000000, 6000000}, stakingInfo.CouncilStakingAmounts)
+	peb, err := stakingInfo.StakingAmountPeb(nodeIds[0])
+	assert.NoError(t, err)
+	assert.Equal(t, 0, source.amounts[stakingAddrs[0]].Cmp(peb))
+
+	// Amounts over the limit are capped as the balances are
+	source.amounts[stakingAddrs[1]] = new(big.Int).Mul(new(big.Int).SetUint64(maxStakingLimit+1), klay)
+	stakingInfo, err = newStakingInfoWithSource(bc, newDefaultTestGovernance(), source, 0, nodeIds, stakingAddrs, nodeIds, common.Address{}, common.Address{})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{7000000, maxStakingLimit}, stakingInfo.CouncilStakingAmounts)
+
+	// An error of the source fails making the stakingInfo
+	source.err = errors.New("failed to call the contract")
+	_, err = newStakingInfoWithSource(bc, newDefaultTestGovernance(), source, 0, nodeIds, stakingAddrs, nodeIds, common.Address{}, common.Address{})
+	assert.Equal(t, source.err, err)
+
+	// The source of the addressBookManager can be injected
+	abm := newAddressBookManager(bc, newDefaultTestGovernance())
+	assert.Equal(t, BalanceStakingAmountSource{}, abm.stakingAmountSource)
+	abm.setStakingAmountSource(source)
+	assert.Equal(t, source, abm.stakingAmountSource)
+	abm.setStakingAmountSource(nil)
+	assert.Equal(t, BalanceStakingAmountSource{}, abm.stakingAmountSource)
+}
+
+func TestStakingInfo_MeetsMinimumStake(t *testing.T) {
+	minStake := new(big.Int).Mul(big.NewInt(5000000), new(big.Int).SetUint64(params.KLAY))
+	nodeIds := []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2"), common.HexToAddress("0x3")}
+
+	stakingInfo := newEmptyStakingInfo(0)
+	stakingInfo.CouncilNodeAddrs = nodeIds
+	stakingInfo.CouncilStakingAmountsPeb = []*big.Int{
+		new(big.Int).Set(minStake),                // exactly the minimum
+		new(big.Int).Sub(minStake, big.NewInt(1)), // 1 peb below the minimum
+		new(big.Int).Add(minStake, big.NewInt(1)), // 1 peb above the minimum
+	}
+	// Rounded to KLAY, all of them look the same except the one below the minimum
+	stakingInfo.CouncilStakingAmounts = []uint64{5000000, 4999999, 5000000}
+
+	expected := []bool{true, false, true}
+	for i, nodeId := range nodeIds {
+		ok, err := stakingInfo.MeetsMinimumStake(nodeId, minStake)
+		assert.NoError(t, err)
+		assert.Equal(t, expected[i], ok, "node %d", i)
+	}
+
+	// The minimum given is not modified
+	assert.Equal(t, 0, minStake.Cmp(new(big.Int).Mul(big.NewInt(5000000), new(big.Int).SetUint64(params.KLAY))))
+
+	_, err := stakingInfo.MeetsMinimumStake(common.HexToAddress("0x4"), minStake)
+	assert.Equal(t, ErrAddrNotInStakingInfo, err)
+
+	_, err = stakingInfo.MeetsMinimumStake(nodeIds[0], nil)
+	assert.Equal(t, ErrInvalidMinimumStake, err)
+	_, err = stakingInfo.MeetsMinimumStake(nodeIds[0], big.NewInt(-1))
+	assert.Equal(t, ErrInvalidMinimumStake, err)
+
+	stakingInfo.CouncilStakingAmountsPeb = nil
+	_, err = stakingInfo.MeetsMinimumStake(nodeIds[0], minStake)
+	assert.Equal(t, ErrPebAmountNotAvailable, err)
+}
+
+// newStakingTestBlockChain returns a blockchain whose genesis allocates random balances to n staking addresses
+func newStakingTestBlockChain(t testing.TB, n int) (*blockchain.BlockChain, []common.Address) {
+	stakingAddrs := make([]common.Address, n)
+	alloc := blockchain.GenesisAlloc{}
+	for i := range stakingAddrs {
+		stakingAddrs[i] = common.BigToAddress(big.NewInt(int64(0x1000 + i)))
+		balance := new(big.Int).Mul(big.NewInt(rand.Int63n(10000000)), new(big.Int).SetUint64(params.KLAY))
+		alloc[stakingAddrs[i]] = blockchain.GenesisAccount{Balance: balance.Add(balance, big.NewInt(int64(i)))}
+	}
+
+	dbm := database.NewDBManager(&database.DBConfig{DBType: database.MemoryDB})
+	(&blockchain.Genesis{Config: params.TestChainConfig, Alloc: alloc}).MustCommit(dbm)
+	bc, err := blockchain.NewBlockChain(dbm, nil, params.TestChainConfig, gxhash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create a blockchain: %v", err)
+	}
+	return bc, stakingAddrs
+}
+
+func TestReadStakingAmounts(t *testing.T) {
+	bc, stakingAddrs := newStakingTestBlockChain(t, 50)
+	defer bc.Stop()
+
+	header := bc.GetBlockByNumber(0).Header()
+	newState := func() (*state.StateDB, error) { return bc.StateAt(header.Root) }
+
+	// The amounts read sequentially
+	statedb, _ := newState()
+	expected := make([]*big.Int, len(stakingAddrs))
+	for i, addr := range stakingAddrs {
+		expected[i], _ = BalanceStakingAmountSource{}.StakingAmount(header, statedb, addr)
+	}
+
+	for _, workers := range []int{1, 3, stakingAmountWorkers, 100} {
+		statedb, _ := newState()
+		amounts, err := readStakingAmounts(header, statedb, newState, BalanceStakingAmountSource{}, stakingAddrs, workers)
+		assert.NoError(t, err)
+		assert.Equal(t, len(expected), len(amounts))
+		for i := range expected {
+			assert.Equal(t, 0, expected[i].Cmp(amounts[i]), "workers: %d, index: %d", workers, i)
+		}
+	}
+
+	// No staking address
+	amounts, err := readStakingAmounts(header, statedb, newState, BalanceStakingAmountSource{}, nil, stakingAmountWorkers)
+	assert.NoError(t, err)
+	assert.Empty(t, amounts)
+
+	// Reading is aborted by an error of the source
+	source := &mockStakingAmountSource{err: errors.New("failed to call the contract")}
+	_, err = readStakingAmounts(header, statedb, newState, source, stakingAddrs, stakingAmountWorkers)
+	assert.Equal(t, source.err, err)
+
+	// Reading is aborted if a state can't be made
+	errState := errors.New("no state")
+	_, err = readStakingAmounts(header, statedb, func() (*state.StateDB, error) { return nil, errState }, BalanceStakingAmountSource{}, stakingAddrs, stakingAmountWorkers)
+	assert.Equal(t, errState, err)
+}
+
+func BenchmarkReadStakingAmounts(b *testing.B) {
+	bc, stakingAddrs := newStakingTestBlockChain(b, 100)
+	defer bc.Stop()
+
+	header := bc.GetBlockByNumber(0).Header()
+	newState := func() (*state.StateDB, error) { return bc.StateAt(header.Root) }
+
+	for _, workers := range []int{1, stakingAmountWorkers} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				statedb, _ := newState()
+				if _, err := readStakingAmounts(header, statedb, newState, BalanceStakingAmountSource{}, stakingAddrs, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -20,18 +20,32 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/json"
+	"github.com/dgraph-io/badger"
 	"github.com/klaytn/klaytn/blockchain/types"
 	"github.com/klaytn/klaytn/common"
 	"github.com/klaytn/klaytn/log"
 	"github.com/klaytn/klaytn/params"
 	"github.com/klaytn/klaytn/ser/rlp"
 	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
 	"math/big"
 	"path/filepath"
 )
 
 var logger = log.NewModuleLogger(log.StorageDatabase)
 
+// ErrGovernanceNotFound is returned if no governance data is found for a block
+var ErrGovernanceNotFound = errors.New("No governance data found")
+
+// IsNotFound returns true if the error is returned because the data is not found in the database
+func IsNotFound(err error) bool {
+	switch err {
+	case leveldb.ErrNotFound, badger.ErrKeyNotFound, errMemDBNotFound, ErrGovernanceNotFound:
+		return true
+	}
+	return false
+}
+
 type DBManager interface {
 	IsParallelDBWrite() bool
 
@@ -169,7 +183,9 @@ type DBManager interface {
 
 	// Governance related functions
 	WriteGovernance(data map[string]interface{}, num uint64) error
+	OverwriteGovernance(data map[string]interface{}, num uint64) error
 	WriteGovernanceIdx(num uint64) error
+	DeleteGovernanceAbove(num uint64) error
 	ReadGovernance(num uint64) (map[string]interface{}, error)
 	ReadRecentGovernanceIdx(count int) ([]uint64, error)
 	ReadGovernanceAtNumber(num uint64, epoch uint64) (uint64, map[string]interface{}, error)
@@ -1480,6 +1496,17 @@ func (dbm *databaseManager) WriteGovernance(data map[string]interface{}, num uin
 	return db.Put(governanceKey(num), b)
 }
 
+// OverwriteGovernance writes governance items at the given block without adding the block to the governance history.
+// It is used to rewrite the items already written by WriteGovernance.
+func (dbm *databaseManager) OverwriteGovernance(data map[string]interface{}, num uint64) error {
+	db := dbm.getDatabase(MiscDB)
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return db.Put(governanceKey(num), b)
+}
+
 func (dbm *databaseManager) WriteGovernanceIdx(num uint64) error {
 	db := dbm.getDatabase(MiscDB)
 	newSlice := make([]uint64, 0)
@@ -1498,14 +1525,47 @@ func (dbm *databaseManager) WriteGovernanceIdx(num uint64) error {
 	return db.Put(governanceHistoryKey, data)
 }
 
+// DeleteGovernanceAbove removes the governance items written above the given block and their indices
+// from the governance history. It is used when the headers having the items are no longer in the chain.
+func (dbm *databaseManager) DeleteGovernanceAbove(num uint64) error {
+	db := dbm.getDatabase(MiscDB)
+
+	idxHistory, err := dbm.ReadRecentGovernanceIdx(0)
+	if err != nil {
+		return err
+	}
+	kept := make([]uint64, 0, len(idxHistory))
+	for _, idx := range idxHistory {
+		if idx > num {
+			if err := db.Delete(governanceKey(idx)); err != nil {
+				return err
+			}
+			continue
+		}
+		kept = append(kept, idx)
+	}
+	if len(kept) == len(idxHistory) {
+		return nil
+	}
+
+	data, err := json.Marshal(kept)
+	if err != nil {
+		return err
+	}
+	return db.Put(governanceHistoryKey, data)
+}
+
 func (dbm *databaseManager) ReadGovernance(num uint64) (map[string]interface{}, error) {
 	db := dbm.getDatabase(MiscDB)
 
 	if data, err := db.Get(governanceKey(num)); err != nil {
 		return nil, err
 	} else {
+		// Numbers are decoded as json.Number, since a uint64 above 2^53 loses its precision as a float64
 		result := make(map[string]interface{})
-		if e := json.Unmarshal(data, &result); e != nil {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if e := dec.Decode(&result); e != nil {
 			return nil, e
 		}
 		return result, nil
@@ -1551,7 +1611,7 @@ func (dbm *databaseManager) ReadGovernanceAtNumber(num uint64, epoch uint64) (ui
 			return totalIdx[i], result, err
 		}
 	}
-	return 0, nil, errors.New("No governance data found")
+	return 0, nil, ErrGovernanceNotFound
 }
 
 func (dbm *databaseManager) WriteGovernanceState(b []byte) error {
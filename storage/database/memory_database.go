@@ -26,6 +26,9 @@ import (
 	"sync"
 )
 
+// errMemDBNotFound is returned if the key is not found in a memory database
+var errMemDBNotFound = errors.New("not found")
+
 /*
  * This is a test memory database. Do not use for any production it does not get persisted
  */
@@ -73,7 +76,7 @@ func (db *MemDB) Get(key []byte) ([]byte, error) {
 	if entry, ok := db.db[string(key)]; ok {
 		return common.CopyBytes(entry), nil
 	}
-	return nil, errors.New("not found")
+	return nil, errMemDBNotFound
 }
 
 func (db *MemDB) Keys() [][]byte {